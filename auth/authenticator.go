@@ -30,7 +30,8 @@ type Params struct {
 type Result struct {
 	fx.Out
 
-	Auth Authenticator
+	Auth   Authenticator
+	Cipher KeyCipher
 }
 
 type simpleAuth struct {
@@ -45,29 +46,38 @@ var _ Authenticator = (*simpleAuth)(nil)
 // Module
 var Module = fx.Module("authenticator", fx.Provide(newModule))
 
-func newModule(p Params) Result {
+func newModule(p Params) (Result, error) {
+	keyCipher, err := newKeyCipher(p.Cfg.MasterKey)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid master key: %w", err)
+	}
+
 	return Result{
 		Auth: &simpleAuth{
 			issuer:      p.Cfg.JWT.Issuer,
 			audience:    p.Cfg.JWT.Audience,
-			jwtDuration: time.Duration(p.Cfg.JWT.Duration),
+			jwtDuration: p.Cfg.JWT.Lifetime(),
 		},
-	}
+		Cipher: keyCipher,
+	}, nil
 }
 
 // GenerateJWT implements Authenticator.
 func (s *simpleAuth) GenerateJWT(subject, id, signingKey string) (string, error) {
+	now := time.Now()
 	c := &Claims{
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        id,
 			Issuer:    s.issuer,
 			Subject:   subject,
-			Audience:  []string{"dino"},
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			// ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.jwtDuration)),
+			Audience:  s.audience,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
 		},
 	}
+	if s.jwtDuration > 0 {
+		c.ExpiresAt = jwt.NewNumericDate(now.Add(s.jwtDuration))
+	}
 
 	tk := jwt.NewWithClaims(jwt.SigningMethodHS256, c)
 	ss, err := tk.SignedString([]byte(signingKey))
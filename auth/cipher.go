@@ -0,0 +1,85 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// masterKeyLength bytes of the decoded master key, AES-256
+const masterKeyLength = 32
+
+// ErrSealedKey sealed key is malformed or was not sealed with the master key
+var ErrSealedKey = errors.New("invalid sealed key")
+
+// KeyCipher seals tunnel signing keys with the server master key, a database
+// read alone does not reveal them. The additional data binds a sealed key to
+// its owner, a sealed key copied to another row does not open.
+type KeyCipher interface {
+	// Seal encrypt key for additional data, the nonce is prepended to the result
+	Seal(key, additionalData []byte) ([]byte, error)
+	// Open decrypt a key returned by Seal for the same additional data
+	Open(sealed, additionalData []byte) ([]byte, error)
+}
+
+type gcmCipher struct {
+	aead cipher.AEAD
+}
+
+// interface compliance
+var _ KeyCipher = (*gcmCipher)(nil)
+
+// newKeyCipher AES-GCM cipher of a base64 encoded 32 byte master key
+func newKeyCipher(masterKey string) (KeyCipher, error) {
+	if masterKey == "" {
+		return nil, errors.New("missing master key")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("base64.DecodeString: %w", err)
+	}
+	if len(key) != masterKeyLength {
+		return nil, fmt.Errorf("master key must be %d bytes: got %d", masterKeyLength, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("aes.NewCipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("cipher.NewGCM: %w", err)
+	}
+
+	return &gcmCipher{aead: aead}, nil
+}
+
+// Seal implements KeyCipher.
+func (g *gcmCipher) Seal(key, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, g.aead.NonceSize(), g.aead.NonceSize()+len(key)+g.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("rand.Read: %w", err)
+	}
+
+	return g.aead.Seal(nonce, nonce, key, additionalData), nil
+}
+
+// Open implements KeyCipher.
+func (g *gcmCipher) Open(sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < g.aead.NonceSize()+g.aead.Overhead() {
+		return nil, ErrSealedKey
+	}
+
+	nonce, ciphertext := sealed[:g.aead.NonceSize()], sealed[g.aead.NonceSize():]
+	key, err := g.aead.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrSealedKey, err)
+	}
+
+	return key, nil
+}
@@ -0,0 +1,71 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CipherSuite struct {
+	suite.Suite
+
+	cipher KeyCipher
+}
+
+func (suite *CipherSuite) SetupTest() {
+	masterKey := make([]byte, masterKeyLength)
+	_, err := rand.Read(masterKey)
+	suite.Require().NoError(err)
+
+	suite.cipher, err = newKeyCipher(base64.StdEncoding.EncodeToString(masterKey))
+	suite.Require().NoError(err)
+}
+
+func (suite *CipherSuite) TestSealOpen() {
+	key := []byte("tunnel signing key")
+
+	sealed, err := suite.cipher.Seal(key, []byte("tunnel"))
+	suite.Require().NoError(err)
+	suite.NotContains(string(sealed), string(key))
+
+	opened, err := suite.cipher.Open(sealed, []byte("tunnel"))
+	suite.Require().NoError(err)
+	suite.Equal(key, opened)
+}
+
+func (suite *CipherSuite) TestOpenRejectsTampered() {
+	sealed, err := suite.cipher.Seal([]byte("tunnel signing key"), []byte("tunnel"))
+	suite.Require().NoError(err)
+
+	sealed[len(sealed)-1] ^= 0xff
+	_, err = suite.cipher.Open(sealed, []byte("tunnel"))
+	suite.ErrorIs(err, ErrSealedKey)
+
+	_, err = suite.cipher.Open(sealed[:4], []byte("tunnel"))
+	suite.ErrorIs(err, ErrSealedKey)
+}
+
+func (suite *CipherSuite) TestOpenRejectsOtherAdditionalData() {
+	sealed, err := suite.cipher.Seal([]byte("tunnel signing key"), []byte("tunnel"))
+	suite.Require().NoError(err)
+
+	// a key moved to the row of another tunnel does not open there
+	for _, additionalData := range [][]byte{[]byte("other"), nil} {
+		_, err = suite.cipher.Open(sealed, additionalData)
+		suite.ErrorIs(err, ErrSealedKey)
+	}
+}
+
+func (suite *CipherSuite) TestInvalidMasterKey() {
+	_, err := newKeyCipher("")
+	suite.Error(err)
+
+	_, err = newKeyCipher(base64.StdEncoding.EncodeToString([]byte("short")))
+	suite.Error(err)
+}
+
+func TestCipherSuite(t *testing.T) {
+	suite.Run(t, new(CipherSuite))
+}
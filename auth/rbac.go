@@ -0,0 +1,81 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"slices"
+)
+
+// Role
+type Role string
+
+const (
+	// RoleAdmin manages everything including api keys
+	RoleAdmin Role = "admin"
+	// RoleOperator manages tunnels, routes and certificates
+	RoleOperator Role = "operator"
+	// RoleReadOnly reads tunnels and routes
+	RoleReadOnly Role = "read-only"
+)
+
+// ParseRole role of its name
+func ParseRole(name string) (Role, error) {
+	switch r := Role(name); r {
+	case RoleAdmin, RoleOperator, RoleReadOnly:
+		return r, nil
+	default:
+		return "", fmt.Errorf("unknown role %q", name)
+	}
+}
+
+// rank higher roles grant every permission of lower ones
+func (r Role) rank() int {
+	switch r {
+	case RoleAdmin:
+		return 3
+	case RoleOperator:
+		return 2
+	case RoleReadOnly:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Allows true when r grants at least the permissions of required
+func (r Role) Allows(required Role) bool {
+	return r.rank() > 0 && r.rank() >= required.rank()
+}
+
+// Principal caller of the management api
+type Principal struct {
+	Name string
+	Role Role
+	// Tunnels names of the tunnels the principal may access, empty grants every tunnel
+	Tunnels []string
+}
+
+// Scoped true when the principal is limited to some tunnels
+func (p Principal) Scoped() bool {
+	return len(p.Tunnels) > 0
+}
+
+// CanAccess true when the principal may access tunnel
+func (p Principal) CanAccess(tunnel string) bool {
+	return !p.Scoped() || slices.Contains(p.Tunnels, tunnel)
+}
+
+type contextKey string
+
+const principalKey contextKey = "dino_principal"
+
+// WithPrincipal
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey, p)
+}
+
+// PrincipalFromContext principal authenticated for the request
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey).(Principal)
+	return p, ok
+}
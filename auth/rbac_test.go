@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RBACSuite struct {
+	suite.Suite
+}
+
+func (suite *RBACSuite) TestParseRole() {
+	for _, name := range []string{"admin", "operator", "read-only"} {
+		role, err := ParseRole(name)
+		suite.Require().NoError(err)
+		suite.Equal(Role(name), role)
+	}
+
+	_, err := ParseRole("superuser")
+	suite.Error(err)
+}
+
+func (suite *RBACSuite) TestAllows() {
+	suite.True(RoleAdmin.Allows(RoleOperator))
+	suite.True(RoleOperator.Allows(RoleReadOnly))
+	suite.True(RoleReadOnly.Allows(RoleReadOnly))
+
+	suite.False(RoleOperator.Allows(RoleAdmin))
+	suite.False(RoleReadOnly.Allows(RoleOperator))
+	suite.False(Role("").Allows(Role("")))
+}
+
+func (suite *RBACSuite) TestCanAccess() {
+	unscoped := Principal{Name: "ci", Role: RoleOperator}
+	suite.False(unscoped.Scoped())
+	suite.True(unscoped.CanAccess("any"))
+
+	scoped := Principal{Name: "ci", Role: RoleOperator, Tunnels: []string{"web"}}
+	suite.True(scoped.Scoped())
+	suite.True(scoped.CanAccess("web"))
+	suite.False(scoped.CanAccess("db"))
+}
+
+func (suite *RBACSuite) TestPrincipalContext() {
+	_, ok := PrincipalFromContext(context.Background())
+	suite.False(ok)
+
+	p := Principal{Name: "ci", Role: RoleAdmin}
+	got, ok := PrincipalFromContext(WithPrincipal(context.Background(), p))
+	suite.True(ok)
+	suite.Equal(p, got)
+}
+
+func TestRBACSuite(t *testing.T) {
+	suite.Run(t, new(RBACSuite))
+}
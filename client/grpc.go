@@ -10,7 +10,11 @@ import (
 	"github.com/google/uuid"
 	"golang.org/x/net/http2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/durationpb"
+	pbapikeys "soft.structx.io/dino/pb/apikeys/v1"
+	pbcertificates "soft.structx.io/dino/pb/certificates/v1"
 	pbroutes "soft.structx.io/dino/pb/routes/v1"
 	pbtunnels "soft.structx.io/dino/pb/tunnels/v1"
 )
@@ -24,6 +28,10 @@ type Tunnel struct {
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt *time.Time
+
+	IsActive   bool
+	LastSeenAt *time.Time
+	RTT        time.Duration
 }
 
 type TunnelList struct {
@@ -43,21 +51,39 @@ type TunnelUpdate struct {
 	Name    string
 }
 
+type TunnelRotate struct {
+	Name string
+	// GracePeriod the previous token keeps verifying, zero revokes it right away
+	GracePeriod time.Duration
+}
+
 type RouteAdd struct {
 	Tunnel              string
 	Hostname            string
 	DestinationProtocol string
 	DestinationIP       string
 	DestinationPort     uint32
+	PublicPort          uint32
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        map[string]string
+	IdleTimeout         time.Duration
+	MaxLifetime         time.Duration
 	Enabled             bool
 }
 
 type RouteDel struct{}
 
 type Route struct {
-	Name    string
-	Enabled bool
-	Tunnel  string
+	Name         string
+	Enabled      bool
+	Tunnel       string
+	PublicPort   uint32
+	PathPrefix   string
+	StripPrefix  bool
+	MatchHeaders map[string]string
+	IdleTimeout  time.Duration
+	MaxLifetime  time.Duration
 }
 
 type RoutePartial struct {
@@ -73,21 +99,62 @@ type RouteUpdate struct {
 	DestinationProtocol string
 	DestinationIP       string
 	DestinationPort     uint32
+	PublicPort          uint32
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        map[string]string
+	IdleTimeout         time.Duration
+	MaxLifetime         time.Duration
 	Enabled             bool
 }
 
+type CertificatePut struct {
+	Hostname string
+	CertPEM  []byte
+	KeyPEM   []byte
+}
+
+type Certificate struct {
+	UID      uuid.UUID
+	Hostname string
+	NotAfter time.Time
+}
+
+type APIKeyAdd struct {
+	Name string
+	// Role admin, operator or read-only
+	Role string
+	// Tunnels the key is limited to, empty grants every tunnel
+	Tunnels []string
+}
+
+type APIKey struct {
+	UID       uuid.UUID
+	Name      string
+	Role      string
+	Tunnels   []string
+	CreatedAt time.Time
+}
+
 type Auth interface{}
 
 type SharedSecret struct {
 	Key string
 }
 
+type RotatedSecret struct {
+	Key string
+	// PreviousExpiresAt end of the grace period of the previous token, nil when revoked right away
+	PreviousExpiresAt *time.Time
+}
+
 type Client interface {
 	AddTunnel(context.Context, TunnelAdd) (Tunnel, Auth, error)
 	GetTunnel(context.Context, string) (Tunnel, error)
 	ListTunnels(context.Context, TunnelList) ([]TunnelPartial, error)
 	UpdateTunnel(context.Context, TunnelUpdate) (Tunnel, error)
 	DelTunnel(context.Context, string) error
+	RotateCredentials(context.Context, TunnelRotate) (Tunnel, Auth, error)
 
 	AddRoute(context.Context, RouteAdd) (Route, error)
 	GetRoute(context.Context, string) (Route, error)
@@ -95,12 +162,21 @@ type Client interface {
 	UpdateRoute(context.Context, RouteUpdate) (Route, error)
 	DelRoute(context.Context, string) error
 
+	PutCertificate(context.Context, CertificatePut) (Certificate, error)
+	DelCertificate(context.Context, string) error
+
+	// AddAPIKey returns the key, it is not retrievable afterwards
+	AddAPIKey(context.Context, APIKeyAdd) (APIKey, string, error)
+	ListAPIKeys(context.Context, int32, int32) ([]APIKey, error)
+	DelAPIKey(context.Context, string) error
+
 	// Close client conn
 	Close() error
 }
 
 type clientImpl struct {
 	target string
+	token  string
 
 	conn *grpc.ClientConn
 }
@@ -118,6 +194,32 @@ func WithTarget(t string) ClientOption {
 	}
 }
 
+// WithCredentials bearer token sent with every call, a root token or api key
+func WithCredentials(token string) ClientOption {
+	return func(c *clientImpl) {
+		c.token = token
+	}
+}
+
+// bearerCredentials
+type bearerCredentials struct {
+	token string
+}
+
+// interface compliance
+var _ credentials.PerRPCCredentials = (*bearerCredentials)(nil)
+
+// GetRequestMetadata implements credentials.PerRPCCredentials.
+func (b *bearerCredentials) GetRequestMetadata(context.Context, ...string) (map[string]string, error) {
+	return map[string]string{"authorization": "Bearer " + b.token}, nil
+}
+
+// RequireTransportSecurity implements credentials.PerRPCCredentials.
+func (b *bearerCredentials) RequireTransportSecurity() bool {
+	// the management api is served over h2c
+	return false
+}
+
 // New
 func New(options ...ClientOption) (Client, error) {
 	cli := &clientImpl{}
@@ -133,11 +235,17 @@ func New(options ...ClientOption) (Client, error) {
 		},
 	}
 
-	conn, err := grpc.NewClient(cli.target,
+	dialOpts := []grpc.DialOption{
 		grpc.WithContextDialer(func(ctx context.Context, s string) (net.Conn, error) {
 			return tr.DialTLSContext(ctx, "tcp", s, nil)
 		}),
-		grpc.WithTransportCredentials(insecure.NewCredentials()))
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+	if cli.token != "" {
+		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(&bearerCredentials{token: cli.token}))
+	}
+
+	conn, err := grpc.NewClient(cli.target, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("grpc.NewClient: %w", err)
 	}
@@ -240,6 +348,32 @@ func (c *clientImpl) DelTunnel(ctx context.Context, tunnelName string) error {
 	return nil
 }
 
+// RotateCredentials
+func (c *clientImpl) RotateCredentials(ctx context.Context, args TunnelRotate) (Tunnel, Auth, error) {
+
+	cli := pbtunnels.NewTunnelServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	req := &pbtunnels.RotateTunnelCredentialsRequest{
+		Name:        args.Name,
+		GracePeriod: durationpb.New(args.GracePeriod),
+	}
+	resp, err := cli.RotateTunnelCredentials(timeout, req)
+	if err != nil {
+		return Tunnel{}, nil, fmt.Errorf("failed to execute gRPC rotate tunnel credentials: %w", err)
+	}
+
+	secret := RotatedSecret{Key: resp.GetSecretKey()}
+	if resp.PreviousExpiresAt != nil {
+		previousExpiresAt := resp.PreviousExpiresAt.AsTime()
+		secret.PreviousExpiresAt = &previousExpiresAt
+	}
+
+	return dtoTunnel(resp.Tunnel), secret, nil
+}
+
 // AddRoute
 func (c *clientImpl) AddRoute(ctx context.Context, args RouteAdd) (Route, error) {
 
@@ -255,6 +389,12 @@ func (c *clientImpl) AddRoute(ctx context.Context, args RouteAdd) (Route, error)
 			DestProtocol: args.DestinationProtocol,
 			DestAddr:     args.DestinationIP,
 			DestPort:     args.DestinationPort,
+			PublicPort:   args.PublicPort,
+			PathPrefix:   args.PathPrefix,
+			StripPrefix:  args.StripPrefix,
+			MatchHeaders: args.MatchHeaders,
+			IdleTimeout:  durationpb.New(args.IdleTimeout),
+			MaxLifetime:  durationpb.New(args.MaxLifetime),
 		},
 	}
 
@@ -313,6 +453,13 @@ func (c *clientImpl) UpdateRoute(ctx context.Context, args RouteUpdate) (Route,
 			DestProtocol: args.DestinationProtocol,
 			DestAddr:     args.DestinationIP,
 			DestPort:     args.DestinationPort,
+			PublicPort:   args.PublicPort,
+			PathPrefix:   args.PathPrefix,
+			StripPrefix:  args.StripPrefix,
+			MatchHeaders: args.MatchHeaders,
+			IdleTimeout:  durationpb.New(args.IdleTimeout),
+			MaxLifetime:  durationpb.New(args.MaxLifetime),
+			Enabled:      args.Enabled,
 		},
 	}
 
@@ -340,6 +487,104 @@ func (c *clientImpl) DelRoute(ctx context.Context, hostname string) error {
 	return nil
 }
 
+// PutCertificate
+func (c *clientImpl) PutCertificate(ctx context.Context, args CertificatePut) (Certificate, error) {
+	cli := pbcertificates.NewCertificateServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	req := &pbcertificates.PutCertificateRequest{
+		Put: &pbcertificates.CertificatePut{
+			Hostname: args.Hostname,
+			CertPem:  args.CertPEM,
+			KeyPem:   args.KeyPEM,
+		},
+	}
+
+	resp, err := cli.PutCertificate(timeout, req)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("failed to execute gRPC put certificate: %w", err)
+	}
+
+	return dtoCertificate(resp.Certificate), nil
+}
+
+// DelCertificate
+func (c *clientImpl) DelCertificate(ctx context.Context, hostname string) error {
+	cli := pbcertificates.NewCertificateServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	req := &pbcertificates.DeleteCertificateRequest{Hostname: hostname}
+	_, err := cli.DeleteCertificate(timeout, req)
+	if err != nil {
+		return fmt.Errorf("failed to execute gRPC delete certificate: %w", err)
+	}
+
+	return nil
+}
+
+// AddAPIKey
+func (c *clientImpl) AddAPIKey(ctx context.Context, args APIKeyAdd) (APIKey, string, error) {
+
+	cli := pbapikeys.NewAPIKeyServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	req := &pbapikeys.CreateAPIKeyRequest{
+		Create: &pbapikeys.APIKeyCreate{
+			Name:    args.Name,
+			Role:    args.Role,
+			Tunnels: args.Tunnels,
+		},
+	}
+	resp, err := cli.CreateAPIKey(timeout, req)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to execute gRPC create api key: %w", err)
+	}
+
+	return dtoAPIKey(resp.ApiKey), resp.GetKey(), nil
+}
+
+// ListAPIKeys
+func (c *clientImpl) ListAPIKeys(ctx context.Context, limit int32, offset int32) ([]APIKey, error) {
+
+	cli := pbapikeys.NewAPIKeyServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	resp, err := cli.ListAPIKeys(timeout, &pbapikeys.ListAPIKeysRequest{Limit: limit, Offset: offset})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute gRPC list api keys: %w", err)
+	}
+
+	keys := make([]APIKey, 0, len(resp.ApiKeys))
+	for _, k := range resp.ApiKeys {
+		keys = append(keys, dtoAPIKey(k))
+	}
+	return keys, nil
+}
+
+// DelAPIKey
+func (c *clientImpl) DelAPIKey(ctx context.Context, name string) error {
+
+	cli := pbapikeys.NewAPIKeyServiceClient(c.conn)
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	_, err := cli.DeleteAPIKey(timeout, &pbapikeys.DeleteAPIKeyRequest{Name: name})
+	if err != nil {
+		return fmt.Errorf("failed to execute gRPC delete api key: %w", err)
+	}
+
+	return nil
+}
+
 // Close
 func (c *clientImpl) Close() error {
 	return c.conn.Close()
@@ -365,19 +610,33 @@ func dtoTunnel(t *pbtunnels.Tunnel) Tunnel {
 		updatedAt := t.UpdatedAt.AsTime()
 		updatedAtPtr = &updatedAt
 	}
+	var lastSeenAtPtr *time.Time
+	if t.LastSeenAt.IsValid() {
+		lastSeenAt := t.LastSeenAt.AsTime()
+		lastSeenAtPtr = &lastSeenAt
+	}
 	return Tunnel{
-		UID:       uuid.MustParse(t.Id),
-		Name:      t.Name,
-		CreatedAt: t.CreatedAt.AsTime(),
-		UpdatedAt: updatedAtPtr,
+		UID:        uuid.MustParse(t.Id),
+		Name:       t.Name,
+		CreatedAt:  t.CreatedAt.AsTime(),
+		UpdatedAt:  updatedAtPtr,
+		IsActive:   t.IsActive,
+		LastSeenAt: lastSeenAtPtr,
+		RTT:        t.Rtt.AsDuration(),
 	}
 }
 
 func dtoRoute(r *pbroutes.Route) Route {
 	return Route{
-		Name:    r.Name,
-		Enabled: r.Enabled,
-		Tunnel:  r.Tunnel,
+		Name:         r.Name,
+		Enabled:      r.Enabled,
+		Tunnel:       r.Tunnel,
+		PublicPort:   r.PublicPort,
+		PathPrefix:   r.PathPrefix,
+		StripPrefix:  r.StripPrefix,
+		MatchHeaders: r.MatchHeaders,
+		IdleTimeout:  r.GetIdleTimeout().AsDuration(),
+		MaxLifetime:  r.GetMaxLifetime().AsDuration(),
 	}
 }
 
@@ -395,3 +654,21 @@ func dtoRoutePartial(p *pbroutes.RoutePartial) RoutePartial {
 		Hostname: p.Hostname,
 	}
 }
+
+func dtoCertificate(c *pbcertificates.Certificate) Certificate {
+	return Certificate{
+		UID:      uuid.MustParse(c.Uid),
+		Hostname: c.Hostname,
+		NotAfter: c.NotAfter.AsTime(),
+	}
+}
+
+func dtoAPIKey(k *pbapikeys.APIKey) APIKey {
+	return APIKey{
+		UID:       uuid.MustParse(k.Uid),
+		Name:      k.Name,
+		Role:      k.Role,
+		Tunnels:   k.Tunnels,
+		CreatedAt: k.CreatedAt.AsTime(),
+	}
+}
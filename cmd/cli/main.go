@@ -4,6 +4,7 @@ import (
 	"soft.structx.io/dino/cmd/cli/sub"
 
 	// do not alter order
+	_ "soft.structx.io/dino/cmd/cli/sub/apikey"
 	_ "soft.structx.io/dino/cmd/cli/sub/route"
 	_ "soft.structx.io/dino/cmd/cli/sub/tunnel"
 	_ "soft.structx.io/dino/cmd/cli/sub/tunnel/credentials"
@@ -0,0 +1,51 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"soft.structx.io/dino/client"
+	"soft.structx.io/dino/logging"
+)
+
+var (
+	addCmd = &cobra.Command{
+		Use:   "add [NAME]",
+		Short: "add api key",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			name := args[0]
+			if len(name) < 1 {
+				return fmt.Errorf("unexpected name length: %d", len(name))
+			}
+
+			logger := logging.FromContext(ctx)
+			cli := client.FromContext(ctx)
+
+			logger.Debug("add api key", zap.String("name", name), zap.String("role", roleFlag), zap.Strings("tunnels", tunnelsFlag))
+
+			timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+			defer cancel()
+
+			apiKey, key, err := cli.AddAPIKey(timeout, client.APIKeyAdd{
+				Name:    name,
+				Role:    roleFlag,
+				Tunnels: tunnelsFlag,
+			})
+			if err != nil {
+				return fmt.Errorf("cli.AddAPIKey: %w", err)
+			}
+
+			logger.Info("api key added", zap.Any("api_key", apiKey))
+			// the key is only shown once
+			fmt.Println(key)
+
+			return nil
+		},
+	}
+)
@@ -0,0 +1,39 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"soft.structx.io/dino/client"
+	"soft.structx.io/dino/logging"
+)
+
+var (
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "list api keys",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			logger := logging.FromContext(ctx)
+			cli := client.FromContext(ctx)
+
+			timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+			defer cancel()
+
+			keys, err := cli.ListAPIKeys(timeout, limitFlag, offsetFlag)
+			if err != nil {
+				return fmt.Errorf("cli.ListAPIKeys: %w", err)
+			}
+
+			for _, k := range keys {
+				logger.Info("api keys", zap.Any("api_key", k))
+			}
+
+			return nil
+		},
+	}
+)
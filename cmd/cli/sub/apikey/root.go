@@ -0,0 +1,34 @@
+package apikey
+
+import (
+	"github.com/spf13/cobra"
+	"soft.structx.io/dino/cmd/cli/sub"
+)
+
+var (
+	roleFlag    string
+	tunnelsFlag []string
+
+	limitFlag  int32
+	offsetFlag int32
+
+	APIKeyCmd = &cobra.Command{
+		Use:     "apikey",
+		Aliases: []string{"apikeys", "key"},
+		Short:   "api key command group",
+	}
+)
+
+func init() {
+	addCmd.Flags().StringVar(&roleFlag, "role", "read-only", "api key role, admin, operator or read-only")
+	addCmd.Flags().StringSliceVar(&tunnelsFlag, "tunnels", nil, "tunnels the key is limited to, empty grants every tunnel")
+
+	listCmd.Flags().Int32Var(&limitFlag, "limit", 10, "limit response items")
+	listCmd.Flags().Int32Var(&offsetFlag, "offset", 0, "offset response items")
+
+	APIKeyCmd.AddCommand(addCmd)
+	APIKeyCmd.AddCommand(listCmd)
+	APIKeyCmd.AddCommand(delCmd)
+
+	sub.RootCmd.AddCommand(APIKeyCmd)
+}
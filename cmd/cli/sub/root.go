@@ -15,10 +15,16 @@ import (
 	"soft.structx.io/dino/logging"
 )
 
+// apiKeyEnv fallback of the api key flag, keeps keys out of shell history
+const apiKeyEnv = "DINO_API_KEY"
+
 var (
 	targetFlag     string
 	targetFlagName string = "target"
 
+	apiKeyFlag     string
+	apiKeyFlagName string = "api-key"
+
 	RootCmd = &cobra.Command{
 		Use: "dino",
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
@@ -32,8 +38,17 @@ var (
 				return fmt.Errorf("failed to get persistent flag: %w", err)
 			}
 
+			apiKey, err := cmd.Flags().GetString(apiKeyFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get persistent flag: %w", err)
+			}
+			if apiKey == "" {
+				apiKey = os.Getenv(apiKeyEnv)
+			}
+
 			if cli, err := client.New(
 				client.WithTarget(target),
+				client.WithCredentials(apiKey),
 			); err != nil {
 				return fmt.Errorf("client.New: %w", err)
 			} else {
@@ -58,6 +73,7 @@ func init() {
 	RootCmd.AddCommand(closeCmd)
 
 	RootCmd.PersistentFlags().StringVarP(&targetFlag, targetFlagName, "t", "api.dino.docker:8000", "target addr")
+	RootCmd.PersistentFlags().StringVar(&apiKeyFlag, apiKeyFlagName, "", "api key or root token, defaults to $"+apiKeyEnv)
 }
 
 func Execute() {
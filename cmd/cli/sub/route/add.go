@@ -19,6 +19,12 @@ const (
 	protocolFlagName string = "protocol"
 	addrFlagName     string = "address"
 	tunnelFlagName   string = "tunnel"
+	publicFlagName   string = "public-port"
+	prefixFlagName   string = "path-prefix"
+	stripFlagName    string = "strip-prefix"
+	headerFlagName   string = "match-header"
+	idleFlagName     string = "idle-timeout"
+	lifetimeFlagName string = "max-lifetime"
 )
 
 var (
@@ -26,6 +32,12 @@ var (
 	protocolFlag string
 	addrFlag     string
 	tunnelFlag   string
+	publicFlag   uint32
+	prefixFlag   string
+	stripFlag    bool
+	headerFlag   map[string]string
+	idleFlag     time.Duration
+	lifetimeFlag time.Duration
 )
 
 func init() {
@@ -33,6 +45,12 @@ func init() {
 	addCmd.Flags().StringVarP(&protocolFlag, protocolFlagName, "r", "", "route local protocol (http)")
 	addCmd.Flags().StringVarP(&addrFlag, addrFlagName, "a", "", "route local addr (localhost:8080)")
 	addCmd.Flags().StringVarP(&tunnelFlag, tunnelFlagName, "x", "", "tunnel flag name (K3D_01)")
+	addCmd.Flags().Uint32VarP(&publicFlag, publicFlagName, "l", 0, "server public port for tcp and udp routes (5432)")
+	addCmd.Flags().StringVar(&prefixFlag, prefixFlagName, "", "request path prefix for http routes (/v2/)")
+	addCmd.Flags().BoolVar(&stripFlag, stripFlagName, false, "remove path prefix before forwarding")
+	addCmd.Flags().StringToStringVar(&headerFlag, headerFlagName, nil, "required request header for http routes (X-Env=preview)")
+	addCmd.Flags().DurationVar(&idleFlag, idleFlagName, 0, "close sessions without traffic for this long (15m)")
+	addCmd.Flags().DurationVar(&lifetimeFlag, lifetimeFlagName, 0, "close sessions open this long (24h)")
 
 	_ = addCmd.MarkFlagRequired(hostnameFlagName)
 	_ = addCmd.MarkFlagRequired(protocolFlagName)
@@ -71,6 +89,40 @@ var (
 				return fmt.Errorf("missing or unexpected tunnel value %s, err :%w", tunnel, err)
 			}
 
+			publicPort, err := cmd.Flags().GetUint32(publicFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get public port flag: %w", err)
+			}
+
+			if (protocol == "tcp" || protocol == "udp") && publicPort == 0 {
+				return fmt.Errorf("missing public port for %s route", protocol)
+			}
+
+			pathPrefix, err := cmd.Flags().GetString(prefixFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get path prefix flag: %w", err)
+			}
+
+			stripPrefix, err := cmd.Flags().GetBool(stripFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get strip prefix flag: %w", err)
+			}
+
+			matchHeaders, err := cmd.Flags().GetStringToString(headerFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get match header flag: %w", err)
+			}
+
+			idleTimeout, err := cmd.Flags().GetDuration(idleFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get idle timeout flag: %w", err)
+			}
+
+			maxLifetime, err := cmd.Flags().GetDuration(lifetimeFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get max lifetime flag: %w", err)
+			}
+
 			localHost, localPort, err := net.SplitHostPort(addrFlag)
 			if err != nil {
 				return fmt.Errorf("net.SplitHostPort: %w", err)
@@ -90,6 +142,12 @@ var (
 				DestinationProtocol: protocol,
 				DestinationIP:       localHost,
 				DestinationPort:     uint32(portU32),
+				PublicPort:          publicPort,
+				PathPrefix:          pathPrefix,
+				StripPrefix:         stripPrefix,
+				MatchHeaders:        matchHeaders,
+				IdleTimeout:         idleTimeout,
+				MaxLifetime:         maxLifetime,
 				Enabled:             true,
 			}
 
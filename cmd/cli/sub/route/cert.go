@@ -0,0 +1,127 @@
+package route
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"soft.structx.io/dino/client"
+	"soft.structx.io/dino/cmd/cli/sub/completion"
+	"soft.structx.io/dino/logging"
+)
+
+const (
+	certFlagName string = "cert"
+	keyFlagName  string = "key"
+)
+
+var (
+	certFlag string
+	keyFlag  string
+)
+
+func init() {
+	certSetCmd.Flags().StringVarP(&certFlag, certFlagName, "c", "", "PEM certificate chain file")
+	certSetCmd.Flags().StringVarP(&keyFlag, keyFlagName, "k", "", "PEM private key file")
+
+	_ = certSetCmd.MarkFlagRequired(certFlagName)
+	_ = certSetCmd.MarkFlagRequired(keyFlagName)
+
+	certCmd.AddCommand(certSetCmd)
+	certCmd.AddCommand(certDelCmd)
+}
+
+var (
+	certCmd = &cobra.Command{
+		Use:     "cert",
+		Aliases: []string{"certificate"},
+		Short:   "route certificate command group",
+	}
+
+	certSetCmd = &cobra.Command{
+		Use:               "set [HOSTNAME]",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.RouteHostnameFunc,
+		Short:             "set route certificate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			hostname := args[0]
+			if len(hostname) < 1 {
+				return fmt.Errorf("unexpected hostname length: %d", len(hostname))
+			}
+
+			logger := logging.FromContext(ctx)
+			cli := client.FromContext(ctx)
+
+			certPath, err := cmd.Flags().GetString(certFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get cert flag: %w", err)
+			}
+
+			keyPath, err := cmd.Flags().GetString(keyFlagName)
+			if err != nil {
+				return fmt.Errorf("failed to get key flag: %w", err)
+			}
+
+			certPEM, err := os.ReadFile(certPath)
+			if err != nil {
+				return fmt.Errorf("os.ReadFile: %w", err)
+			}
+
+			keyPEM, err := os.ReadFile(keyPath)
+			if err != nil {
+				return fmt.Errorf("os.ReadFile: %w", err)
+			}
+
+			timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+			defer cancel()
+
+			cert, err := cli.PutCertificate(timeout, client.CertificatePut{
+				Hostname: hostname,
+				CertPEM:  certPEM,
+				KeyPEM:   keyPEM,
+			})
+			if err != nil {
+				return fmt.Errorf("cli.PutCertificate: %w", err)
+			}
+
+			logger.Info("success set certificate", zap.Any("certificate", cert))
+
+			return nil
+		},
+	}
+
+	certDelCmd = &cobra.Command{
+		Use:               "delete [HOSTNAME]",
+		Aliases:           []string{"del"},
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completion.RouteHostnameFunc,
+		Short:             "delete route certificate",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			hostname := args[0]
+			if len(hostname) < 1 {
+				return fmt.Errorf("unexpected hostname length: %d", len(hostname))
+			}
+
+			logger := logging.FromContext(ctx)
+			cli := client.FromContext(ctx)
+
+			timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+			defer cancel()
+
+			if err := cli.DelCertificate(timeout, hostname); err != nil {
+				return fmt.Errorf("cli.DelCertificate: %w", err)
+			}
+
+			logger.Info("success")
+
+			return nil
+		},
+	}
+)
@@ -18,6 +18,7 @@ func init() {
 	routeCmd.AddCommand(getCmd)
 	routeCmd.AddCommand(listCmd)
 	routeCmd.AddCommand(updateCmd)
+	routeCmd.AddCommand(certCmd)
 
 	sub.RootCmd.AddCommand(routeCmd)
 }
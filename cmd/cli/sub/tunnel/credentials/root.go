@@ -6,6 +6,8 @@ import (
 )
 
 func init() {
+	rotateCmd.Flags().DurationVar(&gracePeriodFlag, "grace", 0, "time the previous token keeps working, connected agents using it are disconnected afterwards")
+
 	credCmd.AddCommand(rotateCmd)
 
 	tunnel.TunnelCmd.AddCommand(credCmd)
@@ -15,5 +17,6 @@ var (
 	credCmd = &cobra.Command{
 		Use:     "credentials",
 		Aliases: []string{"c", "creds", "cred"},
+		Short:   "tunnel credentials command group",
 	}
 )
@@ -1,10 +1,59 @@
 package credentials
 
-import "github.com/spf13/cobra"
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+	"soft.structx.io/dino/client"
+	"soft.structx.io/dino/cmd/cli/sub/completion"
+	"soft.structx.io/dino/logging"
+)
 
 var (
+	gracePeriodFlag time.Duration
+
 	rotateCmd = &cobra.Command{
-		Use:     "rotate",
-		Aliases: []string{"r"},
+		Use:               "rotate [NAME]",
+		Aliases:           []string{"r"},
+		Short:             "rotate tunnel credentials",
+		ValidArgsFunction: completion.TunnelNameFunc,
+		Args:              cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+
+			name := args[0]
+			if len(name) < 1 {
+				return fmt.Errorf("unexpected name length: %d", len(name))
+			}
+
+			if gracePeriodFlag < 0 {
+				return fmt.Errorf("grace period must not be negative: %s", gracePeriodFlag)
+			}
+
+			logger := logging.FromContext(ctx)
+			cli := client.FromContext(ctx)
+
+			logger.Debug("rotate tunnel credentials", zap.String("tunnel_name", name), zap.Duration("grace_period", gracePeriodFlag))
+
+			timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+			defer cancel()
+
+			tunnel, auth, err := cli.RotateCredentials(timeout, client.TunnelRotate{
+				Name:        name,
+				GracePeriod: gracePeriodFlag,
+			})
+			if err != nil {
+				logger.Error("cli.RotateCredentials", zap.Error(err))
+				return fmt.Errorf("cli.RotateCredentials: %w", err)
+			}
+
+			logger.Info("tunnel credentials rotated", zap.Any("tunnel", tunnel))
+			logger.Info("tunnel credentials", zap.Any("auth_details", auth))
+
+			return nil
+		},
 	}
 )
@@ -10,10 +10,13 @@ import (
 	"soft.structx.io/dino/database/migrate"
 	"soft.structx.io/dino/gateway"
 	"soft.structx.io/dino/gateway/interceptors"
+	"soft.structx.io/dino/internal/apikeys"
+	"soft.structx.io/dino/internal/certificates"
 	"soft.structx.io/dino/internal/routes"
 	"soft.structx.io/dino/internal/tunnel"
 	"soft.structx.io/dino/logging"
 	"soft.structx.io/dino/migrations"
+	"soft.structx.io/dino/pki"
 	"soft.structx.io/dino/proxy"
 	"soft.structx.io/dino/pubsub"
 	"soft.structx.io/dino/sessions"
@@ -34,11 +37,14 @@ var opts = fx.Options(
 	verifier.Module, // jwt verifier
 	pubsub.Module,   // pubsub broker
 
-	migrations.Module, // database migrations fixtures
-	migrate.Module,    // pgx database migrations
-	tunnel.Module,     // tunnel service logic
-	routes.Module,     // routes service logic
-	sessions.Module,   // tunnel session manager
+	migrations.Module,   // database migrations fixtures
+	migrate.Module,      // pgx database migrations
+	tunnel.Module,       // tunnel service logic
+	routes.Module,       // routes service logic
+	certificates.Module, // route certificate store
+	apikeys.Module,      // management api keys
+	sessions.Module,     // tunnel session manager
+	pki.Module,          // internal certificate authority
 
 	proxy.Module,        // http proxy handler
 	interceptors.Module, // gateway interceptors
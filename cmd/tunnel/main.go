@@ -1,9 +1,13 @@
 package main
 
 import (
+	"github.com/structx/teapot"
+	teafx "github.com/structx/teapot/adapter/fx"
 	"go.uber.org/fx"
+	"go.uber.org/fx/fxevent"
 	"soft.structx.io/dino/logging"
 	"soft.structx.io/dino/setup"
+	"soft.structx.io/dino/tunnel/metrics"
 	"soft.structx.io/dino/tunnel/router"
 	"soft.structx.io/dino/tunnel/rpc/client"
 	"soft.structx.io/dino/tunnel/sessions"
@@ -11,10 +15,13 @@ import (
 
 var opts = fx.Options(
 	setup.Module,
-	logging.Module,
+	logging.Module, fx.WithLogger(func(l *teapot.Logger) fxevent.Logger {
+		return teafx.New(l)
+	}),
 	router.Module,
 	client.Module,
 	sessions.Module,
+	metrics.Module,
 )
 
 func main() {
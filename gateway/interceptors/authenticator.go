@@ -1 +1,343 @@
 package interceptors
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/structx/teapot"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/gateway"
+	"soft.structx.io/dino/internal/apikeys"
+	"soft.structx.io/dino/internal/routes"
+	pbapikeys "soft.structx.io/dino/pb/apikeys/v1"
+	pbcertificates "soft.structx.io/dino/pb/certificates/v1"
+	pbroutes "soft.structx.io/dino/pb/routes/v1"
+	pbtunnels "soft.structx.io/dino/pb/tunnels/v1"
+)
+
+const (
+	authorizationHeader = "authorization"
+	bearerPrefix        = "Bearer "
+)
+
+// rootPrincipal caller presenting the configured root token
+var rootPrincipal = auth.Principal{Name: "root", Role: auth.RoleAdmin}
+
+// tunnelsFunc names of the tunnels a request touches
+type tunnelsFunc func(context.Context, any) ([]string, error)
+
+// permission required to call an rpc
+type permission struct {
+	role auth.Role
+	// tunnels of the request, nil limits the rpc to unscoped principals
+	tunnels tunnelsFunc
+}
+
+type authInterceptor struct {
+	l *teapot.Logger
+
+	rootToken string
+	keys      apikeys.Service
+
+	// public rpcs served without credentials
+	public map[string]bool
+	// policy of each rpc, unknown rpcs require an unscoped admin
+	policy map[string]permission
+}
+
+func newAuthInterceptor(logger *teapot.Logger, rootToken string, keys apikeys.Service, routeService routes.Service) *authInterceptor {
+	return &authInterceptor{
+		l:         logger,
+		rootToken: rootToken,
+		keys:      keys,
+		public: map[string]bool{
+			healthpb.Health_Check_FullMethodName: true,
+			healthpb.Health_List_FullMethodName:  true,
+			healthpb.Health_Watch_FullMethodName: true,
+		},
+		policy: map[string]permission{
+			pbtunnels.TunnelService_CreateTunnel_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: requestTunnels(func(in *pbtunnels.CreateTunnelRequest) []string {
+					return []string{in.GetTunnelName()}
+				}),
+			},
+			pbtunnels.TunnelService_GetTunnel_FullMethodName: {
+				role: auth.RoleReadOnly,
+				tunnels: requestTunnels(func(in *pbtunnels.GetTunnelRequest) []string {
+					return []string{in.GetName()}
+				}),
+			},
+			pbtunnels.TunnelService_ListTunnels_FullMethodName: {
+				role: auth.RoleReadOnly,
+			},
+			pbtunnels.TunnelService_UpdateTunnel_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: requestTunnels(func(in *pbtunnels.UpdateTunnelRequest) []string {
+					update := in.GetTunnelUpdate()
+					return []string{update.GetOldName(), update.GetNewName()}
+				}),
+			},
+			pbtunnels.TunnelService_DeleteTunnel_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: requestTunnels(func(in *pbtunnels.DeleteTunnelRequest) []string {
+					return []string{in.GetName()}
+				}),
+			},
+			pbtunnels.TunnelService_RotateTunnelCredentials_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: requestTunnels(func(in *pbtunnels.RotateTunnelCredentialsRequest) []string {
+					return []string{in.GetName()}
+				}),
+			},
+
+			pbroutes.RouteService_CreateRoute_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: requestTunnels(func(in *pbroutes.CreateRouteRequest) []string {
+					return []string{in.GetCreate().GetTunnel()}
+				}),
+			},
+			pbroutes.RouteService_GetRoute_FullMethodName: {
+				role: auth.RoleReadOnly,
+				tunnels: routeTunnels(routeService, func(in *pbroutes.GetRouteRequest) string {
+					return in.GetHostname()
+				}),
+			},
+			pbroutes.RouteService_ListRoutes_FullMethodName: {
+				role: auth.RoleReadOnly,
+				tunnels: requestTunnels(func(in *pbroutes.ListRoutesRequest) []string {
+					return []string{in.GetTunnel()}
+				}),
+			},
+			pbroutes.RouteService_UpdateRoute_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: routeTunnels(routeService, func(in *pbroutes.UpdateRouteRequest) string {
+					return in.GetUpdate().GetUid()
+				}),
+			},
+			pbroutes.RouteService_DeleteRoute_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: routeTunnels(routeService, func(in *pbroutes.DeleteRouteRequest) string {
+					return in.GetHostname()
+				}),
+			},
+
+			pbcertificates.CertificateService_PutCertificate_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: routeTunnels(routeService, func(in *pbcertificates.PutCertificateRequest) string {
+					return in.GetPut().GetHostname()
+				}),
+			},
+			pbcertificates.CertificateService_DeleteCertificate_FullMethodName: {
+				role: auth.RoleOperator,
+				tunnels: routeTunnels(routeService, func(in *pbcertificates.DeleteCertificateRequest) string {
+					return in.GetHostname()
+				}),
+			},
+
+			pbapikeys.APIKeyService_CreateAPIKey_FullMethodName: {role: auth.RoleAdmin},
+			pbapikeys.APIKeyService_ListAPIKeys_FullMethodName:  {role: auth.RoleAdmin},
+			pbapikeys.APIKeyService_DeleteAPIKey_FullMethodName: {role: auth.RoleAdmin},
+		},
+	}
+}
+
+// requestTunnels tunnels named by a request of type T
+func requestTunnels[T any](names func(T) []string) tunnelsFunc {
+	return func(_ context.Context, req any) ([]string, error) {
+		in, ok := req.(T)
+		if !ok {
+			return nil, fmt.Errorf("unexpected request type %T", req)
+		}
+		return names(in), nil
+	}
+}
+
+// routeTunnels tunnels serving the route referenced by a request of type T
+func routeTunnels[T any](routeService routes.Service, ref func(T) string) tunnelsFunc {
+	return func(ctx context.Context, req any) ([]string, error) {
+		in, ok := req.(T)
+		if !ok {
+			return nil, fmt.Errorf("unexpected request type %T", req)
+		}
+		return routeService.Tunnels(ctx, ref(in))
+	}
+}
+
+// UnaryInterceptor
+func (ai *authInterceptor) UnaryInterceptor() gateway.UnaryInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx, err := ai.authorize(ctx, info.FullMethod, req)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamInterceptor
+func (ai *authInterceptor) StreamInterceptor() gateway.StreamInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if ai.public[info.FullMethod] {
+			return handler(srv, ss)
+		}
+
+		ctx := ss.Context()
+		principal, perm, err := ai.permit(ctx, info.FullMethod)
+		if err != nil {
+			return err
+		}
+		if principal.Scoped() && perm.tunnels == nil {
+			return gateway.ErrPermissionDenied
+		}
+
+		ctx = auth.WithPrincipal(ctx, principal)
+		stream := &principalStream{ServerStream: ss, ctx: ctx}
+		if !principal.Scoped() {
+			return handler(srv, stream)
+		}
+
+		// stream requests are read by the handler, every received message is
+		// checked against the scope before the handler sees it
+		return handler(srv, &scopedStream{
+			principalStream: stream,
+			authorize: func(msg any) error {
+				return ai.authorizeTunnels(ctx, principal, perm, info.FullMethod, msg)
+			},
+		})
+	}
+}
+
+// authorize authenticate the caller and check it may call method with req
+func (ai *authInterceptor) authorize(ctx context.Context, method string, req any) (context.Context, error) {
+	if ai.public[method] {
+		return ctx, nil
+	}
+
+	principal, perm, err := ai.permit(ctx, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if principal.Scoped() {
+		if err := ai.authorizeTunnels(ctx, principal, perm, method, req); err != nil {
+			return nil, err
+		}
+	}
+
+	return auth.WithPrincipal(ctx, principal), nil
+}
+
+// permit authenticate the caller and check its role allows method
+func (ai *authInterceptor) permit(ctx context.Context, method string) (auth.Principal, permission, error) {
+	principal, err := ai.authenticate(ctx)
+	if err != nil {
+		return auth.Principal{}, permission{}, err
+	}
+
+	perm, ok := ai.policy[method]
+	if !ok {
+		perm = permission{role: auth.RoleAdmin}
+	}
+
+	if !principal.Role.Allows(perm.role) {
+		ai.l.Debug("role denied", teapot.String("principal", principal.Name),
+			teapot.String("role", string(principal.Role)), teapot.String("full_method", method))
+		return auth.Principal{}, permission{}, gateway.ErrPermissionDenied
+	}
+
+	return principal, perm, nil
+}
+
+// authorizeTunnels check every tunnel req touches is in the scope of principal
+func (ai *authInterceptor) authorizeTunnels(ctx context.Context, principal auth.Principal, perm permission, method string, req any) error {
+	if perm.tunnels == nil || req == nil {
+		return gateway.ErrPermissionDenied
+	}
+
+	tunnels, err := perm.tunnels(ctx, req)
+	if err != nil {
+		ai.l.Error("resolve request tunnels", teapot.String("full_method", method), teapot.Error(err))
+		return gateway.ErrPermissionDenied
+	}
+	if len(tunnels) == 0 {
+		// nothing ties the request to the scope of the principal
+		ai.l.Debug("no request tunnels", teapot.String("principal", principal.Name), teapot.String("full_method", method))
+		return gateway.ErrPermissionDenied
+	}
+
+	for _, tunnel := range tunnels {
+		if !principal.CanAccess(tunnel) {
+			ai.l.Debug("tunnel denied", teapot.String("principal", principal.Name),
+				teapot.String("tunnel", tunnel), teapot.String("full_method", method))
+			return gateway.ErrPermissionDenied
+		}
+	}
+
+	return nil
+}
+
+// authenticate principal of the bearer token sent with the request
+func (ai *authInterceptor) authenticate(ctx context.Context) (auth.Principal, error) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return auth.Principal{}, gateway.ErrMissingToken
+	}
+
+	values := md.Get(authorizationHeader)
+	if len(values) < 1 {
+		return auth.Principal{}, gateway.ErrMissingToken
+	}
+
+	token, ok := strings.CutPrefix(values[0], bearerPrefix)
+	if !ok || token == "" {
+		return auth.Principal{}, gateway.ErrInvalidToken
+	}
+
+	if ai.rootToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(ai.rootToken)) == 1 {
+		return rootPrincipal, nil
+	}
+
+	principal, err := ai.keys.Authenticate(ctx, token)
+	if errors.Is(err, apikeys.ErrInvalidAPIKey) {
+		return auth.Principal{}, gateway.ErrInvalidToken
+	} else if err != nil {
+		ai.l.Error("authenticate api key", teapot.Error(err))
+		return auth.Principal{}, gateway.ErrVerifyToken
+	}
+
+	return principal, nil
+}
+
+// principalStream server stream carrying the authorized context
+type principalStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context implements grpc.ServerStream.
+func (s *principalStream) Context() context.Context {
+	return s.ctx
+}
+
+// scopedStream server stream of a scoped principal, a received message
+// touching a tunnel outside the scope fails the receive
+type scopedStream struct {
+	*principalStream
+
+	authorize func(any) error
+}
+
+// RecvMsg implements grpc.ServerStream.
+func (s *scopedStream) RecvMsg(m any) error {
+	if err := s.principalStream.RecvMsg(m); err != nil {
+		return err
+	}
+	return s.authorize(m)
+}
@@ -0,0 +1,256 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/internal/apikeys"
+	"soft.structx.io/dino/internal/routes"
+	pbapikeys "soft.structx.io/dino/pb/apikeys/v1"
+	pbroutes "soft.structx.io/dino/pb/routes/v1"
+	pbtunnels "soft.structx.io/dino/pb/tunnels/v1"
+)
+
+const rootToken = "root-token"
+
+// fakeKeys api keys of a fixed set of principals
+type fakeKeys struct {
+	apikeys.Service
+
+	principals map[string]auth.Principal
+}
+
+// Authenticate implements apikeys.Service.
+func (f *fakeKeys) Authenticate(_ context.Context, key string) (auth.Principal, error) {
+	p, ok := f.principals[key]
+	if !ok {
+		return auth.Principal{}, apikeys.ErrInvalidAPIKey
+	}
+	return p, nil
+}
+
+// fakeRoutes tunnels of a fixed set of routes
+type fakeRoutes struct {
+	routes.Service
+
+	tunnels map[string][]string
+}
+
+// Tunnels implements routes.Service.
+func (f *fakeRoutes) Tunnels(_ context.Context, ref string) ([]string, error) {
+	return f.tunnels[ref], nil
+}
+
+// recvStream server stream receiving a fixed list of messages
+type recvStream struct {
+	grpc.ServerStream
+
+	ctx  context.Context
+	msgs []proto.Message
+}
+
+// Context implements grpc.ServerStream.
+func (r *recvStream) Context() context.Context { return r.ctx }
+
+// RecvMsg implements grpc.ServerStream.
+func (r *recvStream) RecvMsg(m any) error {
+	if len(r.msgs) == 0 {
+		return io.EOF
+	}
+	proto.Merge(m.(proto.Message), r.msgs[0])
+	r.msgs = r.msgs[1:]
+	return nil
+}
+
+const (
+	// watchTunnel streaming rpc of a single tunnel
+	watchTunnel = "/dino.test.v1.WatchService/WatchTunnel"
+	// watchTunnels streaming rpc of every tunnel
+	watchTunnels = "/dino.test.v1.WatchService/WatchTunnels"
+)
+
+type AuthenticatorSuite struct {
+	suite.Suite
+
+	interceptor *authInterceptor
+}
+
+func (suite *AuthenticatorSuite) SetupTest() {
+	keys := &fakeKeys{principals: map[string]auth.Principal{
+		"admin":    {Name: "admin", Role: auth.RoleAdmin},
+		"operator": {Name: "operator", Role: auth.RoleOperator, Tunnels: []string{"web"}},
+		"reader":   {Name: "reader", Role: auth.RoleReadOnly},
+	}}
+	routeService := &fakeRoutes{tunnels: map[string][]string{
+		"web.example.com": {"web"},
+		"db.example.com":  {"db"},
+	}}
+
+	logger := teapot.New(teapot.WithWriter(io.Discard))
+	suite.interceptor = newAuthInterceptor(logger, rootToken, keys, routeService)
+
+	suite.interceptor.policy[watchTunnel] = permission{
+		role: auth.RoleReadOnly,
+		tunnels: requestTunnels(func(in *pbtunnels.GetTunnelRequest) []string {
+			return []string{in.GetName()}
+		}),
+	}
+	suite.interceptor.policy[watchTunnels] = permission{role: auth.RoleReadOnly}
+}
+
+// stream call the stream interceptor with token as bearer credentials, the
+// handler receives every message and returns the first error
+func (suite *AuthenticatorSuite) stream(token, method string, msgs ...proto.Message) ([]string, error) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(authorizationHeader, bearerPrefix+token))
+
+	var received []string
+	err := suite.interceptor.StreamInterceptor()(nil, &recvStream{ctx: ctx, msgs: msgs}, &grpc.StreamServerInfo{FullMethod: method},
+		func(_ any, ss grpc.ServerStream) error {
+			if _, ok := auth.PrincipalFromContext(ss.Context()); !ok {
+				return status.Error(codes.Internal, "missing principal")
+			}
+
+			for {
+				var in pbtunnels.GetTunnelRequest
+				if err := ss.RecvMsg(&in); errors.Is(err, io.EOF) {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				received = append(received, in.GetName())
+			}
+		})
+	return received, err
+}
+
+// call the unary interceptor with token as bearer credentials
+func (suite *AuthenticatorSuite) call(token, method string, req any) (auth.Principal, error) {
+	ctx := context.Background()
+	if token != "" {
+		ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(authorizationHeader, bearerPrefix+token))
+	}
+
+	var principal auth.Principal
+	_, err := suite.interceptor.UnaryInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: method},
+		func(ctx context.Context, _ any) (any, error) {
+			principal, _ = auth.PrincipalFromContext(ctx)
+			return nil, nil
+		})
+	return principal, err
+}
+
+func (suite *AuthenticatorSuite) TestUnauthenticated() {
+	req := &pbtunnels.GetTunnelRequest{Name: "web"}
+
+	_, err := suite.call("", pbtunnels.TunnelService_GetTunnel_FullMethodName, req)
+	suite.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = suite.call("unknown", pbtunnels.TunnelService_GetTunnel_FullMethodName, req)
+	suite.Equal(codes.Unauthenticated, status.Code(err))
+
+	_, err = suite.call("", healthpb.Health_Check_FullMethodName, &healthpb.HealthCheckRequest{})
+	suite.NoError(err)
+}
+
+func (suite *AuthenticatorSuite) TestRootToken() {
+	principal, err := suite.call(rootToken, pbapikeys.APIKeyService_ListAPIKeys_FullMethodName, &pbapikeys.ListAPIKeysRequest{})
+	suite.Require().NoError(err)
+	suite.Equal(rootPrincipal, principal)
+}
+
+func (suite *AuthenticatorSuite) TestRoles() {
+	_, err := suite.call("reader", pbtunnels.TunnelService_ListTunnels_FullMethodName, &pbtunnels.ListTunnelsRequest{})
+	suite.NoError(err)
+
+	_, err = suite.call("reader", pbtunnels.TunnelService_DeleteTunnel_FullMethodName, &pbtunnels.DeleteTunnelRequest{Name: "web"})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.call("operator", pbapikeys.APIKeyService_CreateAPIKey_FullMethodName, &pbapikeys.CreateAPIKeyRequest{})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.call("admin", pbapikeys.APIKeyService_CreateAPIKey_FullMethodName, &pbapikeys.CreateAPIKeyRequest{})
+	suite.NoError(err)
+
+	_, err = suite.call("operator", "/unknown.v1.Service/Method", nil)
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (suite *AuthenticatorSuite) TestTunnelScope() {
+	principal, err := suite.call("operator", pbtunnels.TunnelService_DeleteTunnel_FullMethodName, &pbtunnels.DeleteTunnelRequest{Name: "web"})
+	suite.Require().NoError(err)
+	suite.Equal("operator", principal.Name)
+
+	_, err = suite.call("operator", pbtunnels.TunnelService_DeleteTunnel_FullMethodName, &pbtunnels.DeleteTunnelRequest{Name: "db"})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.call("operator", pbtunnels.TunnelService_UpdateTunnel_FullMethodName, &pbtunnels.UpdateTunnelRequest{
+		TunnelUpdate: &pbtunnels.TunnelUpdate{OldName: "web", NewName: "db"},
+	})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	// listing every tunnel is limited to unscoped principals
+	_, err = suite.call("operator", pbtunnels.TunnelService_ListTunnels_FullMethodName, &pbtunnels.ListTunnelsRequest{})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+}
+
+func (suite *AuthenticatorSuite) TestRouteScope() {
+	_, err := suite.call("operator", pbroutes.RouteService_DeleteRoute_FullMethodName, &pbroutes.DeleteRouteRequest{Hostname: "web.example.com"})
+	suite.NoError(err)
+
+	_, err = suite.call("operator", pbroutes.RouteService_DeleteRoute_FullMethodName, &pbroutes.DeleteRouteRequest{Hostname: "db.example.com"})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.call("operator", pbroutes.RouteService_CreateRoute_FullMethodName, &pbroutes.CreateRouteRequest{
+		Create: &pbroutes.RouteCreate{Tunnel: "db", Hostname: "new.example.com"},
+	})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	// requests resolving to no tunnel are outside every scope
+	_, err = suite.call("operator", pbroutes.RouteService_DeleteRoute_FullMethodName, &pbroutes.DeleteRouteRequest{Hostname: "unknown.example.com"})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.call("admin", pbroutes.RouteService_DeleteRoute_FullMethodName, &pbroutes.DeleteRouteRequest{Hostname: "unknown.example.com"})
+	suite.NoError(err)
+}
+
+func (suite *AuthenticatorSuite) TestStreamScope() {
+	// the scope is checked once the handler receives the request
+	received, err := suite.stream("operator", watchTunnel, &pbtunnels.GetTunnelRequest{Name: "web"})
+	suite.NoError(err)
+	suite.Equal([]string{"web"}, received)
+
+	received, err = suite.stream("operator", watchTunnel,
+		&pbtunnels.GetTunnelRequest{Name: "web"}, &pbtunnels.GetTunnelRequest{Name: "db"})
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+	suite.Equal([]string{"web"}, received)
+
+	received, err = suite.stream("admin", watchTunnel,
+		&pbtunnels.GetTunnelRequest{Name: "web"}, &pbtunnels.GetTunnelRequest{Name: "db"})
+	suite.NoError(err)
+	suite.Equal([]string{"web", "db"}, received)
+
+	// rpcs without tunnels are limited to unscoped principals
+	_, err = suite.stream("operator", watchTunnels)
+	suite.Equal(codes.PermissionDenied, status.Code(err))
+
+	_, err = suite.stream("reader", watchTunnels)
+	suite.NoError(err)
+
+	_, err = suite.stream("unknown", watchTunnel)
+	suite.Equal(codes.Unauthenticated, status.Code(err))
+}
+
+func TestAuthenticatorSuite(t *testing.T) {
+	suite.Run(t, new(AuthenticatorSuite))
+}
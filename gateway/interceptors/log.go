@@ -30,8 +30,10 @@ func (li *loggerInterceptor) UnaryInterceptor() gateway.UnaryInterceptor {
 	}
 }
 
+// StreamInterceptor
 func (li *loggerInterceptor) StreamInterceptor() gateway.StreamInterceptor {
 	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		return nil
+		li.l.Debug("gRPC stream handler", teapot.String("full_method", info.FullMethod))
+		return handler(srv, ss)
 	}
 }
@@ -4,31 +4,48 @@ import (
 	"github.com/structx/teapot"
 	"go.uber.org/fx"
 	"soft.structx.io/dino/gateway"
+	"soft.structx.io/dino/internal/apikeys"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/setup"
 )
 
 // Params
 type Params struct {
 	fx.In
 
+	Cfg    *setup.API
 	Logger *teapot.Logger
+
+	APIKeyService apikeys.Service
+	RouteService  routes.Service
 }
 
 // Result
 type Result struct {
 	fx.Out
 
-	UnaryInterceptors []gateway.UnaryInterceptor
-	StreamInterceptor []gateway.StreamInterceptor
+	UnaryInterceptors  []gateway.UnaryInterceptor
+	StreamInterceptors []gateway.StreamInterceptor
 }
 
 // Module
 var Module = fx.Module("interceptors", fx.Provide(newModule))
 
 func newModule(p Params) Result {
+	if p.Cfg.RootToken == "" {
+		p.Logger.Info("root token is not set, only api keys authenticate the management api")
+	}
+
 	logger := newLoggerInterceptor(p.Logger)
-	_ = logger
+	authenticator := newAuthInterceptor(p.Logger, p.Cfg.RootToken, p.APIKeyService, p.RouteService)
 	return Result{
-		UnaryInterceptors: []gateway.UnaryInterceptor{},
-		StreamInterceptor: []gateway.StreamInterceptor{},
+		UnaryInterceptors: []gateway.UnaryInterceptor{
+			logger.UnaryInterceptor(),
+			authenticator.UnaryInterceptor(),
+		},
+		StreamInterceptors: []gateway.StreamInterceptor{
+			logger.StreamInterceptor(),
+			authenticator.StreamInterceptor(),
+		},
 	}
 }
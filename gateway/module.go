@@ -2,6 +2,7 @@ package gateway
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"fmt"
 	"net"
@@ -27,6 +28,9 @@ var (
 	ErrVerifyToken     = status.Error(codes.Internal, "verify token")
 	ErrInvalidTunnelID = status.Error(codes.InvalidArgument, "missing tunnel id")
 	ErrInvalidToken    = status.Error(codes.Unauthenticated, "invalid token")
+	ErrMissingToken    = status.Error(codes.Unauthenticated, "missing bearer token")
+
+	ErrPermissionDenied = status.Error(codes.PermissionDenied, "permission denied")
 )
 
 // UnaeryInterceptor
@@ -61,12 +65,13 @@ type Params struct {
 	ServerConfig *setup.Server
 	ProxyConfig  *setup.Proxy
 
-	Proxy http.Handler
+	Proxy    http.Handler
+	ProxyTLS *tls.Config `name:"proxy_tls"`
 
 	Transports []Transport `group:"transport"`
 
-	// UnaryInterceptors  []UnaryInterceptor
-	// StreamInterceptors []StreamInterceptor
+	UnaryInterceptors  []UnaryInterceptor
+	StreamInterceptors []StreamInterceptor
 }
 
 // Module
@@ -78,7 +83,10 @@ func invokeModule(p Params) error {
 
 	p.Logger.Info("num transports", teapot.Int("len", len(p.Transports)))
 
-	gs := grpc.NewServer()
+	gs := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(p.UnaryInterceptors...),
+		grpc.ChainStreamInterceptor(p.StreamInterceptors...),
+	)
 	for _, tr := range p.Transports {
 		gs.RegisterService(tr.ServiceDesc, tr.Service)
 	}
@@ -98,10 +106,23 @@ func invokeModule(p Params) error {
 	h1 := &http.Server{
 		Addr:              proxyAddr,
 		Handler:           p.Proxy,
-		ReadTimeout:       time.Second * p.ProxyConfig.ReadTimeout,
-		ReadHeaderTimeout: time.Second * p.ProxyConfig.ReadHeaderTimeout,
-		WriteTimeout:      time.Second * p.ProxyConfig.WriteTimeout,
-		IdleTimeout:       time.Second * p.ProxyConfig.IdleTimeout,
+		ReadTimeout:       p.ProxyConfig.ReadTimeout,
+		ReadHeaderTimeout: p.ProxyConfig.ReadHeaderTimeout,
+		WriteTimeout:      p.ProxyConfig.WriteTimeout,
+		IdleTimeout:       p.ProxyConfig.IdleTimeout,
+	}
+
+	var h1s *http.Server
+	if p.ProxyTLS != nil {
+		h1s = &http.Server{
+			Addr:              net.JoinHostPort(p.ProxyConfig.Host, p.ProxyConfig.TLSPort),
+			Handler:           p.Proxy,
+			TLSConfig:         p.ProxyTLS,
+			ReadTimeout:       p.ProxyConfig.ReadTimeout,
+			ReadHeaderTimeout: p.ProxyConfig.ReadHeaderTimeout,
+			WriteTimeout:      p.ProxyConfig.WriteTimeout,
+			IdleTimeout:       p.ProxyConfig.IdleTimeout,
+		}
 	}
 
 	h2h := h2c.NewHandler(gs, &http2.Server{})
@@ -120,11 +141,21 @@ func invokeModule(p Params) error {
 		OnStart: func(ctx context.Context) error {
 			p.Logger.Info("start http/1 proxy server", teapot.String("server_addr", h1.Addr))
 			go func() {
-				if err := h1.ListenAndServe(); err != nil && errors.Is(err, http.ErrServerClosed) {
+				if err := h1.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 					p.Logger.Fatal("start http/1 proxy server", teapot.Error(err))
 				}
 			}()
 
+			if h1s != nil {
+				p.Logger.Info("start https proxy server", teapot.String("server_addr", h1s.Addr))
+				go func() {
+					// certificates are provided by the tls config
+					if err := h1s.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+						p.Logger.Fatal("start https proxy server", teapot.Error(err))
+					}
+				}()
+			}
+
 			p.Logger.Info("start hls server", teapot.String("server_addr", hls.Addr))
 			go func() {
 				if err := hls.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -157,6 +188,13 @@ func invokeModule(p Params) error {
 				multiErr = multierr.Append(multiErr, fmt.Errorf("h1.Shutdown: %w", err))
 			}
 
+			if h1s != nil {
+				p.Logger.Info("shutdown https proxy server")
+				if err := h1s.Shutdown(ctx); err != nil {
+					multiErr = multierr.Append(multiErr, fmt.Errorf("h1s.Shutdown: %w", err))
+				}
+			}
+
 			p.Logger.Info("shutdown hls server")
 			if err := hls.Shutdown(ctx); err != nil {
 				multiErr = multierr.Append(multiErr, fmt.Errorf("hls.Shutdown: %w", err))
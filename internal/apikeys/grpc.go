@@ -0,0 +1,92 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+
+	"github.com/structx/teapot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb "soft.structx.io/dino/pb/apikeys/v1"
+)
+
+type apiKeyServer struct {
+	pb.UnimplementedAPIKeyServiceServer
+
+	log *teapot.Logger
+	svc Service
+}
+
+// interface compliance
+var _ pb.APIKeyServiceServer = (*apiKeyServer)(nil)
+
+func newAPIKeyServer(logger *teapot.Logger, keyService Service) pb.APIKeyServiceServer {
+	return &apiKeyServer{
+		log: logger,
+		svc: keyService,
+	}
+}
+
+// CreateAPIKey
+func (as *apiKeyServer) CreateAPIKey(ctx context.Context, in *pb.CreateAPIKeyRequest) (*pb.CreateAPIKeyResponse, error) {
+	args := APIKeyCreate{
+		Name:    in.GetCreate().GetName(),
+		Role:    in.GetCreate().GetRole(),
+		Tunnels: in.GetCreate().GetTunnels(),
+	}
+
+	if args.Name == "" {
+		return nil, status.Error(codes.InvalidArgument, "missing name")
+	}
+
+	apiKey, key, err := as.svc.Create(ctx, args)
+	if errors.Is(err, ErrInvalidRole) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		as.log.Error("create api key", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return &pb.CreateAPIKeyResponse{
+		ApiKey: pbAPIKey(apiKey),
+		Key:    key,
+	}, nil
+}
+
+// ListAPIKeys
+func (as *apiKeyServer) ListAPIKeys(ctx context.Context, in *pb.ListAPIKeysRequest) (*pb.ListAPIKeysResponse, error) {
+	keys, err := as.svc.List(ctx, in.GetLimit(), in.GetOffset())
+	if err != nil {
+		as.log.Error("list api keys", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	pks := make([]*pb.APIKey, 0, len(keys))
+	for _, k := range keys {
+		pks = append(pks, pbAPIKey(k))
+	}
+	return &pb.ListAPIKeysResponse{ApiKeys: pks}, nil
+}
+
+// DeleteAPIKey
+func (as *apiKeyServer) DeleteAPIKey(ctx context.Context, in *pb.DeleteAPIKeyRequest) (*pb.DeleteAPIKeyResponse, error) {
+	err := as.svc.Delete(ctx, in.GetName())
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	} else if err != nil {
+		as.log.Error("delete api key", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	return &pb.DeleteAPIKeyResponse{}, nil
+}
+
+func pbAPIKey(k APIKey) *pb.APIKey {
+	return &pb.APIKey{
+		Uid:       k.ID,
+		Name:      k.Name,
+		Role:      string(k.Role),
+		Tunnels:   k.Tunnels,
+		CreatedAt: timestamppb.New(k.CreatedAt),
+	}
+}
@@ -0,0 +1,41 @@
+package apikeys
+
+import (
+	"github.com/structx/teapot"
+	"go.uber.org/fx"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/gateway"
+	pb "soft.structx.io/dino/pb/apikeys/v1"
+)
+
+// Params
+type Params struct {
+	fx.In
+
+	Logger *teapot.Logger
+
+	DBTX database.DBTX
+}
+
+// Result
+type Result struct {
+	fx.Out
+
+	APIKeyService Service
+
+	Transport gateway.Transport `group:"transport"`
+}
+
+// Module
+var Module = fx.Module("apikeys_module", fx.Provide(newModule))
+
+func newModule(p Params) Result {
+	svc := newService(p.DBTX)
+	return Result{
+		APIKeyService: svc,
+		Transport: gateway.Transport{
+			ServiceDesc: &pb.APIKeyService_ServiceDesc,
+			Service:     newAPIKeyServer(p.Logger, svc),
+		},
+	}
+}
@@ -0,0 +1,29 @@
+-- name: InsertAPIKey :one
+INSERT INTO dino.api_keys (
+    name,
+    key_hash,
+    role,
+    tunnels
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING *;
+
+-- name: SelectAPIKeyByHash :one
+-- SelectAPIKeyByHash key presented by a caller
+SELECT
+    *
+FROM
+    dino.api_keys
+WHERE
+    key_hash = $1;
+
+-- name: ListAPIKeys :many
+SELECT
+    *
+FROM
+    dino.api_keys
+ORDER BY (created_at, id)
+LIMIT $1 OFFSET $2;
+
+-- name: DeleteAPIKey :execresult
+DELETE FROM dino.api_keys WHERE name = $1;
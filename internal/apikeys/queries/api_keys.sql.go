@@ -0,0 +1,122 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: api_keys.sql
+
+package queries
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const deleteAPIKey = `-- name: DeleteAPIKey :execresult
+DELETE FROM dino.api_keys WHERE name = $1
+`
+
+func (q *Queries) DeleteAPIKey(ctx context.Context, name string) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, deleteAPIKey, name)
+}
+
+const insertAPIKey = `-- name: InsertAPIKey :one
+INSERT INTO dino.api_keys (
+    name,
+    key_hash,
+    role,
+    tunnels
+) VALUES (
+    $1, $2, $3, $4
+) RETURNING id, name, key_hash, role, tunnels, created_at
+`
+
+type InsertAPIKeyParams struct {
+	Name    string
+	KeyHash []byte
+	Role    string
+	Tunnels []string
+}
+
+func (q *Queries) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (DinoApiKey, error) {
+	row := q.db.QueryRow(ctx, insertAPIKey,
+		arg.Name,
+		arg.KeyHash,
+		arg.Role,
+		arg.Tunnels,
+	)
+	var i DinoApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyHash,
+		&i.Role,
+		&i.Tunnels,
+		&i.CreatedAt,
+	)
+	return i, err
+}
+
+const listAPIKeys = `-- name: ListAPIKeys :many
+SELECT
+    id, name, key_hash, role, tunnels, created_at
+FROM
+    dino.api_keys
+ORDER BY (created_at, id)
+LIMIT $1 OFFSET $2
+`
+
+type ListAPIKeysParams struct {
+	Limit  int32
+	Offset int32
+}
+
+func (q *Queries) ListAPIKeys(ctx context.Context, arg ListAPIKeysParams) ([]DinoApiKey, error) {
+	rows, err := q.db.Query(ctx, listAPIKeys, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DinoApiKey{}
+	for rows.Next() {
+		var i DinoApiKey
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.KeyHash,
+			&i.Role,
+			&i.Tunnels,
+			&i.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectAPIKeyByHash = `-- name: SelectAPIKeyByHash :one
+SELECT
+    id, name, key_hash, role, tunnels, created_at
+FROM
+    dino.api_keys
+WHERE
+    key_hash = $1
+`
+
+// SelectAPIKeyByHash key presented by a caller
+func (q *Queries) SelectAPIKeyByHash(ctx context.Context, keyHash []byte) (DinoApiKey, error) {
+	row := q.db.QueryRow(ctx, selectAPIKeyByHash, keyHash)
+	var i DinoApiKey
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.KeyHash,
+		&i.Role,
+		&i.Tunnels,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,178 @@
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/internal/apikeys/queries"
+)
+
+// keyPrefix marks dino api keys, secret scanners can match it
+const keyPrefix = "dino_"
+
+// keyLength random bytes of an api key
+const keyLength = 32
+
+var (
+	// ErrAPIKeyNotFound no api key has the name
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	// ErrInvalidAPIKey presented key is unknown
+	ErrInvalidAPIKey = errors.New("invalid api key")
+	// ErrInvalidRole role of a new api key is unknown
+	ErrInvalidRole = errors.New("invalid role")
+)
+
+// APIKeyCreate
+type APIKeyCreate struct {
+	Name string
+	Role string
+	// Tunnels names the key is limited to, empty grants every tunnel
+	Tunnels []string
+}
+
+// APIKey
+type APIKey struct {
+	ID        string
+	Name      string
+	Role      auth.Role
+	Tunnels   []string
+	CreatedAt time.Time
+}
+
+// Service
+type Service interface {
+	// Create store a new api key, the key itself is only returned here
+	Create(context.Context, APIKeyCreate) (APIKey, string, error)
+	// List
+	List(context.Context, int32, int32) ([]APIKey, error)
+	// Delete
+	Delete(context.Context, string) error
+
+	// Authenticate principal of a presented api key
+	Authenticate(context.Context, string) (auth.Principal, error)
+}
+
+type serviceImpl struct {
+	db database.DBTX
+}
+
+// interface compliance
+var _ Service = (*serviceImpl)(nil)
+
+func newService(db database.DBTX) Service {
+	return &serviceImpl{db: db}
+}
+
+// Create implements Service.
+func (s *serviceImpl) Create(ctx context.Context, create APIKeyCreate) (APIKey, string, error) {
+	role, err := auth.ParseRole(create.Role)
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("%w: %w", ErrInvalidRole, err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	secret := make([]byte, keyLength)
+	if _, err := rand.Read(secret); err != nil {
+		return APIKey{}, "", fmt.Errorf("rand.Read: %w", err)
+	}
+	key := keyPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	tunnels := create.Tunnels
+	if tunnels == nil {
+		tunnels = []string{}
+	}
+
+	sqlKey, err := queries.New(s.db).InsertAPIKey(timeout, queries.InsertAPIKeyParams{
+		Name:    create.Name,
+		KeyHash: hashKey(key),
+		Role:    string(role),
+		Tunnels: tunnels,
+	})
+	if err != nil {
+		return APIKey{}, "", fmt.Errorf("failed to execute insert api key query: %w", err)
+	}
+
+	return dtoAPIKey(sqlKey), key, nil
+}
+
+// List implements Service.
+func (s *serviceImpl) List(ctx context.Context, limit, offset int32) ([]APIKey, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	rows, err := queries.New(s.db).ListAPIKeys(timeout, queries.ListAPIKeysParams{
+		Limit:  limit,
+		Offset: offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute list api keys query: %w", err)
+	}
+
+	keys := make([]APIKey, 0, len(rows))
+	for _, r := range rows {
+		keys = append(keys, dtoAPIKey(r))
+	}
+	return keys, nil
+}
+
+// Delete implements Service.
+func (s *serviceImpl) Delete(ctx context.Context, name string) error {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	tag, err := queries.New(s.db).DeleteAPIKey(timeout, name)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete api key query: %w", err)
+	}
+
+	if tag.RowsAffected() < 1 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// Authenticate implements Service.
+func (s *serviceImpl) Authenticate(ctx context.Context, key string) (auth.Principal, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	sqlKey, err := queries.New(s.db).SelectAPIKeyByHash(timeout, hashKey(key))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return auth.Principal{}, ErrInvalidAPIKey
+	} else if err != nil {
+		return auth.Principal{}, fmt.Errorf("failed to execute select api key query: %w", err)
+	}
+
+	apiKey := dtoAPIKey(sqlKey)
+	return auth.Principal{
+		Name:    apiKey.Name,
+		Role:    apiKey.Role,
+		Tunnels: apiKey.Tunnels,
+	}, nil
+}
+
+// hashKey lookup hash of key, keys are random so a fast hash is enough
+func hashKey(key string) []byte {
+	sum := sha256.Sum256([]byte(key))
+	return sum[:]
+}
+
+func dtoAPIKey(k queries.DinoApiKey) APIKey {
+	return APIKey{
+		ID:        k.ID.String(),
+		Name:      k.Name,
+		Role:      auth.Role(k.Role),
+		Tunnels:   k.Tunnels,
+		CreatedAt: k.CreatedAt.Time,
+	}
+}
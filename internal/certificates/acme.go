@@ -0,0 +1,55 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/internal/certificates/queries"
+)
+
+// acmeCache autocert cache shared by every server replica through postgres
+type acmeCache struct {
+	db database.DBTX
+}
+
+// interface compliance
+var _ autocert.Cache = (*acmeCache)(nil)
+
+func newACMECache(dbtx database.DBTX) autocert.Cache {
+	return &acmeCache{db: dbtx}
+}
+
+// Get implements autocert.Cache.
+func (a *acmeCache) Get(ctx context.Context, key string) ([]byte, error) {
+	data, err := queries.New(a.db).SelectACMECache(ctx, key)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, autocert.ErrCacheMiss
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to execute select acme cache query: %w", err)
+	}
+	return data, nil
+}
+
+// Put implements autocert.Cache.
+func (a *acmeCache) Put(ctx context.Context, key string, data []byte) error {
+	err := queries.New(a.db).UpsertACMECache(ctx, queries.UpsertACMECacheParams{
+		CacheKey:  key,
+		CacheData: data,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute upsert acme cache query: %w", err)
+	}
+	return nil
+}
+
+// Delete implements autocert.Cache.
+func (a *acmeCache) Delete(ctx context.Context, key string) error {
+	if err := queries.New(a.db).DeleteACMECache(ctx, key); err != nil {
+		return fmt.Errorf("failed to execute delete acme cache query: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,94 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/database"
+)
+
+// fakeACMETable acme_cache table of the acme cache queries
+type fakeACMETable struct {
+	database.DBTX
+
+	rows map[string][]byte
+}
+
+// Exec implements database.DBTX.
+func (f *fakeACMETable) Exec(_ context.Context, sql string, args ...interface{}) (database.CommandTag, error) {
+	switch {
+	case strings.Contains(sql, "name: UpsertACMECache"):
+		f.rows[args[0].(string)] = args[1].([]byte)
+	case strings.Contains(sql, "name: DeleteACMECache"):
+		delete(f.rows, args[0].(string))
+	default:
+		return pgconn.CommandTag{}, errors.New("unexpected query")
+	}
+	return pgconn.CommandTag{}, nil
+}
+
+// QueryRow implements database.DBTX.
+func (f *fakeACMETable) QueryRow(_ context.Context, _ string, args ...interface{}) database.Row {
+	data, ok := f.rows[args[0].(string)]
+	return fakeRow{data: data, ok: ok}
+}
+
+// fakeRow single cache_data column
+type fakeRow struct {
+	data []byte
+	ok   bool
+}
+
+// Scan implements pgx.Row.
+func (f fakeRow) Scan(dest ...any) error {
+	if !f.ok {
+		return pgx.ErrNoRows
+	}
+	*dest[0].(*[]byte) = f.data
+	return nil
+}
+
+type ACMECacheSuite struct {
+	suite.Suite
+
+	cache autocert.Cache
+}
+
+func (suite *ACMECacheSuite) SetupTest() {
+	suite.cache = newACMECache(&fakeACMETable{rows: map[string][]byte{}})
+}
+
+func (suite *ACMECacheSuite) TestRoundTrip() {
+	ctx := context.Background()
+
+	_, err := suite.cache.Get(ctx, "web.dino.local")
+	suite.ErrorIs(err, autocert.ErrCacheMiss)
+
+	suite.Require().NoError(suite.cache.Put(ctx, "web.dino.local", []byte("certificate")))
+	data, err := suite.cache.Get(ctx, "web.dino.local")
+	suite.Require().NoError(err)
+	suite.Equal([]byte("certificate"), data)
+
+	// renewals replace the stored certificate
+	suite.Require().NoError(suite.cache.Put(ctx, "web.dino.local", []byte("renewed")))
+	data, err = suite.cache.Get(ctx, "web.dino.local")
+	suite.Require().NoError(err)
+	suite.Equal([]byte("renewed"), data)
+
+	suite.Require().NoError(suite.cache.Delete(ctx, "web.dino.local"))
+	_, err = suite.cache.Get(ctx, "web.dino.local")
+	suite.ErrorIs(err, autocert.ErrCacheMiss)
+
+	// deleting a missing key is not an error
+	suite.NoError(suite.cache.Delete(ctx, "web.dino.local"))
+}
+
+func TestACMECacheSuite(t *testing.T) {
+	suite.Run(t, new(ACMECacheSuite))
+}
@@ -0,0 +1,82 @@
+package certificates
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/structx/teapot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	pb "soft.structx.io/dino/pb/certificates/v1"
+)
+
+type certificateServer struct {
+	pb.UnimplementedCertificateServiceServer
+
+	log *teapot.Logger
+	svc Service
+}
+
+// interface compliance
+var _ pb.CertificateServiceServer = (*certificateServer)(nil)
+
+func newCertificateServer(logger *teapot.Logger, certService Service) pb.CertificateServiceServer {
+	return &certificateServer{
+		log: logger,
+		svc: certService,
+	}
+}
+
+// PutCertificate
+func (cs *certificateServer) PutCertificate(ctx context.Context, in *pb.PutCertificateRequest) (*pb.PutCertificateResponse, error) {
+	args := CertificatePut{
+		Hostname: in.GetPut().GetHostname(),
+		CertPEM:  in.GetPut().GetCertPem(),
+		KeyPEM:   in.GetPut().GetKeyPem(),
+	}
+
+	cert, err := cs.svc.Put(ctx, args)
+	if errors.Is(err, ErrRouteNotFound) {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	} else if err != nil {
+		cs.log.Error("put certificate", teapot.Error(err))
+		return nil, status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
+	}
+
+	return newPutCertificateResponse(cert), nil
+}
+
+// DeleteCertificate
+func (cs *certificateServer) DeleteCertificate(ctx context.Context, in *pb.DeleteCertificateRequest) (*pb.DeleteCertificateResponse, error) {
+	err := cs.svc.Delete(ctx, in.GetHostname())
+	if errors.Is(err, ErrCertificateNotFound) {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	} else if err != nil {
+		cs.log.Error("delete certificate", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+	return &pb.DeleteCertificateResponse{}, nil
+}
+
+func pbCertificate(c Certificate) *pb.Certificate {
+	var updatedAt = time.Time{}
+	if c.UpdatedAt != nil {
+		updatedAt = *c.UpdatedAt
+	}
+
+	return &pb.Certificate{
+		Uid:       c.ID,
+		Hostname:  c.Hostname,
+		NotAfter:  timestamppb.New(c.NotAfter),
+		CreatedAt: timestamppb.New(c.CreatedAt),
+		UpdatedAt: timestamppb.New(updatedAt),
+	}
+}
+
+func newPutCertificateResponse(c Certificate) *pb.PutCertificateResponse {
+	return &pb.PutCertificateResponse{
+		Certificate: pbCertificate(c),
+	}
+}
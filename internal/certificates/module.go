@@ -0,0 +1,45 @@
+package certificates
+
+import (
+	"github.com/structx/teapot"
+	"go.uber.org/fx"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/gateway"
+	pb "soft.structx.io/dino/pb/certificates/v1"
+)
+
+// Params
+type Params struct {
+	fx.In
+
+	Logger *teapot.Logger
+
+	DBTX database.DBTX
+}
+
+// Result
+type Result struct {
+	fx.Out
+
+	CertificateService Service
+	ACMECache          autocert.Cache
+
+	Transport gateway.Transport `group:"transport"`
+}
+
+// Module
+var Module = fx.Module("certificates_module", fx.Provide(newModule))
+
+func newModule(p Params) Result {
+	svc := newService(p.DBTX)
+	cs := newCertificateServer(p.Logger, svc)
+	return Result{
+		CertificateService: svc,
+		ACMECache:          newACMECache(p.DBTX),
+		Transport: gateway.Transport{
+			ServiceDesc: &pb.CertificateService_ServiceDesc,
+			Service:     cs,
+		},
+	}
+}
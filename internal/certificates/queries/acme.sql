@@ -0,0 +1,25 @@
+-- name: SelectACMECache :one
+-- SelectACMECache acme account, certificate or challenge data stored under key
+SELECT
+    cache_data
+FROM
+    dino.acme_cache
+WHERE
+    cache_key = $1;
+
+-- name: UpsertACMECache :exec
+-- UpsertACMECache insert or replace data stored under key
+INSERT INTO dino.acme_cache (
+    cache_key,
+    cache_data
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (cache_key) DO UPDATE
+SET
+    cache_data = EXCLUDED.cache_data,
+    updated_at = CURRENT_TIMESTAMP;
+
+-- name: DeleteACMECache :exec
+DELETE FROM dino.acme_cache
+WHERE cache_key = $1;
@@ -0,0 +1,61 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: acme.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const deleteACMECache = `-- name: DeleteACMECache :exec
+DELETE FROM dino.acme_cache
+WHERE cache_key = $1
+`
+
+func (q *Queries) DeleteACMECache(ctx context.Context, cacheKey string) error {
+	_, err := q.db.Exec(ctx, deleteACMECache, cacheKey)
+	return err
+}
+
+const selectACMECache = `-- name: SelectACMECache :one
+SELECT
+    cache_data
+FROM
+    dino.acme_cache
+WHERE
+    cache_key = $1
+`
+
+// SelectACMECache acme account, certificate or challenge data stored under key
+func (q *Queries) SelectACMECache(ctx context.Context, cacheKey string) ([]byte, error) {
+	row := q.db.QueryRow(ctx, selectACMECache, cacheKey)
+	var cache_data []byte
+	err := row.Scan(&cache_data)
+	return cache_data, err
+}
+
+const upsertACMECache = `-- name: UpsertACMECache :exec
+INSERT INTO dino.acme_cache (
+    cache_key,
+    cache_data
+) VALUES (
+    $1, $2
+)
+ON CONFLICT (cache_key) DO UPDATE
+SET
+    cache_data = EXCLUDED.cache_data,
+    updated_at = CURRENT_TIMESTAMP
+`
+
+type UpsertACMECacheParams struct {
+	CacheKey  string
+	CacheData []byte
+}
+
+// UpsertACMECache insert or replace data stored under key
+func (q *Queries) UpsertACMECache(ctx context.Context, arg UpsertACMECacheParams) error {
+	_, err := q.db.Exec(ctx, upsertACMECache, arg.CacheKey, arg.CacheData)
+	return err
+}
@@ -0,0 +1,43 @@
+-- name: UpsertCertificate :one
+-- UpsertCertificate insert or replace certificate of route matching hostname
+INSERT INTO dino.certificates (
+    route_id,
+    cert_pem,
+    key_pem,
+    not_after
+)
+SELECT
+    r.id, $2, $3, $4
+FROM
+    dino.routes AS r
+WHERE
+    r.hostname = $1
+ON CONFLICT (route_id) DO UPDATE
+SET
+    cert_pem = EXCLUDED.cert_pem,
+    key_pem = EXCLUDED.key_pem,
+    not_after = EXCLUDED.not_after,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING *;
+
+-- name: SelectCertificate :one
+-- SelectCertificate certificate of active route matching hostname, falling back to its wildcard route
+SELECT
+    c.cert_pem,
+    c.key_pem
+FROM
+    dino.certificates AS c
+INNER JOIN
+    dino.routes AS r
+ON
+    c.route_id = r.id
+WHERE
+    (r.hostname = @hostname OR r.hostname = @wildcard) AND r.is_active = TRUE
+ORDER BY
+    r.hostname = @hostname DESC
+LIMIT 1;
+
+-- name: DeleteCertificate :execresult
+DELETE FROM dino.certificates AS c
+USING dino.routes AS r
+WHERE c.route_id = r.id AND r.hostname = $1;
@@ -0,0 +1,108 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: certificates.sql
+
+package queries
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const deleteCertificate = `-- name: DeleteCertificate :execresult
+DELETE FROM dino.certificates AS c
+USING dino.routes AS r
+WHERE c.route_id = r.id AND r.hostname = $1
+`
+
+func (q *Queries) DeleteCertificate(ctx context.Context, hostname string) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, deleteCertificate, hostname)
+}
+
+const selectCertificate = `-- name: SelectCertificate :one
+SELECT
+    c.cert_pem,
+    c.key_pem
+FROM
+    dino.certificates AS c
+INNER JOIN
+    dino.routes AS r
+ON
+    c.route_id = r.id
+WHERE
+    (r.hostname = $1 OR r.hostname = $2) AND r.is_active = TRUE
+ORDER BY
+    r.hostname = $1 DESC
+LIMIT 1
+`
+
+type SelectCertificateParams struct {
+	Hostname string
+	Wildcard string
+}
+
+type SelectCertificateRow struct {
+	CertPem string
+	KeyPem  string
+}
+
+// SelectCertificate certificate of active route matching hostname, falling back to its wildcard route
+func (q *Queries) SelectCertificate(ctx context.Context, arg SelectCertificateParams) (SelectCertificateRow, error) {
+	row := q.db.QueryRow(ctx, selectCertificate, arg.Hostname, arg.Wildcard)
+	var i SelectCertificateRow
+	err := row.Scan(&i.CertPem, &i.KeyPem)
+	return i, err
+}
+
+const upsertCertificate = `-- name: UpsertCertificate :one
+INSERT INTO dino.certificates (
+    route_id,
+    cert_pem,
+    key_pem,
+    not_after
+)
+SELECT
+    r.id, $2, $3, $4
+FROM
+    dino.routes AS r
+WHERE
+    r.hostname = $1
+ON CONFLICT (route_id) DO UPDATE
+SET
+    cert_pem = EXCLUDED.cert_pem,
+    key_pem = EXCLUDED.key_pem,
+    not_after = EXCLUDED.not_after,
+    updated_at = CURRENT_TIMESTAMP
+RETURNING id, route_id, cert_pem, key_pem, not_after, created_at, updated_at
+`
+
+type UpsertCertificateParams struct {
+	Hostname string
+	CertPem  string
+	KeyPem   string
+	NotAfter pgtype.Timestamp
+}
+
+// UpsertCertificate insert or replace certificate of route matching hostname
+func (q *Queries) UpsertCertificate(ctx context.Context, arg UpsertCertificateParams) (DinoCertificate, error) {
+	row := q.db.QueryRow(ctx, upsertCertificate,
+		arg.Hostname,
+		arg.CertPem,
+		arg.KeyPem,
+		arg.NotAfter,
+	)
+	var i DinoCertificate
+	err := row.Scan(
+		&i.ID,
+		&i.RouteID,
+		&i.CertPem,
+		&i.KeyPem,
+		&i.NotAfter,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
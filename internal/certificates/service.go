@@ -0,0 +1,160 @@
+package certificates
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/internal/certificates/queries"
+	"soft.structx.io/dino/routing"
+)
+
+var (
+	// ErrRouteNotFound no route matches certificate hostname
+	ErrRouteNotFound = errors.New("route not found")
+	// ErrCertificateNotFound no certificate is stored for hostname
+	ErrCertificateNotFound = errors.New("certificate not found")
+)
+
+// CertificatePut
+type CertificatePut struct {
+	Hostname string
+	CertPEM  []byte
+	KeyPEM   []byte
+}
+
+// Certificate
+type Certificate struct {
+	ID        string
+	RouteID   string
+	Hostname  string
+	NotAfter  time.Time
+	CreatedAt time.Time
+	UpdatedAt *time.Time
+}
+
+// Service
+type Service interface {
+	// Put
+	Put(context.Context, CertificatePut) (Certificate, error)
+	// Delete
+	Delete(context.Context, string) error
+
+	// Lookup
+	Lookup(context.Context, string) (*tls.Certificate, error)
+}
+
+type serviceImpl struct {
+	db database.DBTX
+}
+
+// interface compliance
+var _ Service = (*serviceImpl)(nil)
+
+func newService(dbtx database.DBTX) Service {
+	return &serviceImpl{db: dbtx}
+}
+
+// Put
+func (s *serviceImpl) Put(ctx context.Context, put CertificatePut) (Certificate, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	cert, err := tls.X509KeyPair(put.CertPEM, put.KeyPEM)
+	if err != nil {
+		return Certificate{}, fmt.Errorf("tls.X509KeyPair: %w", err)
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return Certificate{}, fmt.Errorf("x509.ParseCertificate: %w", err)
+	}
+
+	// wildcard routes need a certificate covering any single label
+	verifyName := put.Hostname
+	if routing.IsWildcard(verifyName) {
+		verifyName = "dino-verify" + strings.TrimPrefix(verifyName, "*")
+	}
+
+	if err := leaf.VerifyHostname(verifyName); err != nil {
+		return Certificate{}, fmt.Errorf("leaf.VerifyHostname: %w", err)
+	}
+
+	sqlCert, err := queries.New(s.db).UpsertCertificate(timeout, queries.UpsertCertificateParams{
+		Hostname: put.Hostname,
+		CertPem:  string(put.CertPEM),
+		KeyPem:   string(put.KeyPEM),
+		NotAfter: pgtype.Timestamp{Time: leaf.NotAfter, Valid: true},
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Certificate{}, ErrRouteNotFound
+	} else if err != nil {
+		return Certificate{}, fmt.Errorf("failed to execute upsert certificate query: %w", err)
+	}
+
+	return dtoCertificate(sqlCert, put.Hostname), nil
+}
+
+// Delete
+func (s *serviceImpl) Delete(ctx context.Context, hostname string) error {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	tag, err := queries.New(s.db).DeleteCertificate(timeout, hostname)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete certificate query: %w", err)
+	}
+
+	if tag.RowsAffected() < 1 {
+		return ErrCertificateNotFound
+	}
+
+	return nil
+}
+
+// Lookup
+func (s *serviceImpl) Lookup(ctx context.Context, hostname string) (*tls.Certificate, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	wildcard, _, _ := routing.Wildcard(hostname)
+	row, err := queries.New(s.db).SelectCertificate(timeout, queries.SelectCertificateParams{
+		Hostname: hostname,
+		Wildcard: wildcard,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrCertificateNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to execute select certificate query: %w", err)
+	}
+
+	cert, err := tls.X509KeyPair([]byte(row.CertPem), []byte(row.KeyPem))
+	if err != nil {
+		return nil, fmt.Errorf("tls.X509KeyPair: %w", err)
+	}
+
+	return &cert, nil
+}
+
+func dtoCertificate(c queries.DinoCertificate, hostname string) Certificate {
+	var updatedAt *time.Time
+	if c.UpdatedAt.Valid {
+		updatedAt = &c.UpdatedAt.Time
+	}
+
+	return Certificate{
+		ID:        c.ID.String(),
+		RouteID:   c.RouteID.String(),
+		Hostname:  hostname,
+		NotAfter:  c.NotAfter.Time,
+		CreatedAt: c.CreatedAt.Time,
+		UpdatedAt: updatedAt,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/structx/teapot"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	pb "soft.structx.io/dino/pb/routes/v1"
 )
@@ -34,6 +35,12 @@ func (rs *routeServer) CreateRoute(ctx context.Context, in *pb.CreateRouteReques
 		DestinationProtocol: in.Create.DestProtocol,
 		DestinationIP:       in.Create.DestAddr,
 		DestinationPort:     in.Create.DestPort,
+		PublicPort:          in.Create.PublicPort,
+		PathPrefix:          in.Create.PathPrefix,
+		StripPrefix:         in.Create.StripPrefix,
+		MatchHeaders:        in.Create.MatchHeaders,
+		IdleTimeout:         in.Create.GetIdleTimeout().AsDuration(),
+		MaxLifetime:         in.Create.GetMaxLifetime().AsDuration(),
 	}
 
 	route, err := rs.svc.Create(ctx, args)
@@ -92,6 +99,12 @@ func (rs *routeServer) UpdateRoute(ctx context.Context, in *pb.UpdateRouteReques
 		DestinationProtocol: in.Update.DestProtocol,
 		DestinationIP:       in.Update.DestAddr,
 		DestinationPort:     in.Update.DestPort,
+		PublicPort:          in.Update.PublicPort,
+		PathPrefix:          in.Update.PathPrefix,
+		StripPrefix:         in.Update.StripPrefix,
+		MatchHeaders:        in.Update.MatchHeaders,
+		IdleTimeout:         in.Update.GetIdleTimeout().AsDuration(),
+		MaxLifetime:         in.Update.GetMaxLifetime().AsDuration(),
 		Enabled:             in.Update.Enabled,
 	}
 
@@ -111,12 +124,18 @@ func pbRoute(r Route) *pb.Route {
 	}
 
 	return &pb.Route{
-		Uid:       r.ID,
-		Tunnel:    r.Tunnel,
-		Name:      r.Hostname,
-		Enabled:   r.Enabled,
-		CreatedAt: timestamppb.New(r.CreatedAt),
-		UpdatedAt: timestamppb.New(updatedAt),
+		Uid:          r.ID,
+		Tunnel:       r.Tunnel,
+		Name:         r.Hostname,
+		Enabled:      r.Enabled,
+		PublicPort:   r.PublicPort,
+		PathPrefix:   r.PathPrefix,
+		StripPrefix:  r.StripPrefix,
+		MatchHeaders: r.MatchHeaders,
+		IdleTimeout:  durationpb.New(r.IdleTimeout),
+		MaxLifetime:  durationpb.New(r.MaxLifetime),
+		CreatedAt:    timestamppb.New(r.CreatedAt),
+		UpdatedAt:    timestamppb.New(updatedAt),
 	}
 }
 
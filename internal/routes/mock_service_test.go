@@ -41,18 +41,18 @@ func (m *MockService) EXPECT() *MockServiceMockRecorder {
 }
 
 // Active mocks base method.
-func (m *MockService) Active(arg0 context.Context, arg1 string) (string, error) {
+func (m *MockService) Active(arg0 context.Context, arg1, arg2 string) (RouteMatch, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Active", arg0, arg1)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Active", arg0, arg1, arg2)
+	ret0, _ := ret[0].(RouteMatch)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Active indicates an expected call of Active.
-func (mr *MockServiceMockRecorder) Active(arg0, arg1 any) *gomock.Call {
+func (mr *MockServiceMockRecorder) Active(arg0, arg1, arg2 any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Active", reflect.TypeOf((*MockService)(nil).Active), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Active", reflect.TypeOf((*MockService)(nil).Active), arg0, arg1, arg2)
 }
 
 // Create mocks base method.
@@ -114,6 +114,36 @@ func (mr *MockServiceMockRecorder) List(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockService)(nil).List), arg0, arg1)
 }
 
+// Listeners mocks base method.
+func (m *MockService) Listeners(arg0 context.Context, arg1 string) ([]Route, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Listeners", arg0, arg1)
+	ret0, _ := ret[0].([]Route)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Listeners indicates an expected call of Listeners.
+func (mr *MockServiceMockRecorder) Listeners(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Listeners", reflect.TypeOf((*MockService)(nil).Listeners), arg0, arg1)
+}
+
+// Match mocks base method.
+func (m *MockService) Match(arg0 context.Context, arg1 RouteRequest) (RouteMatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Match", arg0, arg1)
+	ret0, _ := ret[0].(RouteMatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Match indicates an expected call of Match.
+func (mr *MockServiceMockRecorder) Match(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Match", reflect.TypeOf((*MockService)(nil).Match), arg0, arg1)
+}
+
 // Sync mocks base method.
 func (m *MockService) Sync(arg0 context.Context, arg1 string) ([]Route, error) {
 	m.ctrl.T.Helper()
@@ -129,6 +159,21 @@ func (mr *MockServiceMockRecorder) Sync(arg0, arg1 any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Sync", reflect.TypeOf((*MockService)(nil).Sync), arg0, arg1)
 }
 
+// Tunnels mocks base method.
+func (m *MockService) Tunnels(arg0 context.Context, arg1 string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Tunnels", arg0, arg1)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Tunnels indicates an expected call of Tunnels.
+func (mr *MockServiceMockRecorder) Tunnels(arg0, arg1 any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tunnels", reflect.TypeOf((*MockService)(nil).Tunnels), arg0, arg1)
+}
+
 // Update mocks base method.
 func (m *MockService) Update(arg0 context.Context, arg1 RouteUpdate) (Route, error) {
 	m.ctrl.T.Helper()
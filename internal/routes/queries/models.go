@@ -9,6 +9,39 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
+type DinoAcmeCache struct {
+	CacheKey  string
+	CacheData []byte
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
+}
+
+type DinoApiKey struct {
+	ID        uuid.UUID
+	Name      string
+	KeyHash   []byte
+	Role      string
+	Tunnels   []string
+	CreatedAt pgtype.Timestamp
+}
+
+type DinoCertificate struct {
+	ID        uuid.UUID
+	RouteID   uuid.UUID
+	CertPem   string
+	KeyPem    string
+	NotAfter  pgtype.Timestamp
+	CreatedAt pgtype.Timestamp
+	UpdatedAt pgtype.Timestamp
+}
+
+type DinoCertificateAuthority struct {
+	ID        int16
+	CertPem   []byte
+	SealedKey []byte
+	CreatedAt pgtype.Timestamp
+}
+
 type DinoRoute struct {
 	ID                  uuid.UUID
 	TunnelName          string
@@ -19,13 +52,23 @@ type DinoRoute struct {
 	IsActive            bool
 	CreatedAt           pgtype.Timestamp
 	UpdatedAt           pgtype.Timestamp
+	PublicPort          pgtype.Int4
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        []byte
+	IdleTimeoutMs       int64
+	MaxLifetimeMs       int64
 }
 
 type DinoTunnel struct {
-	ID         uuid.UUID
-	Identifier string
-	TokenHash  string
-	IsActive   bool
-	CreatedAt  pgtype.Timestamp
-	UpdatedAt  pgtype.Timestamp
+	ID                     uuid.UUID
+	Identifier             string
+	IsActive               bool
+	CreatedAt              pgtype.Timestamp
+	UpdatedAt              pgtype.Timestamp
+	LastSeenAt             pgtype.Timestamp
+	RttMicros              pgtype.Int8
+	PreviousTokenExpiresAt pgtype.Timestamp
+	SigningKey             []byte
+	PreviousSigningKey     []byte
 }
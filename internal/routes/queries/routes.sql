@@ -6,9 +6,15 @@ INSERT INTO dino.routes (
     hostname,
     destination_protocol,
     destination_ip,
-    destination_port
+    destination_port,
+    public_port,
+    path_prefix,
+    strip_prefix,
+    match_headers,
+    idle_timeout_ms,
+    max_lifetime_ms
 ) VALUES ( 
-    $1, $2, $3, $4, $5
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
 ) RETURNING *;
 
 -- name: SelectRoute :one
@@ -39,18 +45,28 @@ SET
     destination_ip = $3,
     destination_port = $4,
     destination_protocol = $5,
-    is_active = $6
+    is_active = $6,
+    public_port = $7,
+    path_prefix = $8,
+    strip_prefix = $9,
+    match_headers = $10,
+    idle_timeout_ms = $11,
+    max_lifetime_ms = $12
 WHERE
     id = $1 
 RETURNING *;
 
--- name: DeleteRoute :exec
-DELETE FROM dino.routes WHERE id = $1;
+-- name: DeleteRoute :one
+DELETE FROM dino.routes WHERE id = $1 RETURNING *;
 
 -- name: SelectActiveRoute :one
--- SelectActiveRoute
+-- SelectActiveRoute tunnel of hostname or its wildcard served over protocol, preferring the exact route without match rules
 SELECT
-    t.id
+    t.id,
+    r.hostname,
+    r.destination_protocol,
+    r.idle_timeout_ms,
+    r.max_lifetime_ms
 FROM
     dino.routes as r
 INNER JOIN 
@@ -58,7 +74,31 @@ INNER JOIN
 ON
     r.tunnel_name = t.identifier
 WHERE
-    hostname = $1 AND r.is_active = TRUE;
+    (r.hostname = @hostname OR r.hostname = @wildcard) AND r.destination_protocol = @protocol AND r.is_active = TRUE
+ORDER BY
+    r.hostname = @hostname DESC, r.path_prefix = '' DESC, r.match_headers = '{}' DESC
+LIMIT 1;
+
+-- name: SelectActiveRules :many
+-- SelectActiveRules match rules of every active http route for hostname or its wildcard
+SELECT
+    r.id,
+    t.id AS tunnel_id,
+    r.hostname,
+    r.destination_protocol,
+    r.path_prefix,
+    r.strip_prefix,
+    r.match_headers,
+    r.idle_timeout_ms,
+    r.max_lifetime_ms
+FROM
+    dino.routes as r
+INNER JOIN 
+    dino.tunnels as t
+ON
+    r.tunnel_name = t.identifier
+WHERE
+    (r.hostname = @hostname OR r.hostname = @wildcard) AND r.destination_protocol = 'http' AND r.is_active = TRUE;
 
 -- name: SelectRoutesMany :many
 -- SelectRoutesMany
@@ -72,3 +112,30 @@ ON
     r.tunnel_name = t.identifier
 WHERE
     t.id = $1;
+
+-- name: SelectListenerRoutes :many
+-- SelectListenerRoutes active routes reserving a public port for protocol
+SELECT
+    *
+FROM
+    dino.routes
+WHERE
+    destination_protocol = $1 AND is_active = TRUE AND public_port IS NOT NULL;
+
+-- name: SelectTunnelUID :one
+-- SelectTunnelUID id of tunnel by name, agent sessions are keyed by tunnel id
+SELECT
+    id
+FROM
+    dino.tunnels
+WHERE
+    identifier = $1;
+
+-- name: SelectRouteTunnels :many
+-- SelectRouteTunnels tunnels of the routes with id or hostname ref
+SELECT DISTINCT
+    tunnel_name
+FROM
+    dino.routes
+WHERE
+    id::TEXT = @ref::TEXT OR hostname = @ref::TEXT;
@@ -12,13 +12,31 @@ import (
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
-const deleteRoute = `-- name: DeleteRoute :exec
-DELETE FROM dino.routes WHERE id = $1
+const deleteRoute = `-- name: DeleteRoute :one
+DELETE FROM dino.routes WHERE id = $1 RETURNING id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at, public_port, path_prefix, strip_prefix, match_headers, idle_timeout_ms, max_lifetime_ms
 `
 
-func (q *Queries) DeleteRoute(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.Exec(ctx, deleteRoute, id)
-	return err
+func (q *Queries) DeleteRoute(ctx context.Context, id uuid.UUID) (DinoRoute, error) {
+	row := q.db.QueryRow(ctx, deleteRoute, id)
+	var i DinoRoute
+	err := row.Scan(
+		&i.ID,
+		&i.TunnelName,
+		&i.Hostname,
+		&i.DestinationProtocol,
+		&i.DestinationIp,
+		&i.DestinationPort,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PublicPort,
+		&i.PathPrefix,
+		&i.StripPrefix,
+		&i.MatchHeaders,
+		&i.IdleTimeoutMs,
+		&i.MaxLifetimeMs,
+	)
+	return i, err
 }
 
 const insertRoute = `-- name: InsertRoute :one
@@ -27,10 +45,16 @@ INSERT INTO dino.routes (
     hostname,
     destination_protocol,
     destination_ip,
-    destination_port
+    destination_port,
+    public_port,
+    path_prefix,
+    strip_prefix,
+    match_headers,
+    idle_timeout_ms,
+    max_lifetime_ms
 ) VALUES ( 
-    $1, $2, $3, $4, $5
-) RETURNING id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at
+    $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+) RETURNING id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at, public_port, path_prefix, strip_prefix, match_headers, idle_timeout_ms, max_lifetime_ms
 `
 
 type InsertRouteParams struct {
@@ -39,6 +63,12 @@ type InsertRouteParams struct {
 	DestinationProtocol string
 	DestinationIp       string
 	DestinationPort     int32
+	PublicPort          pgtype.Int4
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        []byte
+	IdleTimeoutMs       int64
+	MaxLifetimeMs       int64
 }
 
 // InsertRoute insert new route record
@@ -49,6 +79,12 @@ func (q *Queries) InsertRoute(ctx context.Context, arg InsertRouteParams) (DinoR
 		arg.DestinationProtocol,
 		arg.DestinationIp,
 		arg.DestinationPort,
+		arg.PublicPort,
+		arg.PathPrefix,
+		arg.StripPrefix,
+		arg.MatchHeaders,
+		arg.IdleTimeoutMs,
+		arg.MaxLifetimeMs,
 	)
 	var i DinoRoute
 	err := row.Scan(
@@ -61,13 +97,23 @@ func (q *Queries) InsertRoute(ctx context.Context, arg InsertRouteParams) (DinoR
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PublicPort,
+		&i.PathPrefix,
+		&i.StripPrefix,
+		&i.MatchHeaders,
+		&i.IdleTimeoutMs,
+		&i.MaxLifetimeMs,
 	)
 	return i, err
 }
 
 const selectActiveRoute = `-- name: SelectActiveRoute :one
 SELECT
-    t.id
+    t.id,
+    r.hostname,
+    r.destination_protocol,
+    r.idle_timeout_ms,
+    r.max_lifetime_ms
 FROM
     dino.routes as r
 INNER JOIN 
@@ -75,15 +121,153 @@ INNER JOIN
 ON
     r.tunnel_name = t.identifier
 WHERE
-    hostname = $1 AND r.is_active = TRUE
+    (r.hostname = $1 OR r.hostname = $2) AND r.destination_protocol = $3 AND r.is_active = TRUE
+ORDER BY
+    r.hostname = $1 DESC, r.path_prefix = '' DESC, r.match_headers = '{}' DESC
+LIMIT 1
 `
 
-// SelectActiveRoute
-func (q *Queries) SelectActiveRoute(ctx context.Context, hostname string) (uuid.UUID, error) {
-	row := q.db.QueryRow(ctx, selectActiveRoute, hostname)
-	var id uuid.UUID
-	err := row.Scan(&id)
-	return id, err
+type SelectActiveRouteParams struct {
+	Hostname string
+	Wildcard string
+	Protocol string
+}
+
+type SelectActiveRouteRow struct {
+	ID                  uuid.UUID
+	Hostname            string
+	DestinationProtocol string
+	IdleTimeoutMs       int64
+	MaxLifetimeMs       int64
+}
+
+// SelectActiveRoute tunnel of hostname or its wildcard served over protocol, preferring the exact route without match rules
+func (q *Queries) SelectActiveRoute(ctx context.Context, arg SelectActiveRouteParams) (SelectActiveRouteRow, error) {
+	row := q.db.QueryRow(ctx, selectActiveRoute, arg.Hostname, arg.Wildcard, arg.Protocol)
+	var i SelectActiveRouteRow
+	err := row.Scan(
+		&i.ID,
+		&i.Hostname,
+		&i.DestinationProtocol,
+		&i.IdleTimeoutMs,
+		&i.MaxLifetimeMs,
+	)
+	return i, err
+}
+
+const selectActiveRules = `-- name: SelectActiveRules :many
+SELECT
+    r.id,
+    t.id AS tunnel_id,
+    r.hostname,
+    r.destination_protocol,
+    r.path_prefix,
+    r.strip_prefix,
+    r.match_headers,
+    r.idle_timeout_ms,
+    r.max_lifetime_ms
+FROM
+    dino.routes as r
+INNER JOIN 
+    dino.tunnels as t
+ON
+    r.tunnel_name = t.identifier
+WHERE
+    (r.hostname = $1 OR r.hostname = $2) AND r.destination_protocol = 'http' AND r.is_active = TRUE
+`
+
+type SelectActiveRulesParams struct {
+	Hostname string
+	Wildcard string
+}
+
+type SelectActiveRulesRow struct {
+	ID                  uuid.UUID
+	TunnelID            uuid.UUID
+	Hostname            string
+	DestinationProtocol string
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        []byte
+	IdleTimeoutMs       int64
+	MaxLifetimeMs       int64
+}
+
+// SelectActiveRules match rules of every active http route for hostname or its wildcard
+func (q *Queries) SelectActiveRules(ctx context.Context, arg SelectActiveRulesParams) ([]SelectActiveRulesRow, error) {
+	rows, err := q.db.Query(ctx, selectActiveRules, arg.Hostname, arg.Wildcard)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []SelectActiveRulesRow{}
+	for rows.Next() {
+		var i SelectActiveRulesRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.TunnelID,
+			&i.Hostname,
+			&i.DestinationProtocol,
+			&i.PathPrefix,
+			&i.StripPrefix,
+			&i.MatchHeaders,
+			&i.IdleTimeoutMs,
+			&i.MaxLifetimeMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const selectListenerRoutes = `-- name: SelectListenerRoutes :many
+SELECT
+    id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at, public_port, path_prefix, strip_prefix, match_headers, idle_timeout_ms, max_lifetime_ms
+FROM
+    dino.routes
+WHERE
+    destination_protocol = $1 AND is_active = TRUE AND public_port IS NOT NULL
+`
+
+// SelectListenerRoutes active routes reserving a public port for protocol
+func (q *Queries) SelectListenerRoutes(ctx context.Context, destinationProtocol string) ([]DinoRoute, error) {
+	rows, err := q.db.Query(ctx, selectListenerRoutes, destinationProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []DinoRoute{}
+	for rows.Next() {
+		var i DinoRoute
+		if err := rows.Scan(
+			&i.ID,
+			&i.TunnelName,
+			&i.Hostname,
+			&i.DestinationProtocol,
+			&i.DestinationIp,
+			&i.DestinationPort,
+			&i.IsActive,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.PublicPort,
+			&i.PathPrefix,
+			&i.StripPrefix,
+			&i.MatchHeaders,
+			&i.IdleTimeoutMs,
+			&i.MaxLifetimeMs,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
 const selectManyRoutes = `-- name: SelectManyRoutes :many
@@ -140,7 +324,7 @@ func (q *Queries) SelectManyRoutes(ctx context.Context, arg SelectManyRoutesPara
 
 const selectRoute = `-- name: SelectRoute :one
 SELECT
-    id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at
+    id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at, public_port, path_prefix, strip_prefix, match_headers, idle_timeout_ms, max_lifetime_ms
 FROM 
     dino.routes
 WHERE
@@ -160,13 +344,49 @@ func (q *Queries) SelectRoute(ctx context.Context, id uuid.UUID) (DinoRoute, err
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PublicPort,
+		&i.PathPrefix,
+		&i.StripPrefix,
+		&i.MatchHeaders,
+		&i.IdleTimeoutMs,
+		&i.MaxLifetimeMs,
 	)
 	return i, err
 }
 
+const selectRouteTunnels = `-- name: SelectRouteTunnels :many
+SELECT DISTINCT
+    tunnel_name
+FROM
+    dino.routes
+WHERE
+    id::TEXT = $1::TEXT OR hostname = $1::TEXT
+`
+
+// SelectRouteTunnels tunnels of the routes with id or hostname ref
+func (q *Queries) SelectRouteTunnels(ctx context.Context, ref string) ([]string, error) {
+	rows, err := q.db.Query(ctx, selectRouteTunnels, ref)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var tunnel_name string
+		if err := rows.Scan(&tunnel_name); err != nil {
+			return nil, err
+		}
+		items = append(items, tunnel_name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const selectRoutesMany = `-- name: SelectRoutesMany :many
 SELECT
-    r.id, r.tunnel_name, r.hostname, r.destination_protocol, r.destination_ip, r.destination_port, r.is_active, r.created_at, r.updated_at
+    r.id, r.tunnel_name, r.hostname, r.destination_protocol, r.destination_ip, r.destination_port, r.is_active, r.created_at, r.updated_at, r.public_port, r.path_prefix, r.strip_prefix, r.match_headers, r.idle_timeout_ms, r.max_lifetime_ms
 FROM 
     dino.routes as r
 INNER JOIN
@@ -197,6 +417,12 @@ func (q *Queries) SelectRoutesMany(ctx context.Context, id uuid.UUID) ([]DinoRou
 			&i.IsActive,
 			&i.CreatedAt,
 			&i.UpdatedAt,
+			&i.PublicPort,
+			&i.PathPrefix,
+			&i.StripPrefix,
+			&i.MatchHeaders,
+			&i.IdleTimeoutMs,
+			&i.MaxLifetimeMs,
 		); err != nil {
 			return nil, err
 		}
@@ -208,6 +434,23 @@ func (q *Queries) SelectRoutesMany(ctx context.Context, id uuid.UUID) ([]DinoRou
 	return items, nil
 }
 
+const selectTunnelUID = `-- name: SelectTunnelUID :one
+SELECT
+    id
+FROM
+    dino.tunnels
+WHERE
+    identifier = $1
+`
+
+// SelectTunnelUID id of tunnel by name, agent sessions are keyed by tunnel id
+func (q *Queries) SelectTunnelUID(ctx context.Context, identifier string) (uuid.UUID, error) {
+	row := q.db.QueryRow(ctx, selectTunnelUID, identifier)
+	var id uuid.UUID
+	err := row.Scan(&id)
+	return id, err
+}
+
 const updateRoute = `-- name: UpdateRoute :one
 UPDATE dino.routes
 SET
@@ -215,10 +458,16 @@ SET
     destination_ip = $3,
     destination_port = $4,
     destination_protocol = $5,
-    is_active = $6
+    is_active = $6,
+    public_port = $7,
+    path_prefix = $8,
+    strip_prefix = $9,
+    match_headers = $10,
+    idle_timeout_ms = $11,
+    max_lifetime_ms = $12
 WHERE
     id = $1 
-RETURNING id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at
+RETURNING id, tunnel_name, hostname, destination_protocol, destination_ip, destination_port, is_active, created_at, updated_at, public_port, path_prefix, strip_prefix, match_headers, idle_timeout_ms, max_lifetime_ms
 `
 
 type UpdateRouteParams struct {
@@ -228,6 +477,12 @@ type UpdateRouteParams struct {
 	DestinationPort     int32
 	DestinationProtocol string
 	IsActive            bool
+	PublicPort          pgtype.Int4
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        []byte
+	IdleTimeoutMs       int64
+	MaxLifetimeMs       int64
 }
 
 func (q *Queries) UpdateRoute(ctx context.Context, arg UpdateRouteParams) (DinoRoute, error) {
@@ -238,6 +493,12 @@ func (q *Queries) UpdateRoute(ctx context.Context, arg UpdateRouteParams) (DinoR
 		arg.DestinationPort,
 		arg.DestinationProtocol,
 		arg.IsActive,
+		arg.PublicPort,
+		arg.PathPrefix,
+		arg.StripPrefix,
+		arg.MatchHeaders,
+		arg.IdleTimeoutMs,
+		arg.MaxLifetimeMs,
 	)
 	var i DinoRoute
 	err := row.Scan(
@@ -250,6 +511,12 @@ func (q *Queries) UpdateRoute(ctx context.Context, arg UpdateRouteParams) (DinoR
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PublicPort,
+		&i.PathPrefix,
+		&i.StripPrefix,
+		&i.MatchHeaders,
+		&i.IdleTimeoutMs,
+		&i.MaxLifetimeMs,
 	)
 	return i, err
 }
@@ -2,15 +2,24 @@ package routes
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
 	"soft.structx.io/dino/database"
 	"soft.structx.io/dino/internal/routes/queries"
 	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/routing"
 )
 
+// ErrNoMatch no active route rule matches the request
+var ErrNoMatch = errors.New("no matching route")
+
 // RouteCreate
 type RouteCreate struct {
 	Tunnel string
@@ -20,6 +29,21 @@ type RouteCreate struct {
 	DestinationProtocol string
 	DestinationIP       string
 	DestinationPort     uint32
+
+	// PublicPort server port reserved for tcp and udp routes
+	PublicPort uint32
+
+	// PathPrefix request path prefix the route matches, empty matches every path
+	PathPrefix string
+	// StripPrefix remove path prefix before forwarding
+	StripPrefix bool
+	// MatchHeaders request header values the route requires
+	MatchHeaders map[string]string
+
+	// IdleTimeout closes sessions without traffic, zero uses the server default
+	IdleTimeout time.Duration
+	// MaxLifetime closes sessions open longer, zero uses the server default
+	MaxLifetime time.Duration
 }
 
 // Route
@@ -30,6 +54,12 @@ type Route struct {
 	DestinationProtocol string
 	DestinationIP       string
 	DestinationPort     uint32
+	PublicPort          uint32
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        map[string]string
+	IdleTimeout         time.Duration
+	MaxLifetime         time.Duration
 	Enabled             bool
 	CreatedAt           time.Time
 	UpdatedAt           *time.Time
@@ -42,9 +72,38 @@ type RouteUpdate struct {
 	DestinationProtocol string
 	DestinationIP       string
 	DestinationPort     uint32
+	PublicPort          uint32
+	PathPrefix          string
+	StripPrefix         bool
+	MatchHeaders        map[string]string
+	IdleTimeout         time.Duration
+	MaxLifetime         time.Duration
 	Enabled             bool
 }
 
+// RouteRequest http request attributes matched against route rules
+type RouteRequest struct {
+	Hostname string
+	Path     string
+	Header   http.Header
+}
+
+// RouteMatch most specific active route of a request
+type RouteMatch struct {
+	RouteID     string
+	TunnelUID   string
+	PathPrefix  string
+	StripPrefix bool
+	// Protocol destination protocol the agent serves the route with
+	Protocol string
+	// Label subdomain substituted by a wildcard route, empty on exact matches
+	Label string
+
+	// IdleTimeout and MaxLifetime session limits of the route, zero uses the server default
+	IdleTimeout time.Duration
+	MaxLifetime time.Duration
+}
+
 // RoutePartial
 type RoutePartial struct {
 	ID        string
@@ -74,10 +133,16 @@ type Service interface {
 	// Delete
 	Delete(context.Context, string) error
 
-	// Active
-	Active(context.Context, string) (string, error)
+	// Active route of a hostname served over a protocol
+	Active(context.Context, string, string) (RouteMatch, error)
+	// Match http route of a request
+	Match(context.Context, RouteRequest) (RouteMatch, error)
 	// Sync
 	Sync(context.Context, string) ([]Route, error)
+	// Listeners
+	Listeners(context.Context, string) ([]Route, error)
+	// Tunnels names of the tunnels holding the routes with an id or hostname
+	Tunnels(context.Context, string) ([]string, error)
 }
 
 type serviceImpl struct {
@@ -100,12 +165,35 @@ func (s *serviceImpl) Create(ctx context.Context, create RouteCreate) (Route, er
 	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
 	defer cancel()
 
+	if err := routing.ValidateWildcard(create.Hostname); err != nil {
+		return Route{}, err
+	}
+
+	if err := validatePathPrefix(create.PathPrefix); err != nil {
+		return Route{}, err
+	}
+
+	if err := validateTimeouts(create.IdleTimeout, create.MaxLifetime); err != nil {
+		return Route{}, err
+	}
+
+	headers, err := encodeHeaders(create.MatchHeaders)
+	if err != nil {
+		return Route{}, err
+	}
+
 	params := queries.InsertRouteParams{
 		TunnelName:          create.Tunnel,
 		Hostname:            create.Hostname,
 		DestinationProtocol: create.DestinationProtocol,
 		DestinationIp:       create.DestinationIP,
 		DestinationPort:     int32(create.DestinationPort),
+		PublicPort:          pgPort(create.PublicPort),
+		PathPrefix:          create.PathPrefix,
+		StripPrefix:         create.StripPrefix,
+		MatchHeaders:        headers,
+		IdleTimeoutMs:       create.IdleTimeout.Milliseconds(),
+		MaxLifetimeMs:       create.MaxLifetime.Milliseconds(),
 	}
 
 	sqlRoute, err := queries.New(s.db).InsertRoute(timeout, params)
@@ -113,14 +201,7 @@ func (s *serviceImpl) Create(ctx context.Context, create RouteCreate) (Route, er
 		return Route{}, fmt.Errorf("failed to execute insert route query: %w", err)
 	}
 
-	if err := s.br.Publish("dino.routes", &pubsub.RouteConfig{
-		TunnelUID:    sqlRoute.TunnelName,
-		Hostname:     sqlRoute.Hostname,
-		DestProtocol: sqlRoute.DestinationProtocol,
-		DestAddr:     sqlRoute.DestinationIp,
-		DestPort:     uint32(sqlRoute.DestinationPort),
-		IsDelete:     false,
-	}); err != nil {
+	if err := s.publish(timeout, sqlRoute, false); err != nil {
 		return Route{}, fmt.Errorf("failed to publish route creation: %w", err)
 	}
 
@@ -174,11 +255,36 @@ func (s *serviceImpl) Update(ctx context.Context, args RouteUpdate) (Route, erro
 		return Route{}, fmt.Errorf("uuid.Parse: %w", err)
 	}
 
+	if err := routing.ValidateWildcard(args.Hostname); err != nil {
+		return Route{}, err
+	}
+
+	if err := validatePathPrefix(args.PathPrefix); err != nil {
+		return Route{}, err
+	}
+
+	if err := validateTimeouts(args.IdleTimeout, args.MaxLifetime); err != nil {
+		return Route{}, err
+	}
+
+	headers, err := encodeHeaders(args.MatchHeaders)
+	if err != nil {
+		return Route{}, err
+	}
+
 	params := queries.UpdateRouteParams{
-		ID:              routeUID,
-		Hostname:        args.Hostname,
-		DestinationIp:   args.DestinationIP,
-		DestinationPort: int32(args.DestinationPort),
+		ID:                  routeUID,
+		Hostname:            args.Hostname,
+		DestinationIp:       args.DestinationIP,
+		DestinationPort:     int32(args.DestinationPort),
+		DestinationProtocol: args.DestinationProtocol,
+		IsActive:            args.Enabled,
+		PublicPort:          pgPort(args.PublicPort),
+		PathPrefix:          args.PathPrefix,
+		StripPrefix:         args.StripPrefix,
+		MatchHeaders:        headers,
+		IdleTimeoutMs:       args.IdleTimeout.Milliseconds(),
+		MaxLifetimeMs:       args.MaxLifetime.Milliseconds(),
 	}
 
 	sqlRoute, err := queries.New(s.db).UpdateRoute(timeout, params)
@@ -186,15 +292,8 @@ func (s *serviceImpl) Update(ctx context.Context, args RouteUpdate) (Route, erro
 		return Route{}, fmt.Errorf("failed to execute update route query: %w", err)
 	}
 
-	if err := s.br.Publish("dino.routes", &pubsub.RouteConfig{
-		TunnelUID:    sqlRoute.TunnelName,
-		Hostname:     sqlRoute.Hostname,
-		DestProtocol: sqlRoute.DestinationProtocol,
-		DestAddr:     sqlRoute.DestinationIp,
-		DestPort:     uint32(sqlRoute.DestinationPort),
-		IsDelete:     false,
-	}); err != nil {
-		return Route{}, fmt.Errorf("failed to publish route creation: %w", err)
+	if err := s.publish(timeout, sqlRoute, !sqlRoute.IsActive); err != nil {
+		return Route{}, fmt.Errorf("failed to publish route update: %w", err)
 	}
 
 	return dtoRoute(sqlRoute), nil
@@ -210,30 +309,98 @@ func (s *serviceImpl) Delete(ctx context.Context, routeID string) error {
 		return fmt.Errorf("uuid.Parse: %w", err)
 	}
 
-	// if err := s.br.Publish("dino.routes", &pubsub.RouteConfig{
-	// 	TunnelUID:    sqlRoute.TunnelName,
-	// 	Hostname:     sqlRoute.Hostname,
-	// 	DestProtocol: sqlRoute.DestinationProtocol,
-	// 	DestAddr:     sqlRoute.DestinationIp,
-	// 	DestPort:     uint32(sqlRoute.DestinationPort),
-	// 	IsDelete:     false,
-	// }); err != nil {
-	// 	return Route{}, fmt.Errorf("failed to publish route creation: %w", err)
-	// }
+	sqlRoute, err := queries.New(s.db).DeleteRoute(timeout, routeUID)
+	if err != nil {
+		return fmt.Errorf("failed to execute delete route query: %w", err)
+	}
+
+	if err := s.publish(timeout, sqlRoute, true); err != nil {
+		return fmt.Errorf("failed to publish route deletion: %w", err)
+	}
 
-	return queries.New(s.db).DeleteRoute(timeout, routeUID)
+	return nil
 }
 
 // Active
-func (s *serviceImpl) Active(ctx context.Context, hostname string) (string, error) {
+func (s *serviceImpl) Active(ctx context.Context, hostname, protocol string) (RouteMatch, error) {
 	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
 	defer cancel()
 
-	activeUID, err := queries.New(s.db).SelectActiveRoute(timeout, hostname)
+	wildcard, label, _ := routing.Wildcard(hostname)
+	row, err := queries.New(s.db).SelectActiveRoute(timeout, queries.SelectActiveRouteParams{
+		Hostname: hostname,
+		Wildcard: wildcard,
+		Protocol: protocol,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to execute select active route query: %w", err)
+		return RouteMatch{}, fmt.Errorf("failed to execute select active route query: %w", err)
 	}
-	return activeUID.String(), nil
+	if row.Hostname == hostname {
+		label = ""
+	}
+	return RouteMatch{
+		TunnelUID:   row.ID.String(),
+		Protocol:    row.DestinationProtocol,
+		Label:       label,
+		IdleTimeout: time.Duration(row.IdleTimeoutMs) * time.Millisecond,
+		MaxLifetime: time.Duration(row.MaxLifetimeMs) * time.Millisecond,
+	}, nil
+}
+
+// Match
+func (s *serviceImpl) Match(ctx context.Context, req RouteRequest) (RouteMatch, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	wildcard, label, _ := routing.Wildcard(req.Hostname)
+	rows, err := queries.New(s.db).SelectActiveRules(timeout, queries.SelectActiveRulesParams{
+		Hostname: req.Hostname,
+		Wildcard: wildcard,
+	})
+	if err != nil {
+		return RouteMatch{}, fmt.Errorf("failed to execute select active rules query: %w", err)
+	}
+
+	// exact hostname rules take precedence, wildcard rules are only tried
+	// when none of them match
+	exact := make([]routing.Rule, 0, len(rows))
+	wild := make([]routing.Rule, 0, len(rows))
+	for _, r := range rows {
+		rule := routing.Rule{
+			ID:         r.ID.String(),
+			PathPrefix: r.PathPrefix,
+			Headers:    decodeHeaders(r.MatchHeaders),
+		}
+		if r.Hostname == req.Hostname {
+			exact = append(exact, rule)
+		} else {
+			wild = append(wild, rule)
+		}
+	}
+
+	rule, ok := routing.MostSpecific(exact, req.Path, req.Header)
+	if ok {
+		label = ""
+	} else if rule, ok = routing.MostSpecific(wild, req.Path, req.Header); !ok {
+		return RouteMatch{}, ErrNoMatch
+	}
+
+	for _, r := range rows {
+		if r.ID.String() == rule.ID {
+			return RouteMatch{
+				RouteID:     rule.ID,
+				TunnelUID:   r.TunnelID.String(),
+				Protocol:    r.DestinationProtocol,
+				PathPrefix:  r.PathPrefix,
+				StripPrefix: r.StripPrefix,
+				Label:       label,
+				IdleTimeout: time.Duration(r.IdleTimeoutMs) * time.Millisecond,
+				MaxLifetime: time.Duration(r.MaxLifetimeMs) * time.Millisecond,
+			}, nil
+		}
+	}
+
+	return RouteMatch{}, ErrNoMatch
 }
 
 // Sync
@@ -254,6 +421,97 @@ func (s *serviceImpl) Sync(ctx context.Context, tunnelUID string) ([]Route, erro
 	return dtoRoutes(rows), nil
 }
 
+// Listeners
+func (s *serviceImpl) Listeners(ctx context.Context, protocol string) ([]Route, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	rows, err := queries.New(s.db).SelectListenerRoutes(timeout, protocol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select listener routes query: %w", err)
+	}
+
+	return dtoRoutes(rows), nil
+}
+
+// Tunnels
+func (s *serviceImpl) Tunnels(ctx context.Context, ref string) ([]string, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	tunnels, err := queries.New(s.db).SelectRouteTunnels(timeout, ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute select route tunnels query: %w", err)
+	}
+
+	return tunnels, nil
+}
+
+// publish route change to the tunnel holding the route
+func (s *serviceImpl) publish(ctx context.Context, r queries.DinoRoute, isDelete bool) error {
+	tunnelUID, err := queries.New(s.db).SelectTunnelUID(ctx, r.TunnelName)
+	if err != nil {
+		return fmt.Errorf("failed to execute select tunnel uid query: %w", err)
+	}
+
+	return s.br.Publish("dino.routes", routeConfig(r, tunnelUID.String(), isDelete))
+}
+
+func routeConfig(r queries.DinoRoute, tunnelUID string, isDelete bool) *pubsub.RouteConfig {
+	return &pubsub.RouteConfig{
+		TunnelUID:    tunnelUID,
+		Hostname:     r.Hostname,
+		DestProtocol: r.DestinationProtocol,
+		DestAddr:     r.DestinationIp,
+		DestPort:     uint32(r.DestinationPort),
+		PublicPort:   uint32(r.PublicPort.Int32),
+		RouteID:      r.ID.String(),
+		PathPrefix:   r.PathPrefix,
+		Headers:      decodeHeaders(r.MatchHeaders),
+		IsDelete:     isDelete,
+	}
+}
+
+func validatePathPrefix(prefix string) error {
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		return fmt.Errorf("path prefix %q must start with /", prefix)
+	}
+	return nil
+}
+
+func validateTimeouts(idle, lifetime time.Duration) error {
+	if idle < 0 || lifetime < 0 {
+		return fmt.Errorf("session timeouts must not be negative: idle %s, max lifetime %s", idle, lifetime)
+	}
+	return nil
+}
+
+// encodeHeaders json object of canonical header names, identical conditions encode identically
+func encodeHeaders(headers map[string]string) ([]byte, error) {
+	canonical := make(map[string]string, len(headers))
+	for name, value := range headers {
+		canonical[http.CanonicalHeaderKey(name)] = value
+	}
+
+	b, err := json.Marshal(canonical)
+	if err != nil {
+		return nil, fmt.Errorf("json.Marshal: %w", err)
+	}
+	return b, nil
+}
+
+func decodeHeaders(b []byte) map[string]string {
+	var headers map[string]string
+	if err := json.Unmarshal(b, &headers); err != nil || len(headers) == 0 {
+		return nil
+	}
+	return headers
+}
+
+func pgPort(port uint32) pgtype.Int4 {
+	return pgtype.Int4{Int32: int32(port), Valid: port > 0}
+}
+
 func dtoRoute(r queries.DinoRoute) Route {
 	return Route{
 		ID:                  r.ID.String(),
@@ -262,6 +520,12 @@ func dtoRoute(r queries.DinoRoute) Route {
 		DestinationProtocol: r.DestinationProtocol,
 		DestinationIP:       r.DestinationIp,
 		DestinationPort:     uint32(r.DestinationPort),
+		PublicPort:          uint32(r.PublicPort.Int32),
+		PathPrefix:          r.PathPrefix,
+		StripPrefix:         r.StripPrefix,
+		MatchHeaders:        decodeHeaders(r.MatchHeaders),
+		IdleTimeout:         time.Duration(r.IdleTimeoutMs) * time.Millisecond,
+		MaxLifetime:         time.Duration(r.MaxLifetimeMs) * time.Millisecond,
 		Enabled:             r.IsActive,
 		CreatedAt:           r.CreatedAt.Time,
 	}
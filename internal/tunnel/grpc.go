@@ -2,10 +2,12 @@ package tunnel
 
 import (
 	"context"
+	"errors"
 
 	"github.com/structx/teapot"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/emptypb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"soft.structx.io/dino/auth"
@@ -96,6 +98,40 @@ func (g *grpcServer) UpdateTunnel(ctx context.Context, in *pb.UpdateTunnelReques
 	return newUpdateTunnelResponse(tunnel), nil
 }
 
+// RotateTunnelCredentials
+func (g *grpcServer) RotateTunnelCredentials(ctx context.Context, in *pb.RotateTunnelCredentialsRequest) (*pb.RotateTunnelCredentialsResponse, error) {
+	tunnel, sharedSecret, err := g.s.Rotate(ctx, in.GetName(), in.GetGracePeriod().AsDuration())
+	if errors.Is(err, ErrTunnelNotFound) {
+		return nil, status.Error(codes.NotFound, codes.NotFound.String())
+	} else if errors.Is(err, ErrInvalidGracePeriod) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		g.l.Error("rotate tunnel credentials", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	token, err := g.a.GenerateJWT(tunnel.Name, tunnel.ID, sharedSecret.Secret)
+	if err != nil {
+		g.l.Error("generate jwt", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	return newRotateTunnelCredentialsResponse(tunnel, token), nil
+}
+
+func newRotateTunnelCredentialsResponse(t Tunnel, sharedSecret string) *pb.RotateTunnelCredentialsResponse {
+	resp := &pb.RotateTunnelCredentialsResponse{
+		Tunnel: pbTunnel(t),
+		AuthDetails: &pb.RotateTunnelCredentialsResponse_SecretKey{
+			SecretKey: sharedSecret,
+		},
+	}
+	if t.PreviousExpiresAt != nil {
+		resp.PreviousExpiresAt = timestamppb.New(*t.PreviousExpiresAt)
+	}
+	return resp
+}
+
 func newUpdateTunnelResponse(t Tunnel) *pb.UpdateTunnelResponse {
 	return &pb.UpdateTunnelResponse{
 		Tunnel: pbTunnel(t),
@@ -103,11 +139,17 @@ func newUpdateTunnelResponse(t Tunnel) *pb.UpdateTunnelResponse {
 }
 
 func pbTunnel(t Tunnel) *pb.Tunnel {
-	return &pb.Tunnel{
+	pt := &pb.Tunnel{
 		Id:        t.ID,
 		Name:      t.Name,
 		CreatedAt: timestamppb.New(t.CreatedAt),
+		IsActive:  t.IsActive,
+	}
+	if t.LastSeenAt != nil {
+		pt.LastSeenAt = timestamppb.New(*t.LastSeenAt)
+		pt.Rtt = durationpb.New(t.RTT)
 	}
+	return pt
 }
 
 func newCreateTunnelResponse(t Tunnel, sharedSecret string) *pb.CreateTunnelResponse {
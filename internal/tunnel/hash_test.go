@@ -1 +0,0 @@
-package tunnel
@@ -0,0 +1,41 @@
+package tunnel
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// signingKeyLength bytes of a tunnel signing key, HS256 keys should match the hash size
+const signingKeyLength = 32
+
+// newSigningKey random signing key of tunnel tokens and the key sealed for
+// storage in the row of tunnelID
+func (s *serviceImpl) newSigningKey(tunnelID uuid.UUID) ([]byte, []byte, error) {
+	signingKey := make([]byte, signingKeyLength)
+	if _, err := rand.Read(signingKey); err != nil {
+		return nil, nil, fmt.Errorf("rand.Read: %w", err)
+	}
+
+	sealed, err := s.cipher.Seal(signingKey, keyData(tunnelID))
+	if err != nil {
+		return nil, nil, fmt.Errorf("cipher.Seal: %w", err)
+	}
+
+	return signingKey, sealed, nil
+}
+
+// keyData additional data binding a sealed signing key to its tunnel
+func keyData(tunnelID uuid.UUID) []byte {
+	return []byte(tunnelID.String())
+}
+
+// KeyID fingerprint of a signing key, identifies the key a connection
+// authenticated with without revealing it
+func KeyID(signingKey []byte) string {
+	sum := sha256.Sum256(signingKey)
+	return hex.EncodeToString(sum[:8])
+}
@@ -7,6 +7,7 @@ import (
 	"soft.structx.io/dino/database"
 	"soft.structx.io/dino/gateway"
 	pb "soft.structx.io/dino/pb/tunnels/v1"
+	"soft.structx.io/dino/pubsub"
 )
 
 // Params
@@ -17,7 +18,10 @@ type Params struct {
 
 	DB database.DBTX
 
-	Auth auth.Authenticator
+	Auth   auth.Authenticator
+	Cipher auth.KeyCipher
+
+	Broker pubsub.Broker
 }
 
 // Result
@@ -33,7 +37,7 @@ type Result struct {
 var Module = fx.Module("tunnel", fx.Provide(newModule))
 
 func newModule(p Params) Result {
-	svc := newService(p.DB)
+	svc := newService(p.Logger, p.DB, p.Broker, p.Cipher)
 	s := newGrpcServer(p.Logger, svc, p.Auth)
 	return Result{
 		Transport: gateway.Transport{
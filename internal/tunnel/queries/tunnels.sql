@@ -1,10 +1,12 @@
 
 -- name: InsertTunnel :one
+-- InsertTunnel the id is generated by the caller, signing keys are sealed for it
 INSERT INTO dino.tunnels (
+    id,
     identifier,
-    token_hash
+    signing_key
 ) VALUES (
-    $1, $2
+    $1, $2, $3
 ) RETURNING *;
 
 -- name: SelectTunnel :one
@@ -17,7 +19,9 @@ WHERE
 
 -- name: SelectTunnelToken :one
 SELECT
-    token_hash
+    signing_key,
+    previous_signing_key,
+    previous_token_expires_at
 FROM
     dino.tunnels
 WHERE
@@ -41,5 +45,41 @@ WHERE
     identifier = $1
 RETURNING *;
 
+-- name: RotateTunnelToken :one
+-- RotateTunnelToken replace the signing key, the replaced key is kept for
+-- grace_ms after updated_at
+UPDATE dino.tunnels
+SET
+    previous_signing_key = CASE
+        WHEN sqlc.arg(grace_ms)::BIGINT > 0 THEN signing_key
+        ELSE NULL
+    END,
+    previous_token_expires_at = CASE
+        WHEN sqlc.arg(grace_ms)::BIGINT > 0 THEN CURRENT_TIMESTAMP + sqlc.arg(grace_ms)::BIGINT * INTERVAL '1 millisecond'
+        ELSE NULL
+    END,
+    signing_key = @signing_key,
+    updated_at = CURRENT_TIMESTAMP
+WHERE
+    id = @id
+RETURNING *;
+
 -- name: DeleteTunnel :execresult
-DELETE FROM dino.tunnels WHERE identifier = $1;
\ No newline at end of file
+DELETE FROM dino.tunnels WHERE identifier = $1;
+
+-- name: UpdateTunnelActive :execresult
+-- UpdateTunnelActive flag whether an agent holds the tunnel stream
+UPDATE dino.tunnels
+SET
+    is_active = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE
+    id = $1;
+-- name: UpdateTunnelHeartbeat :execresult
+-- UpdateTunnelHeartbeat record the round trip of the latest answered ping
+UPDATE dino.tunnels
+SET
+    last_seen_at = CURRENT_TIMESTAMP,
+    rtt_micros = $2
+WHERE
+    id = $1;
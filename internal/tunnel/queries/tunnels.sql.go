@@ -23,28 +23,35 @@ func (q *Queries) DeleteTunnel(ctx context.Context, identifier string) (pgconn.C
 
 const insertTunnel = `-- name: InsertTunnel :one
 INSERT INTO dino.tunnels (
+    id,
     identifier,
-    token_hash
+    signing_key
 ) VALUES (
-    $1, $2
-) RETURNING id, identifier, token_hash, is_active, created_at, updated_at
+    $1, $2, $3
+) RETURNING id, identifier, is_active, created_at, updated_at, last_seen_at, rtt_micros, previous_token_expires_at, signing_key, previous_signing_key
 `
 
 type InsertTunnelParams struct {
+	ID         uuid.UUID
 	Identifier string
-	TokenHash  string
+	SigningKey []byte
 }
 
+// InsertTunnel the id is generated by the caller, signing keys are sealed for it
 func (q *Queries) InsertTunnel(ctx context.Context, arg InsertTunnelParams) (DinoTunnel, error) {
-	row := q.db.QueryRow(ctx, insertTunnel, arg.Identifier, arg.TokenHash)
+	row := q.db.QueryRow(ctx, insertTunnel, arg.ID, arg.Identifier, arg.SigningKey)
 	var i DinoTunnel
 	err := row.Scan(
 		&i.ID,
 		&i.Identifier,
-		&i.TokenHash,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.RttMicros,
+		&i.PreviousTokenExpiresAt,
+		&i.SigningKey,
+		&i.PreviousSigningKey,
 	)
 	return i, err
 }
@@ -91,9 +98,53 @@ func (q *Queries) ListTunnels(ctx context.Context, arg ListTunnelsParams) ([]Lis
 	return items, nil
 }
 
+const rotateTunnelToken = `-- name: RotateTunnelToken :one
+UPDATE dino.tunnels
+SET
+    previous_signing_key = CASE
+        WHEN $1::BIGINT > 0 THEN signing_key
+        ELSE NULL
+    END,
+    previous_token_expires_at = CASE
+        WHEN $1::BIGINT > 0 THEN CURRENT_TIMESTAMP + $1::BIGINT * INTERVAL '1 millisecond'
+        ELSE NULL
+    END,
+    signing_key = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE
+    id = $3
+RETURNING id, identifier, is_active, created_at, updated_at, last_seen_at, rtt_micros, previous_token_expires_at, signing_key, previous_signing_key
+`
+
+type RotateTunnelTokenParams struct {
+	GraceMs    int64
+	SigningKey []byte
+	ID         uuid.UUID
+}
+
+// RotateTunnelToken replace the signing key, the replaced key is kept for
+// grace_ms after updated_at
+func (q *Queries) RotateTunnelToken(ctx context.Context, arg RotateTunnelTokenParams) (DinoTunnel, error) {
+	row := q.db.QueryRow(ctx, rotateTunnelToken, arg.GraceMs, arg.SigningKey, arg.ID)
+	var i DinoTunnel
+	err := row.Scan(
+		&i.ID,
+		&i.Identifier,
+		&i.IsActive,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.RttMicros,
+		&i.PreviousTokenExpiresAt,
+		&i.SigningKey,
+		&i.PreviousSigningKey,
+	)
+	return i, err
+}
+
 const selectTunnel = `-- name: SelectTunnel :one
 SELECT
-    id, identifier, token_hash, is_active, created_at, updated_at
+    id, identifier, is_active, created_at, updated_at, last_seen_at, rtt_micros, previous_token_expires_at, signing_key, previous_signing_key
 FROM
     dino.tunnels
 WHERE
@@ -106,28 +157,40 @@ func (q *Queries) SelectTunnel(ctx context.Context, identifier string) (DinoTunn
 	err := row.Scan(
 		&i.ID,
 		&i.Identifier,
-		&i.TokenHash,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.RttMicros,
+		&i.PreviousTokenExpiresAt,
+		&i.SigningKey,
+		&i.PreviousSigningKey,
 	)
 	return i, err
 }
 
 const selectTunnelToken = `-- name: SelectTunnelToken :one
 SELECT
-    token_hash
+    signing_key,
+    previous_signing_key,
+    previous_token_expires_at
 FROM
     dino.tunnels
 WHERE
     id = $1
 `
 
-func (q *Queries) SelectTunnelToken(ctx context.Context, id uuid.UUID) (string, error) {
+type SelectTunnelTokenRow struct {
+	SigningKey             []byte
+	PreviousSigningKey     []byte
+	PreviousTokenExpiresAt pgtype.Timestamp
+}
+
+func (q *Queries) SelectTunnelToken(ctx context.Context, id uuid.UUID) (SelectTunnelTokenRow, error) {
 	row := q.db.QueryRow(ctx, selectTunnelToken, id)
-	var token_hash string
-	err := row.Scan(&token_hash)
-	return token_hash, err
+	var i SelectTunnelTokenRow
+	err := row.Scan(&i.SigningKey, &i.PreviousSigningKey, &i.PreviousTokenExpiresAt)
+	return i, err
 }
 
 const updateTunnel = `-- name: UpdateTunnel :one
@@ -136,7 +199,7 @@ SET
     identifier = $2
 WHERE 
     identifier = $1
-RETURNING id, identifier, token_hash, is_active, created_at, updated_at
+RETURNING id, identifier, is_active, created_at, updated_at, last_seen_at, rtt_micros, previous_token_expires_at, signing_key, previous_signing_key
 `
 
 type UpdateTunnelParams struct {
@@ -150,10 +213,52 @@ func (q *Queries) UpdateTunnel(ctx context.Context, arg UpdateTunnelParams) (Din
 	err := row.Scan(
 		&i.ID,
 		&i.Identifier,
-		&i.TokenHash,
 		&i.IsActive,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.LastSeenAt,
+		&i.RttMicros,
+		&i.PreviousTokenExpiresAt,
+		&i.SigningKey,
+		&i.PreviousSigningKey,
 	)
 	return i, err
 }
+
+const updateTunnelActive = `-- name: UpdateTunnelActive :execresult
+UPDATE dino.tunnels
+SET
+    is_active = $2,
+    updated_at = CURRENT_TIMESTAMP
+WHERE
+    id = $1
+`
+
+type UpdateTunnelActiveParams struct {
+	ID       uuid.UUID
+	IsActive bool
+}
+
+// UpdateTunnelActive flag whether an agent holds the tunnel stream
+func (q *Queries) UpdateTunnelActive(ctx context.Context, arg UpdateTunnelActiveParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, updateTunnelActive, arg.ID, arg.IsActive)
+}
+
+const updateTunnelHeartbeat = `-- name: UpdateTunnelHeartbeat :execresult
+UPDATE dino.tunnels
+SET
+    last_seen_at = CURRENT_TIMESTAMP,
+    rtt_micros = $2
+WHERE
+    id = $1
+`
+
+type UpdateTunnelHeartbeatParams struct {
+	ID        uuid.UUID
+	RttMicros pgtype.Int8
+}
+
+// UpdateTunnelHeartbeat record the round trip of the latest answered ping
+func (q *Queries) UpdateTunnelHeartbeat(ctx context.Context, arg UpdateTunnelHeartbeatParams) (pgconn.CommandTag, error) {
+	return q.db.Exec(ctx, updateTunnelHeartbeat, arg.ID, arg.RttMicros)
+}
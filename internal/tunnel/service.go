@@ -2,14 +2,27 @@ package tunnel
 
 import (
 	"context"
-	"crypto/rand"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/auth"
 	"soft.structx.io/dino/database"
 	"soft.structx.io/dino/internal/tunnel/queries"
+	"soft.structx.io/dino/pubsub"
+)
+
+var (
+	// ErrTunnelNotFound no tunnel has the name
+	ErrTunnelNotFound = errors.New("tunnel not found")
+	// ErrInvalidGracePeriod grace period of a rotation is negative
+	ErrInvalidGracePeriod = errors.New("invalid grace period")
+	// ErrMissingSigningKey tunnel created before signing keys, its credentials must be rotated
+	ErrMissingSigningKey = errors.New("tunnel has no signing key")
 )
 
 // TunnelCreate
@@ -23,6 +36,15 @@ type Tunnel struct {
 	Name      string
 	CreatedAt time.Time
 	UpdatedAt *time.Time
+
+	IsActive bool
+	// LastSeenAt time the agent last answered a heartbeat, nil before the first one
+	LastSeenAt *time.Time
+	// RTT round trip of the last answered heartbeat
+	RTT time.Duration
+
+	// PreviousExpiresAt end of the grace period of rotated credentials, nil without one
+	PreviousExpiresAt *time.Time
 }
 
 // TunnelPartial
@@ -40,9 +62,19 @@ type TunnelUpdate struct {
 
 // SecretKey
 type SecretKey struct {
+	// Secret signing key of the tunnel tokens, only stored sealed
 	Secret string
 }
 
+// TokenKeys signing keys tunnel tokens verify with
+type TokenKeys struct {
+	Current []byte
+	// Previous key replaced by a rotation, nil once its grace period ended
+	Previous []byte
+	// PreviousExpiresAt end of the grace period of the previous key
+	PreviousExpiresAt time.Time
+}
+
 // Service
 type Service interface {
 	// Create
@@ -56,22 +88,34 @@ type Service interface {
 	// Delete
 	Delete(context.Context, string) error
 
+	// Rotate replace the secret of the tunnel, the previous secret keeps
+	// verifying for the grace period
+	Rotate(context.Context, string, time.Duration) (Tunnel, SecretKey, error)
+
 	// VerifyToken
-	VerifyToken(context.Context, string) (string, error)
+	VerifyToken(context.Context, string) (TokenKeys, error)
+
+	// SetActive flag whether an agent is connected to the tunnel
+	SetActive(context.Context, string, bool) error
+	// Heartbeat record the round trip of a heartbeat answered by the agent
+	Heartbeat(context.Context, string, time.Duration) error
 
 	// Health
 	Health(context.Context) error
 }
 
 type serviceImpl struct {
-	dbtx database.DBTX
+	log    *teapot.Logger
+	dbtx   database.DBTX
+	br     pubsub.Broker
+	cipher auth.KeyCipher
 }
 
 // interface compliance
 var _ Service = (*serviceImpl)(nil)
 
-func newService(db database.DBTX) Service {
-	return &serviceImpl{dbtx: db}
+func newService(logger *teapot.Logger, db database.DBTX, broker pubsub.Broker, keyCipher auth.KeyCipher) Service {
+	return &serviceImpl{log: logger, dbtx: db, br: broker, cipher: keyCipher}
 }
 
 // Create
@@ -79,27 +123,75 @@ func (s *serviceImpl) Create(ctx context.Context, create TunnelCreate) (Tunnel,
 	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
 	defer cancel()
 
-	// generate random secret
-	secretKey := make([]byte, 11)
-	if _, err := rand.Read(secretKey); err != nil {
-		return Tunnel{}, SecretKey{}, fmt.Errorf("rand.Read: %w", err)
-	}
-	secretStr := hex.EncodeToString(secretKey)
-
-	tokenHash, err := hashToken(secretStr)
+	tunnelID := uuid.New()
+	signingKey, sealed, err := s.newSigningKey(tunnelID)
 	if err != nil {
 		return Tunnel{}, SecretKey{}, err
 	}
 
 	sqlTunnel, err := queries.New(s.dbtx).InsertTunnel(timeout, queries.InsertTunnelParams{
+		ID:         tunnelID,
 		Identifier: create.Name,
-		TokenHash:  tokenHash,
+		SigningKey: sealed,
 	})
 	if err != nil {
 		return Tunnel{}, SecretKey{}, fmt.Errorf("failed to execute insert tunnel query: %w", err)
 	}
 
-	return dtoTunnel(sqlTunnel), SecretKey{Secret: sqlTunnel.TokenHash}, nil
+	return dtoTunnel(sqlTunnel), SecretKey{Secret: string(signingKey)}, nil
+}
+
+// Rotate implements Service.
+func (s *serviceImpl) Rotate(ctx context.Context, tunnelName string, grace time.Duration) (Tunnel, SecretKey, error) {
+	if grace < 0 {
+		return Tunnel{}, SecretKey{}, fmt.Errorf("%w: %s", ErrInvalidGracePeriod, grace)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	// the new key is sealed for the id of the tunnel
+	current, err := queries.New(s.dbtx).SelectTunnel(timeout, tunnelName)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Tunnel{}, SecretKey{}, ErrTunnelNotFound
+	} else if err != nil {
+		return Tunnel{}, SecretKey{}, fmt.Errorf("failed to execute select tunnel query: %w", err)
+	}
+
+	signingKey, sealed, err := s.newSigningKey(current.ID)
+	if err != nil {
+		return Tunnel{}, SecretKey{}, err
+	}
+
+	// without a grace period the previous secret stops verifying right away
+	sqlTunnel, err := queries.New(s.dbtx).RotateTunnelToken(timeout, queries.RotateTunnelTokenParams{
+		GraceMs:    grace.Milliseconds(),
+		SigningKey: sealed,
+		ID:         current.ID,
+	})
+	if errors.Is(err, pgx.ErrNoRows) {
+		return Tunnel{}, SecretKey{}, ErrTunnelNotFound
+	} else if err != nil {
+		return Tunnel{}, SecretKey{}, fmt.Errorf("failed to execute rotate tunnel token query: %w", err)
+	}
+
+	// the grace period ends on the database clock the verifier compares against
+	revokeAt := sqlTunnel.UpdatedAt.Time
+	if sqlTunnel.PreviousTokenExpiresAt.Valid {
+		revokeAt = sqlTunnel.PreviousTokenExpiresAt.Time
+	}
+
+	// connected agents authenticated with the previous secret, the rotation
+	// already happened so a failed publish only delays their disconnect
+	if err := s.br.Publish("dino.tunnels.credentials", &pubsub.CredentialsRotated{
+		TunnelUID: sqlTunnel.ID.String(),
+		KeyID:     KeyID(signingKey),
+		RevokeAt:  revokeAt,
+	}); err != nil {
+		s.log.Error("publish credentials rotated", teapot.String("tunnel", tunnelName), teapot.Error(err))
+	}
+
+	return dtoTunnel(sqlTunnel), SecretKey{Secret: string(signingKey)}, nil
 }
 
 // Delete implements Service.
@@ -175,21 +267,96 @@ func (s *serviceImpl) Update(ctx context.Context, args TunnelUpdate) (Tunnel, er
 }
 
 // VerifyToken
-func (s *serviceImpl) VerifyToken(ctx context.Context, tunnelID string) (string, error) {
+func (s *serviceImpl) VerifyToken(ctx context.Context, tunnelID string) (TokenKeys, error) {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	tunnelUID, err := uuid.Parse(tunnelID)
+	if err != nil {
+		return TokenKeys{}, fmt.Errorf("uuid.Parse: %w", err)
+	}
+
+	row, err := queries.New(s.dbtx).SelectTunnelToken(timeout, tunnelUID)
+	if err != nil {
+		return TokenKeys{}, fmt.Errorf("failed to execute select tunnel with token query: %w", err)
+	}
+
+	return s.openTokenKeys(tunnelUID, row, time.Now())
+}
+
+// openTokenKeys unseal the keys of the row of tunnelID, the previous key is
+// dropped once expired at now
+func (s *serviceImpl) openTokenKeys(tunnelID uuid.UUID, row queries.SelectTunnelTokenRow, now time.Time) (TokenKeys, error) {
+	if row.SigningKey == nil {
+		return TokenKeys{}, ErrMissingSigningKey
+	}
+
+	current, err := s.cipher.Open(row.SigningKey, keyData(tunnelID))
+	if err != nil {
+		return TokenKeys{}, fmt.Errorf("open signing key: %w", err)
+	}
+
+	keys := TokenKeys{Current: current}
+	if row.PreviousSigningKey != nil && row.PreviousTokenExpiresAt.Valid &&
+		now.Before(row.PreviousTokenExpiresAt.Time) {
+		previous, err := s.cipher.Open(row.PreviousSigningKey, keyData(tunnelID))
+		if err != nil {
+			return TokenKeys{}, fmt.Errorf("open previous signing key: %w", err)
+		}
+		keys.Previous = previous
+		keys.PreviousExpiresAt = row.PreviousTokenExpiresAt.Time
+	}
+	return keys, nil
+}
+
+// SetActive
+func (s *serviceImpl) SetActive(ctx context.Context, tunnelID string, active bool) error {
 	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
 	defer cancel()
 
 	tunnelUID, err := uuid.Parse(tunnelID)
 	if err != nil {
-		return "", fmt.Errorf("uuid.Parse: %w", err)
+		return fmt.Errorf("uuid.Parse: %w", err)
 	}
 
-	combinedHash, err := queries.New(s.dbtx).SelectTunnelToken(timeout, tunnelUID)
+	tag, err := queries.New(s.dbtx).UpdateTunnelActive(timeout, queries.UpdateTunnelActiveParams{
+		ID:       tunnelUID,
+		IsActive: active,
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to execute select tunnel with token query: %w", err)
+		return fmt.Errorf("failed to execute update tunnel active query: %w", err)
 	}
 
-	return combinedHash, nil
+	if tag.RowsAffected() < 1 {
+		return fmt.Errorf("tunnel %s not found", tunnelID)
+	}
+
+	return nil
+}
+
+// Heartbeat
+func (s *serviceImpl) Heartbeat(ctx context.Context, tunnelID string, rtt time.Duration) error {
+	timeout, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+
+	tunnelUID, err := uuid.Parse(tunnelID)
+	if err != nil {
+		return fmt.Errorf("uuid.Parse: %w", err)
+	}
+
+	tag, err := queries.New(s.dbtx).UpdateTunnelHeartbeat(timeout, queries.UpdateTunnelHeartbeatParams{
+		ID:        tunnelUID,
+		RttMicros: pgtype.Int8{Int64: rtt.Microseconds(), Valid: true},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute update tunnel heartbeat query: %w", err)
+	}
+
+	if tag.RowsAffected() < 1 {
+		return fmt.Errorf("tunnel %s not found", tunnelID)
+	}
+
+	return nil
 }
 
 // Health
@@ -204,10 +371,25 @@ func dtoTunnel(t queries.DinoTunnel) Tunnel {
 		updatedAt = &t.UpdatedAt.Time
 	}
 
+	var lastSeenAt *time.Time
+	if t.LastSeenAt.Valid {
+		lastSeenAt = &t.LastSeenAt.Time
+	}
+
+	var previousExpiresAt *time.Time
+	if t.PreviousTokenExpiresAt.Valid {
+		previousExpiresAt = &t.PreviousTokenExpiresAt.Time
+	}
+
 	return Tunnel{
-		ID:        t.ID.String(),
-		Name:      t.Identifier,
-		CreatedAt: t.CreatedAt.Time,
-		UpdatedAt: updatedAt,
+		ID:         t.ID.String(),
+		Name:       t.Identifier,
+		CreatedAt:  t.CreatedAt.Time,
+		UpdatedAt:  updatedAt,
+		IsActive:   t.IsActive,
+		LastSeenAt: lastSeenAt,
+		RTT:        time.Duration(t.RttMicros.Int64) * time.Microsecond,
+
+		PreviousExpiresAt: previousExpiresAt,
 	}
 }
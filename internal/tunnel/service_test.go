@@ -1 +1,175 @@
 package tunnel
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/internal/tunnel/queries"
+	"soft.structx.io/dino/pubsub"
+)
+
+// prefixCipher seals keys by prefixing them with their additional data,
+// enough to tell sealed from open keys and which row they belong to
+type prefixCipher struct{}
+
+// interface compliance
+var _ auth.KeyCipher = (*prefixCipher)(nil)
+
+// sealedPrefix prefix of a key sealed for additional data
+func sealedPrefix(additionalData []byte) []byte {
+	return []byte("sealed(" + string(additionalData) + "):")
+}
+
+// Seal implements auth.KeyCipher.
+func (prefixCipher) Seal(key, additionalData []byte) ([]byte, error) {
+	return append(sealedPrefix(additionalData), key...), nil
+}
+
+// Open implements auth.KeyCipher.
+func (prefixCipher) Open(sealed, additionalData []byte) ([]byte, error) {
+	key, ok := bytes.CutPrefix(sealed, sealedPrefix(additionalData))
+	if !ok {
+		return nil, auth.ErrSealedKey
+	}
+	return key, nil
+}
+
+// rotatedTunnel database answering the select and rotate queries with row
+type rotatedTunnel struct {
+	database.DBTX
+
+	row queries.DinoTunnel
+}
+
+// QueryRow implements database.DBTX.
+func (r *rotatedTunnel) QueryRow(context.Context, string, ...interface{}) database.Row {
+	return tunnelRow{row: r.row}
+}
+
+// tunnelRow columns of a dino.tunnels row
+type tunnelRow struct {
+	row queries.DinoTunnel
+}
+
+// Scan implements pgx.Row.
+func (t tunnelRow) Scan(dest ...any) error {
+	*dest[0].(*uuid.UUID) = t.row.ID
+	*dest[1].(*string) = t.row.Identifier
+	*dest[4].(*pgtype.Timestamp) = t.row.UpdatedAt
+	*dest[7].(*pgtype.Timestamp) = t.row.PreviousTokenExpiresAt
+	*dest[8].(*[]byte) = t.row.SigningKey
+	return nil
+}
+
+// failingBroker records published messages and fails every publish
+type failingBroker struct {
+	pubsub.Broker
+
+	published []interface{}
+}
+
+// Publish implements pubsub.Broker.
+func (f *failingBroker) Publish(_ string, msg interface{}) error {
+	f.published = append(f.published, msg)
+	return errors.New("broker unavailable")
+}
+
+type ServiceSuite struct {
+	suite.Suite
+
+	svc *serviceImpl
+}
+
+func (suite *ServiceSuite) SetupTest() {
+	suite.svc = &serviceImpl{cipher: prefixCipher{}}
+}
+
+func (suite *ServiceSuite) TestNewSigningKeyIsSealed() {
+	tunnelID := uuid.New()
+	signingKey, sealed, err := suite.svc.newSigningKey(tunnelID)
+	suite.Require().NoError(err)
+	suite.Len(signingKey, signingKeyLength)
+	suite.Equal(append(sealedPrefix([]byte(tunnelID.String())), signingKey...), sealed)
+}
+
+// sealed key of tunnelID as the cipher stores it
+func sealed(tunnelID uuid.UUID, key string) []byte {
+	return append(sealedPrefix([]byte(tunnelID.String())), key...)
+}
+
+func (suite *ServiceSuite) TestTokenKeysDropExpiredPrevious() {
+	now := time.Now().UTC()
+	tunnelID := uuid.New()
+	row := queries.SelectTunnelTokenRow{
+		SigningKey:             sealed(tunnelID, "current"),
+		PreviousSigningKey:     sealed(tunnelID, "previous"),
+		PreviousTokenExpiresAt: pgtype.Timestamp{Time: now.Add(time.Minute), Valid: true},
+	}
+
+	keys, err := suite.svc.openTokenKeys(tunnelID, row, now)
+	suite.Require().NoError(err)
+	suite.Equal([]byte("current"), keys.Current)
+	suite.Equal([]byte("previous"), keys.Previous)
+
+	keys, err = suite.svc.openTokenKeys(tunnelID, row, now.Add(2*time.Minute))
+	suite.Require().NoError(err)
+	suite.Equal([]byte("current"), keys.Current)
+	suite.Nil(keys.Previous)
+}
+
+func (suite *ServiceSuite) TestTokenKeysMissingSigningKey() {
+	_, err := suite.svc.openTokenKeys(uuid.New(), queries.SelectTunnelTokenRow{}, time.Now())
+	suite.ErrorIs(err, ErrMissingSigningKey)
+}
+
+func (suite *ServiceSuite) TestTokenKeysOfAnotherTunnel() {
+	// a signing key copied from the row of another tunnel does not open
+	row := queries.SelectTunnelTokenRow{SigningKey: sealed(uuid.New(), "current")}
+
+	_, err := suite.svc.openTokenKeys(uuid.New(), row, time.Now())
+	suite.ErrorIs(err, auth.ErrSealedKey)
+}
+
+func (suite *ServiceSuite) TestKeyID() {
+	suite.Equal(KeyID([]byte("current")), KeyID([]byte("current")))
+	suite.NotEqual(KeyID([]byte("current")), KeyID([]byte("previous")))
+}
+
+func (suite *ServiceSuite) TestRotatePublishFailure() {
+	updatedAt := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	tunnelID := uuid.New()
+	db := &rotatedTunnel{row: queries.DinoTunnel{
+		ID:                     tunnelID,
+		Identifier:             "web",
+		UpdatedAt:              pgtype.Timestamp{Time: updatedAt, Valid: true},
+		PreviousTokenExpiresAt: pgtype.Timestamp{Time: updatedAt.Add(time.Hour), Valid: true},
+		SigningKey:             sealed(tunnelID, "current"),
+	}}
+	br := &failingBroker{}
+	svc := newService(teapot.New(teapot.WithWriter(io.Discard)), db, br, prefixCipher{})
+
+	// the secret is already replaced, the rpc still hands it out
+	_, secret, err := svc.Rotate(context.Background(), "web", time.Hour)
+	suite.Require().NoError(err)
+	suite.Len(secret.Secret, signingKeyLength)
+
+	suite.Require().Len(br.published, 1)
+	rotated := br.published[0].(*pubsub.CredentialsRotated)
+	suite.Equal(db.row.ID.String(), rotated.TunnelUID)
+	suite.Equal(KeyID([]byte(secret.Secret)), rotated.KeyID)
+	suite.Equal(updatedAt.Add(time.Hour), rotated.RevokeAt)
+}
+
+func TestServiceSuite(t *testing.T) {
+	suite.Run(t, new(ServiceSuite))
+}
@@ -0,0 +1 @@
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS public_port;
@@ -0,0 +1 @@
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS public_port INTEGER UNIQUE;
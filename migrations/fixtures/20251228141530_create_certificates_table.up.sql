@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS dino.certificates (
+    id UUID PRIMARY KEY DEFAULT extensions.uuid_generate_v4(),
+    route_id UUID UNIQUE NOT NULL,
+    cert_pem TEXT NOT NULL,
+    key_pem TEXT NOT NULL,
+    not_after TIMESTAMP NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP,
+    FOREIGN KEY (route_id) REFERENCES dino.routes (id) ON DELETE CASCADE
+);
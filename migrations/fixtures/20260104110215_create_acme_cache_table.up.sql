@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS dino.acme_cache (
+    cache_key TEXT PRIMARY KEY,
+    cache_data BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP
+);
@@ -0,0 +1,7 @@
+ALTER TABLE dino.routes DROP CONSTRAINT IF EXISTS routes_match_key;
+
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS match_headers;
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS strip_prefix;
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS path_prefix;
+
+ALTER TABLE dino.routes ADD CONSTRAINT routes_hostname_key UNIQUE (hostname);
@@ -0,0 +1,7 @@
+ALTER TABLE dino.routes DROP CONSTRAINT IF EXISTS routes_hostname_key;
+
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS path_prefix VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS strip_prefix BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS match_headers JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE dino.routes ADD CONSTRAINT routes_match_key UNIQUE (hostname, path_prefix, match_headers);
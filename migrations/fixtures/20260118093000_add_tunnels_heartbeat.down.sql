@@ -0,0 +1,2 @@
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS rtt_micros;
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS last_seen_at;
@@ -0,0 +1,2 @@
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS rtt_micros BIGINT;
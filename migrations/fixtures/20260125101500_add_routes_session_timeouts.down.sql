@@ -0,0 +1,2 @@
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS max_lifetime_ms;
+ALTER TABLE dino.routes DROP COLUMN IF EXISTS idle_timeout_ms;
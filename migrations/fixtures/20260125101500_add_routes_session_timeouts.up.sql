@@ -0,0 +1,2 @@
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS idle_timeout_ms BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE dino.routes ADD COLUMN IF NOT EXISTS max_lifetime_ms BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS previous_token_expires_at;
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS previous_token_hash;
@@ -0,0 +1,2 @@
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS previous_token_hash VARCHAR(255);
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS previous_token_expires_at TIMESTAMP;
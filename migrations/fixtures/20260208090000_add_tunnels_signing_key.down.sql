@@ -0,0 +1,4 @@
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS token_hash VARCHAR(255) UNIQUE;
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS previous_token_hash VARCHAR(255);
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS previous_signing_key;
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS signing_key;
@@ -0,0 +1,4 @@
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS signing_key BYTEA;
+ALTER TABLE dino.tunnels ADD COLUMN IF NOT EXISTS previous_signing_key BYTEA;
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS previous_token_hash;
+ALTER TABLE dino.tunnels DROP COLUMN IF EXISTS token_hash;
@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS dino.api_keys (
+    id UUID PRIMARY KEY DEFAULT extensions.uuid_generate_v4(),
+    name VARCHAR(255) UNIQUE NOT NULL,
+    -- sha256 of the key, keys are random and never stored in clear
+    key_hash BYTEA UNIQUE NOT NULL,
+    role VARCHAR(32) NOT NULL,
+    -- tunnels the key is limited to, empty grants every tunnel
+    tunnels VARCHAR(255)[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
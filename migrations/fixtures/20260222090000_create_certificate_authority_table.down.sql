@@ -0,0 +1 @@
+DROP TABLE IF EXISTS dino.certificate_authority;
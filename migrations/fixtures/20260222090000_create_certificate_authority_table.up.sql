@@ -0,0 +1,8 @@
+CREATE TABLE IF NOT EXISTS dino.certificate_authority (
+    -- single row, every server replica signs with the same ca
+    id SMALLINT PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    cert_pem BYTEA NOT NULL,
+    -- pkcs8 private key sealed with the master key
+    sealed_key BYTEA NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,535 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.2
+// source: pb/apikeys/v1/apikey_service.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type APIKey struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Uid   string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Name  string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	// role admin, operator or read-only
+	Role string `protobuf:"bytes,3,opt,name=role,proto3" json:"role,omitempty"`
+	// tunnels the key is limited to, empty grants every tunnel
+	Tunnels       []string               `protobuf:"bytes,4,rep,name=tunnels,proto3" json:"tunnels,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *APIKey) Reset() {
+	*x = APIKey{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *APIKey) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*APIKey) ProtoMessage() {}
+
+func (x *APIKey) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use APIKey.ProtoReflect.Descriptor instead.
+func (*APIKey) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *APIKey) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *APIKey) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *APIKey) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *APIKey) GetTunnels() []string {
+	if x != nil {
+		return x.Tunnels
+	}
+	return nil
+}
+
+func (x *APIKey) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type APIKeyCreate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Role          string                 `protobuf:"bytes,2,opt,name=role,proto3" json:"role,omitempty"`
+	Tunnels       []string               `protobuf:"bytes,3,rep,name=tunnels,proto3" json:"tunnels,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *APIKeyCreate) Reset() {
+	*x = APIKeyCreate{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *APIKeyCreate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*APIKeyCreate) ProtoMessage() {}
+
+func (x *APIKeyCreate) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use APIKeyCreate.ProtoReflect.Descriptor instead.
+func (*APIKeyCreate) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *APIKeyCreate) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *APIKeyCreate) GetRole() string {
+	if x != nil {
+		return x.Role
+	}
+	return ""
+}
+
+func (x *APIKeyCreate) GetTunnels() []string {
+	if x != nil {
+		return x.Tunnels
+	}
+	return nil
+}
+
+type CreateAPIKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Create        *APIKeyCreate          `protobuf:"bytes,1,opt,name=create,proto3" json:"create,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAPIKeyRequest) Reset() {
+	*x = CreateAPIKeyRequest{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAPIKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAPIKeyRequest) ProtoMessage() {}
+
+func (x *CreateAPIKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAPIKeyRequest.ProtoReflect.Descriptor instead.
+func (*CreateAPIKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *CreateAPIKeyRequest) GetCreate() *APIKeyCreate {
+	if x != nil {
+		return x.Create
+	}
+	return nil
+}
+
+type CreateAPIKeyResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	ApiKey *APIKey                `protobuf:"bytes,1,opt,name=api_key,json=apiKey,proto3" json:"api_key,omitempty"`
+	// key only returned once, the server stores its hash
+	Key           string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateAPIKeyResponse) Reset() {
+	*x = CreateAPIKeyResponse{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateAPIKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateAPIKeyResponse) ProtoMessage() {}
+
+func (x *CreateAPIKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateAPIKeyResponse.ProtoReflect.Descriptor instead.
+func (*CreateAPIKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *CreateAPIKeyResponse) GetApiKey() *APIKey {
+	if x != nil {
+		return x.ApiKey
+	}
+	return nil
+}
+
+func (x *CreateAPIKeyResponse) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+type ListAPIKeysRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Limit         int32                  `protobuf:"varint,1,opt,name=limit,proto3" json:"limit,omitempty"`
+	Offset        int32                  `protobuf:"varint,2,opt,name=offset,proto3" json:"offset,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAPIKeysRequest) Reset() {
+	*x = ListAPIKeysRequest{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAPIKeysRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAPIKeysRequest) ProtoMessage() {}
+
+func (x *ListAPIKeysRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAPIKeysRequest.ProtoReflect.Descriptor instead.
+func (*ListAPIKeysRequest) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListAPIKeysRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+func (x *ListAPIKeysRequest) GetOffset() int32 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+type ListAPIKeysResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ApiKeys       []*APIKey              `protobuf:"bytes,1,rep,name=api_keys,json=apiKeys,proto3" json:"api_keys,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListAPIKeysResponse) Reset() {
+	*x = ListAPIKeysResponse{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListAPIKeysResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListAPIKeysResponse) ProtoMessage() {}
+
+func (x *ListAPIKeysResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListAPIKeysResponse.ProtoReflect.Descriptor instead.
+func (*ListAPIKeysResponse) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListAPIKeysResponse) GetApiKeys() []*APIKey {
+	if x != nil {
+		return x.ApiKeys
+	}
+	return nil
+}
+
+type DeleteAPIKeyRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAPIKeyRequest) Reset() {
+	*x = DeleteAPIKeyRequest{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAPIKeyRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAPIKeyRequest) ProtoMessage() {}
+
+func (x *DeleteAPIKeyRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAPIKeyRequest.ProtoReflect.Descriptor instead.
+func (*DeleteAPIKeyRequest) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *DeleteAPIKeyRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type DeleteAPIKeyResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteAPIKeyResponse) Reset() {
+	*x = DeleteAPIKeyResponse{}
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteAPIKeyResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteAPIKeyResponse) ProtoMessage() {}
+
+func (x *DeleteAPIKeyResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_apikeys_v1_apikey_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteAPIKeyResponse.ProtoReflect.Descriptor instead.
+func (*DeleteAPIKeyResponse) Descriptor() ([]byte, []int) {
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP(), []int{7}
+}
+
+var File_pb_apikeys_v1_apikey_service_proto protoreflect.FileDescriptor
+
+const file_pb_apikeys_v1_apikey_service_proto_rawDesc = "" +
+	"\n" +
+	"\"pb/apikeys/v1/apikey_service.proto\x12\n" +
+	"apikeys.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x97\x01\n" +
+	"\x06APIKey\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04role\x18\x03 \x01(\tR\x04role\x12\x18\n" +
+	"\atunnels\x18\x04 \x03(\tR\atunnels\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"P\n" +
+	"\fAPIKeyCreate\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04role\x18\x02 \x01(\tR\x04role\x12\x18\n" +
+	"\atunnels\x18\x03 \x03(\tR\atunnels\"G\n" +
+	"\x13CreateAPIKeyRequest\x120\n" +
+	"\x06create\x18\x01 \x01(\v2\x18.apikeys.v1.APIKeyCreateR\x06create\"U\n" +
+	"\x14CreateAPIKeyResponse\x12+\n" +
+	"\aapi_key\x18\x01 \x01(\v2\x12.apikeys.v1.APIKeyR\x06apiKey\x12\x10\n" +
+	"\x03key\x18\x02 \x01(\tR\x03key\"B\n" +
+	"\x12ListAPIKeysRequest\x12\x14\n" +
+	"\x05limit\x18\x01 \x01(\x05R\x05limit\x12\x16\n" +
+	"\x06offset\x18\x02 \x01(\x05R\x06offset\"D\n" +
+	"\x13ListAPIKeysResponse\x12-\n" +
+	"\bapi_keys\x18\x01 \x03(\v2\x12.apikeys.v1.APIKeyR\aapiKeys\")\n" +
+	"\x13DeleteAPIKeyRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"\x16\n" +
+	"\x14DeleteAPIKeyResponse2\x8b\x02\n" +
+	"\rAPIKeyService\x12S\n" +
+	"\fCreateAPIKey\x12\x1f.apikeys.v1.CreateAPIKeyRequest\x1a .apikeys.v1.CreateAPIKeyResponse\"\x00\x12P\n" +
+	"\vListAPIKeys\x12\x1e.apikeys.v1.ListAPIKeysRequest\x1a\x1f.apikeys.v1.ListAPIKeysResponse\"\x00\x12S\n" +
+	"\fDeleteAPIKey\x12\x1f.apikeys.v1.DeleteAPIKeyRequest\x1a .apikeys.v1.DeleteAPIKeyResponse\"\x00B(Z&soft.structx.io/dino/protos/apikeys/v1b\x06proto3"
+
+var (
+	file_pb_apikeys_v1_apikey_service_proto_rawDescOnce sync.Once
+	file_pb_apikeys_v1_apikey_service_proto_rawDescData []byte
+)
+
+func file_pb_apikeys_v1_apikey_service_proto_rawDescGZIP() []byte {
+	file_pb_apikeys_v1_apikey_service_proto_rawDescOnce.Do(func() {
+		file_pb_apikeys_v1_apikey_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_apikeys_v1_apikey_service_proto_rawDesc), len(file_pb_apikeys_v1_apikey_service_proto_rawDesc)))
+	})
+	return file_pb_apikeys_v1_apikey_service_proto_rawDescData
+}
+
+var file_pb_apikeys_v1_apikey_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_pb_apikeys_v1_apikey_service_proto_goTypes = []any{
+	(*APIKey)(nil),                // 0: apikeys.v1.APIKey
+	(*APIKeyCreate)(nil),          // 1: apikeys.v1.APIKeyCreate
+	(*CreateAPIKeyRequest)(nil),   // 2: apikeys.v1.CreateAPIKeyRequest
+	(*CreateAPIKeyResponse)(nil),  // 3: apikeys.v1.CreateAPIKeyResponse
+	(*ListAPIKeysRequest)(nil),    // 4: apikeys.v1.ListAPIKeysRequest
+	(*ListAPIKeysResponse)(nil),   // 5: apikeys.v1.ListAPIKeysResponse
+	(*DeleteAPIKeyRequest)(nil),   // 6: apikeys.v1.DeleteAPIKeyRequest
+	(*DeleteAPIKeyResponse)(nil),  // 7: apikeys.v1.DeleteAPIKeyResponse
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_pb_apikeys_v1_apikey_service_proto_depIdxs = []int32{
+	8, // 0: apikeys.v1.APIKey.created_at:type_name -> google.protobuf.Timestamp
+	1, // 1: apikeys.v1.CreateAPIKeyRequest.create:type_name -> apikeys.v1.APIKeyCreate
+	0, // 2: apikeys.v1.CreateAPIKeyResponse.api_key:type_name -> apikeys.v1.APIKey
+	0, // 3: apikeys.v1.ListAPIKeysResponse.api_keys:type_name -> apikeys.v1.APIKey
+	2, // 4: apikeys.v1.APIKeyService.CreateAPIKey:input_type -> apikeys.v1.CreateAPIKeyRequest
+	4, // 5: apikeys.v1.APIKeyService.ListAPIKeys:input_type -> apikeys.v1.ListAPIKeysRequest
+	6, // 6: apikeys.v1.APIKeyService.DeleteAPIKey:input_type -> apikeys.v1.DeleteAPIKeyRequest
+	3, // 7: apikeys.v1.APIKeyService.CreateAPIKey:output_type -> apikeys.v1.CreateAPIKeyResponse
+	5, // 8: apikeys.v1.APIKeyService.ListAPIKeys:output_type -> apikeys.v1.ListAPIKeysResponse
+	7, // 9: apikeys.v1.APIKeyService.DeleteAPIKey:output_type -> apikeys.v1.DeleteAPIKeyResponse
+	7, // [7:10] is the sub-list for method output_type
+	4, // [4:7] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_pb_apikeys_v1_apikey_service_proto_init() }
+func file_pb_apikeys_v1_apikey_service_proto_init() {
+	if File_pb_apikeys_v1_apikey_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_apikeys_v1_apikey_service_proto_rawDesc), len(file_pb_apikeys_v1_apikey_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_apikeys_v1_apikey_service_proto_goTypes,
+		DependencyIndexes: file_pb_apikeys_v1_apikey_service_proto_depIdxs,
+		MessageInfos:      file_pb_apikeys_v1_apikey_service_proto_msgTypes,
+	}.Build()
+	File_pb_apikeys_v1_apikey_service_proto = out.File
+	file_pb_apikeys_v1_apikey_service_proto_goTypes = nil
+	file_pb_apikeys_v1_apikey_service_proto_depIdxs = nil
+}
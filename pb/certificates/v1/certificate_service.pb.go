@@ -0,0 +1,418 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v6.33.2
+// source: pb/certificates/v1/certificate_service.proto
+
+package v1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Certificate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
+	Hostname      string                 `protobuf:"bytes,2,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	NotAfter      *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=not_after,json=notAfter,proto3" json:"not_after,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Certificate) Reset() {
+	*x = Certificate{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Certificate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Certificate) ProtoMessage() {}
+
+func (x *Certificate) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Certificate.ProtoReflect.Descriptor instead.
+func (*Certificate) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Certificate) GetUid() string {
+	if x != nil {
+		return x.Uid
+	}
+	return ""
+}
+
+func (x *Certificate) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *Certificate) GetNotAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.NotAfter
+	}
+	return nil
+}
+
+func (x *Certificate) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Certificate) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type CertificatePut struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	CertPem       []byte                 `protobuf:"bytes,2,opt,name=cert_pem,json=certPem,proto3" json:"cert_pem,omitempty"`
+	KeyPem        []byte                 `protobuf:"bytes,3,opt,name=key_pem,json=keyPem,proto3" json:"key_pem,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CertificatePut) Reset() {
+	*x = CertificatePut{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CertificatePut) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CertificatePut) ProtoMessage() {}
+
+func (x *CertificatePut) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CertificatePut.ProtoReflect.Descriptor instead.
+func (*CertificatePut) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *CertificatePut) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+func (x *CertificatePut) GetCertPem() []byte {
+	if x != nil {
+		return x.CertPem
+	}
+	return nil
+}
+
+func (x *CertificatePut) GetKeyPem() []byte {
+	if x != nil {
+		return x.KeyPem
+	}
+	return nil
+}
+
+type PutCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Put           *CertificatePut        `protobuf:"bytes,1,opt,name=put,proto3" json:"put,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutCertificateRequest) Reset() {
+	*x = PutCertificateRequest{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutCertificateRequest) ProtoMessage() {}
+
+func (x *PutCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutCertificateRequest.ProtoReflect.Descriptor instead.
+func (*PutCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *PutCertificateRequest) GetPut() *CertificatePut {
+	if x != nil {
+		return x.Put
+	}
+	return nil
+}
+
+type PutCertificateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Certificate   *Certificate           `protobuf:"bytes,1,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PutCertificateResponse) Reset() {
+	*x = PutCertificateResponse{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PutCertificateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PutCertificateResponse) ProtoMessage() {}
+
+func (x *PutCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PutCertificateResponse.ProtoReflect.Descriptor instead.
+func (*PutCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PutCertificateResponse) GetCertificate() *Certificate {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+type DeleteCertificateRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hostname      string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCertificateRequest) Reset() {
+	*x = DeleteCertificateRequest{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCertificateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCertificateRequest) ProtoMessage() {}
+
+func (x *DeleteCertificateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCertificateRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCertificateRequest) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *DeleteCertificateRequest) GetHostname() string {
+	if x != nil {
+		return x.Hostname
+	}
+	return ""
+}
+
+type DeleteCertificateResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCertificateResponse) Reset() {
+	*x = DeleteCertificateResponse{}
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCertificateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCertificateResponse) ProtoMessage() {}
+
+func (x *DeleteCertificateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_certificates_v1_certificate_service_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCertificateResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCertificateResponse) Descriptor() ([]byte, []int) {
+	return file_pb_certificates_v1_certificate_service_proto_rawDescGZIP(), []int{5}
+}
+
+var File_pb_certificates_v1_certificate_service_proto protoreflect.FileDescriptor
+
+const file_pb_certificates_v1_certificate_service_proto_rawDesc = "" +
+	"\n" +
+	",pb/certificates/v1/certificate_service.proto\x12\x0fcertificates.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xea\x01\n" +
+	"\vCertificate\x12\x10\n" +
+	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x1a\n" +
+	"\bhostname\x18\x02 \x01(\tR\bhostname\x127\n" +
+	"\tnot_after\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\bnotAfter\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"`\n" +
+	"\x0eCertificatePut\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\x12\x19\n" +
+	"\bcert_pem\x18\x02 \x01(\fR\acertPem\x12\x17\n" +
+	"\akey_pem\x18\x03 \x01(\fR\x06keyPem\"J\n" +
+	"\x15PutCertificateRequest\x121\n" +
+	"\x03put\x18\x01 \x01(\v2\x1f.certificates.v1.CertificatePutR\x03put\"X\n" +
+	"\x16PutCertificateResponse\x12>\n" +
+	"\vcertificate\x18\x01 \x01(\v2\x1c.certificates.v1.CertificateR\vcertificate\"6\n" +
+	"\x18DeleteCertificateRequest\x12\x1a\n" +
+	"\bhostname\x18\x01 \x01(\tR\bhostname\"\x1b\n" +
+	"\x19DeleteCertificateResponse2\xe7\x01\n" +
+	"\x12CertificateService\x12c\n" +
+	"\x0ePutCertificate\x12&.certificates.v1.PutCertificateRequest\x1a'.certificates.v1.PutCertificateResponse\"\x00\x12l\n" +
+	"\x11DeleteCertificate\x12).certificates.v1.DeleteCertificateRequest\x1a*.certificates.v1.DeleteCertificateResponse\"\x00B-Z+soft.structx.io/dino/protos/certificates/v1b\x06proto3"
+
+var (
+	file_pb_certificates_v1_certificate_service_proto_rawDescOnce sync.Once
+	file_pb_certificates_v1_certificate_service_proto_rawDescData []byte
+)
+
+func file_pb_certificates_v1_certificate_service_proto_rawDescGZIP() []byte {
+	file_pb_certificates_v1_certificate_service_proto_rawDescOnce.Do(func() {
+		file_pb_certificates_v1_certificate_service_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_pb_certificates_v1_certificate_service_proto_rawDesc), len(file_pb_certificates_v1_certificate_service_proto_rawDesc)))
+	})
+	return file_pb_certificates_v1_certificate_service_proto_rawDescData
+}
+
+var file_pb_certificates_v1_certificate_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_pb_certificates_v1_certificate_service_proto_goTypes = []any{
+	(*Certificate)(nil),               // 0: certificates.v1.Certificate
+	(*CertificatePut)(nil),            // 1: certificates.v1.CertificatePut
+	(*PutCertificateRequest)(nil),     // 2: certificates.v1.PutCertificateRequest
+	(*PutCertificateResponse)(nil),    // 3: certificates.v1.PutCertificateResponse
+	(*DeleteCertificateRequest)(nil),  // 4: certificates.v1.DeleteCertificateRequest
+	(*DeleteCertificateResponse)(nil), // 5: certificates.v1.DeleteCertificateResponse
+	(*timestamppb.Timestamp)(nil),     // 6: google.protobuf.Timestamp
+}
+var file_pb_certificates_v1_certificate_service_proto_depIdxs = []int32{
+	6, // 0: certificates.v1.Certificate.not_after:type_name -> google.protobuf.Timestamp
+	6, // 1: certificates.v1.Certificate.created_at:type_name -> google.protobuf.Timestamp
+	6, // 2: certificates.v1.Certificate.updated_at:type_name -> google.protobuf.Timestamp
+	1, // 3: certificates.v1.PutCertificateRequest.put:type_name -> certificates.v1.CertificatePut
+	0, // 4: certificates.v1.PutCertificateResponse.certificate:type_name -> certificates.v1.Certificate
+	2, // 5: certificates.v1.CertificateService.PutCertificate:input_type -> certificates.v1.PutCertificateRequest
+	4, // 6: certificates.v1.CertificateService.DeleteCertificate:input_type -> certificates.v1.DeleteCertificateRequest
+	3, // 7: certificates.v1.CertificateService.PutCertificate:output_type -> certificates.v1.PutCertificateResponse
+	5, // 8: certificates.v1.CertificateService.DeleteCertificate:output_type -> certificates.v1.DeleteCertificateResponse
+	7, // [7:9] is the sub-list for method output_type
+	5, // [5:7] is the sub-list for method input_type
+	5, // [5:5] is the sub-list for extension type_name
+	5, // [5:5] is the sub-list for extension extendee
+	0, // [0:5] is the sub-list for field type_name
+}
+
+func init() { file_pb_certificates_v1_certificate_service_proto_init() }
+func file_pb_certificates_v1_certificate_service_proto_init() {
+	if File_pb_certificates_v1_certificate_service_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_certificates_v1_certificate_service_proto_rawDesc), len(file_pb_certificates_v1_certificate_service_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_pb_certificates_v1_certificate_service_proto_goTypes,
+		DependencyIndexes: file_pb_certificates_v1_certificate_service_proto_depIdxs,
+		MessageInfos:      file_pb_certificates_v1_certificate_service_proto_msgTypes,
+	}.Build()
+	File_pb_certificates_v1_certificate_service_proto = out.File
+	file_pb_certificates_v1_certificate_service_proto_goTypes = nil
+	file_pb_certificates_v1_certificate_service_proto_depIdxs = nil
+}
@@ -9,6 +9,7 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
 	sync "sync"
@@ -29,6 +30,12 @@ type RouteCreate struct {
 	DestProtocol  string                 `protobuf:"bytes,3,opt,name=dest_protocol,json=destProtocol,proto3" json:"dest_protocol,omitempty"`
 	DestAddr      string                 `protobuf:"bytes,4,opt,name=dest_addr,json=destAddr,proto3" json:"dest_addr,omitempty"`
 	DestPort      uint32                 `protobuf:"varint,5,opt,name=dest_port,json=destPort,proto3" json:"dest_port,omitempty"`
+	PublicPort    uint32                 `protobuf:"varint,6,opt,name=public_port,json=publicPort,proto3" json:"public_port,omitempty"`
+	PathPrefix    string                 `protobuf:"bytes,7,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	StripPrefix   bool                   `protobuf:"varint,8,opt,name=strip_prefix,json=stripPrefix,proto3" json:"strip_prefix,omitempty"`
+	MatchHeaders  map[string]string      `protobuf:"bytes,9,rep,name=match_headers,json=matchHeaders,proto3" json:"match_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IdleTimeout   *durationpb.Duration   `protobuf:"bytes,10,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	MaxLifetime   *durationpb.Duration   `protobuf:"bytes,11,opt,name=max_lifetime,json=maxLifetime,proto3" json:"max_lifetime,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -98,6 +105,48 @@ func (x *RouteCreate) GetDestPort() uint32 {
 	return 0
 }
 
+func (x *RouteCreate) GetPublicPort() uint32 {
+	if x != nil {
+		return x.PublicPort
+	}
+	return 0
+}
+
+func (x *RouteCreate) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *RouteCreate) GetStripPrefix() bool {
+	if x != nil {
+		return x.StripPrefix
+	}
+	return false
+}
+
+func (x *RouteCreate) GetMatchHeaders() map[string]string {
+	if x != nil {
+		return x.MatchHeaders
+	}
+	return nil
+}
+
+func (x *RouteCreate) GetIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return nil
+}
+
+func (x *RouteCreate) GetMaxLifetime() *durationpb.Duration {
+	if x != nil {
+		return x.MaxLifetime
+	}
+	return nil
+}
+
 type Route struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
@@ -106,6 +155,12 @@ type Route struct {
 	Tunnel        string                 `protobuf:"bytes,4,opt,name=tunnel,proto3" json:"tunnel,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	PublicPort    uint32                 `protobuf:"varint,7,opt,name=public_port,json=publicPort,proto3" json:"public_port,omitempty"`
+	PathPrefix    string                 `protobuf:"bytes,8,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	StripPrefix   bool                   `protobuf:"varint,9,opt,name=strip_prefix,json=stripPrefix,proto3" json:"strip_prefix,omitempty"`
+	MatchHeaders  map[string]string      `protobuf:"bytes,10,rep,name=match_headers,json=matchHeaders,proto3" json:"match_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IdleTimeout   *durationpb.Duration   `protobuf:"bytes,11,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	MaxLifetime   *durationpb.Duration   `protobuf:"bytes,12,opt,name=max_lifetime,json=maxLifetime,proto3" json:"max_lifetime,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -182,6 +237,48 @@ func (x *Route) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Route) GetPublicPort() uint32 {
+	if x != nil {
+		return x.PublicPort
+	}
+	return 0
+}
+
+func (x *Route) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *Route) GetStripPrefix() bool {
+	if x != nil {
+		return x.StripPrefix
+	}
+	return false
+}
+
+func (x *Route) GetMatchHeaders() map[string]string {
+	if x != nil {
+		return x.MatchHeaders
+	}
+	return nil
+}
+
+func (x *Route) GetIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return nil
+}
+
+func (x *Route) GetMaxLifetime() *durationpb.Duration {
+	if x != nil {
+		return x.MaxLifetime
+	}
+	return nil
+}
+
 type RoutePartial struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Uid           string                 `protobuf:"bytes,1,opt,name=uid,proto3" json:"uid,omitempty"`
@@ -242,6 +339,12 @@ type RouteUpdate struct {
 	DestAddr      string                 `protobuf:"bytes,4,opt,name=dest_addr,json=destAddr,proto3" json:"dest_addr,omitempty"`
 	DestPort      uint32                 `protobuf:"varint,5,opt,name=dest_port,json=destPort,proto3" json:"dest_port,omitempty"`
 	Enabled       bool                   `protobuf:"varint,6,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	PublicPort    uint32                 `protobuf:"varint,7,opt,name=public_port,json=publicPort,proto3" json:"public_port,omitempty"`
+	PathPrefix    string                 `protobuf:"bytes,8,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	StripPrefix   bool                   `protobuf:"varint,9,opt,name=strip_prefix,json=stripPrefix,proto3" json:"strip_prefix,omitempty"`
+	MatchHeaders  map[string]string      `protobuf:"bytes,10,rep,name=match_headers,json=matchHeaders,proto3" json:"match_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	IdleTimeout   *durationpb.Duration   `protobuf:"bytes,11,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	MaxLifetime   *durationpb.Duration   `protobuf:"bytes,12,opt,name=max_lifetime,json=maxLifetime,proto3" json:"max_lifetime,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -318,6 +421,48 @@ func (x *RouteUpdate) GetEnabled() bool {
 	return false
 }
 
+func (x *RouteUpdate) GetPublicPort() uint32 {
+	if x != nil {
+		return x.PublicPort
+	}
+	return 0
+}
+
+func (x *RouteUpdate) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *RouteUpdate) GetStripPrefix() bool {
+	if x != nil {
+		return x.StripPrefix
+	}
+	return false
+}
+
+func (x *RouteUpdate) GetMatchHeaders() map[string]string {
+	if x != nil {
+		return x.MatchHeaders
+	}
+	return nil
+}
+
+func (x *RouteUpdate) GetIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return nil
+}
+
+func (x *RouteUpdate) GetMaxLifetime() *durationpb.Duration {
+	if x != nil {
+		return x.MaxLifetime
+	}
+	return nil
+}
+
 type CreateRouteRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Create        *RouteCreate           `protobuf:"bytes,1,opt,name=create,proto3" json:"create,omitempty"`
@@ -770,13 +915,25 @@ var File_pb_routes_v1_route_service_proto protoreflect.FileDescriptor
 
 const file_pb_routes_v1_route_service_proto_rawDesc = "" +
 	"\n" +
-	" pb/routes/v1/route_service.proto\x12\troutes.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa0\x01\n" +
+	" pb/routes/v1/route_service.proto\x12\troutes.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x91\x04\n" +
 	"\vRouteCreate\x12\x16\n" +
 	"\x06tunnel\x18\x01 \x01(\tR\x06tunnel\x12\x1a\n" +
 	"\bhostname\x18\x02 \x01(\tR\bhostname\x12#\n" +
 	"\rdest_protocol\x18\x03 \x01(\tR\fdestProtocol\x12\x1b\n" +
 	"\tdest_addr\x18\x04 \x01(\tR\bdestAddr\x12\x1b\n" +
-	"\tdest_port\x18\x05 \x01(\rR\bdestPort\"\xd5\x01\n" +
+	"\tdest_port\x18\x05 \x01(\rR\bdestPort\x12\x1f\n" +
+	"\vpublic_port\x18\x06 \x01(\rR\n" +
+	"publicPort\x12\x1f\n" +
+	"\vpath_prefix\x18\a \x01(\tR\n" +
+	"pathPrefix\x12!\n" +
+	"\fstrip_prefix\x18\b \x01(\bR\vstripPrefix\x12M\n" +
+	"\rmatch_headers\x18\t \x03(\v2(.routes.v1.RouteCreate.MatchHeadersEntryR\fmatchHeaders\x12<\n" +
+	"\fidle_timeout\x18\n" +
+	" \x01(\v2\x19.google.protobuf.DurationR\vidleTimeout\x12<\n" +
+	"\fmax_lifetime\x18\v \x01(\v2\x19.google.protobuf.DurationR\vmaxLifetime\x1a?\n" +
+	"\x11MatchHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc0\x04\n" +
 	"\x05Route\x12\x10\n" +
 	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
@@ -785,17 +942,41 @@ const file_pb_routes_v1_route_service_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"<\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1f\n" +
+	"\vpublic_port\x18\a \x01(\rR\n" +
+	"publicPort\x12\x1f\n" +
+	"\vpath_prefix\x18\b \x01(\tR\n" +
+	"pathPrefix\x12!\n" +
+	"\fstrip_prefix\x18\t \x01(\bR\vstripPrefix\x12G\n" +
+	"\rmatch_headers\x18\n" +
+	" \x03(\v2\".routes.v1.Route.MatchHeadersEntryR\fmatchHeaders\x12<\n" +
+	"\fidle_timeout\x18\v \x01(\v2\x19.google.protobuf.DurationR\vidleTimeout\x12<\n" +
+	"\fmax_lifetime\x18\f \x01(\v2\x19.google.protobuf.DurationR\vmaxLifetime\x1a?\n" +
+	"\x11MatchHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"<\n" +
 	"\fRoutePartial\x12\x10\n" +
 	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x1a\n" +
-	"\bhostname\x18\x02 \x01(\tR\bhostname\"\xb4\x01\n" +
+	"\bhostname\x18\x02 \x01(\tR\bhostname\"\xa5\x04\n" +
 	"\vRouteUpdate\x12\x10\n" +
 	"\x03uid\x18\x01 \x01(\tR\x03uid\x12\x1a\n" +
 	"\bhostname\x18\x02 \x01(\tR\bhostname\x12#\n" +
 	"\rdest_protocol\x18\x03 \x01(\tR\fdestProtocol\x12\x1b\n" +
 	"\tdest_addr\x18\x04 \x01(\tR\bdestAddr\x12\x1b\n" +
 	"\tdest_port\x18\x05 \x01(\rR\bdestPort\x12\x18\n" +
-	"\aenabled\x18\x06 \x01(\bR\aenabled\"D\n" +
+	"\aenabled\x18\x06 \x01(\bR\aenabled\x12\x1f\n" +
+	"\vpublic_port\x18\a \x01(\rR\n" +
+	"publicPort\x12\x1f\n" +
+	"\vpath_prefix\x18\b \x01(\tR\n" +
+	"pathPrefix\x12!\n" +
+	"\fstrip_prefix\x18\t \x01(\bR\vstripPrefix\x12M\n" +
+	"\rmatch_headers\x18\n" +
+	" \x03(\v2(.routes.v1.RouteUpdate.MatchHeadersEntryR\fmatchHeaders\x12<\n" +
+	"\fidle_timeout\x18\v \x01(\v2\x19.google.protobuf.DurationR\vidleTimeout\x12<\n" +
+	"\fmax_lifetime\x18\f \x01(\v2\x19.google.protobuf.DurationR\vmaxLifetime\x1a?\n" +
+	"\x11MatchHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"D\n" +
 	"\x12CreateRouteRequest\x12.\n" +
 	"\x06create\x18\x01 \x01(\v2\x16.routes.v1.RouteCreateR\x06create\"=\n" +
 	"\x13CreateRouteResponse\x12&\n" +
@@ -837,7 +1018,7 @@ func file_pb_routes_v1_route_service_proto_rawDescGZIP() []byte {
 	return file_pb_routes_v1_route_service_proto_rawDescData
 }
 
-var file_pb_routes_v1_route_service_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_pb_routes_v1_route_service_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_pb_routes_v1_route_service_proto_goTypes = []any{
 	(*RouteCreate)(nil),           // 0: routes.v1.RouteCreate
 	(*Route)(nil),                 // 1: routes.v1.Route
@@ -853,32 +1034,45 @@ var file_pb_routes_v1_route_service_proto_goTypes = []any{
 	(*UpdateRouteResponse)(nil),   // 11: routes.v1.UpdateRouteResponse
 	(*DeleteRouteRequest)(nil),    // 12: routes.v1.DeleteRouteRequest
 	(*DeleteRouteResponse)(nil),   // 13: routes.v1.DeleteRouteResponse
-	(*timestamppb.Timestamp)(nil), // 14: google.protobuf.Timestamp
+	nil,                           // 14: routes.v1.RouteCreate.MatchHeadersEntry
+	nil,                           // 15: routes.v1.Route.MatchHeadersEntry
+	nil,                           // 16: routes.v1.RouteUpdate.MatchHeadersEntry
+	(*durationpb.Duration)(nil),   // 17: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil), // 18: google.protobuf.Timestamp
 }
 var file_pb_routes_v1_route_service_proto_depIdxs = []int32{
-	14, // 0: routes.v1.Route.created_at:type_name -> google.protobuf.Timestamp
-	14, // 1: routes.v1.Route.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 2: routes.v1.CreateRouteRequest.create:type_name -> routes.v1.RouteCreate
-	1,  // 3: routes.v1.CreateRouteResponse.route:type_name -> routes.v1.Route
-	1,  // 4: routes.v1.GetRouteResponse.route:type_name -> routes.v1.Route
-	2,  // 5: routes.v1.ListRoutesResponse.partials:type_name -> routes.v1.RoutePartial
-	3,  // 6: routes.v1.UpdateRouteRequest.update:type_name -> routes.v1.RouteUpdate
-	1,  // 7: routes.v1.UpdateRouteResponse.route:type_name -> routes.v1.Route
-	4,  // 8: routes.v1.RouteService.CreateRoute:input_type -> routes.v1.CreateRouteRequest
-	6,  // 9: routes.v1.RouteService.GetRoute:input_type -> routes.v1.GetRouteRequest
-	8,  // 10: routes.v1.RouteService.ListRoutes:input_type -> routes.v1.ListRoutesRequest
-	10, // 11: routes.v1.RouteService.UpdateRoute:input_type -> routes.v1.UpdateRouteRequest
-	12, // 12: routes.v1.RouteService.DeleteRoute:input_type -> routes.v1.DeleteRouteRequest
-	5,  // 13: routes.v1.RouteService.CreateRoute:output_type -> routes.v1.CreateRouteResponse
-	7,  // 14: routes.v1.RouteService.GetRoute:output_type -> routes.v1.GetRouteResponse
-	9,  // 15: routes.v1.RouteService.ListRoutes:output_type -> routes.v1.ListRoutesResponse
-	11, // 16: routes.v1.RouteService.UpdateRoute:output_type -> routes.v1.UpdateRouteResponse
-	13, // 17: routes.v1.RouteService.DeleteRoute:output_type -> routes.v1.DeleteRouteResponse
-	13, // [13:18] is the sub-list for method output_type
-	8,  // [8:13] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	14, // 0: routes.v1.RouteCreate.match_headers:type_name -> routes.v1.RouteCreate.MatchHeadersEntry
+	17, // 1: routes.v1.RouteCreate.idle_timeout:type_name -> google.protobuf.Duration
+	17, // 2: routes.v1.RouteCreate.max_lifetime:type_name -> google.protobuf.Duration
+	18, // 3: routes.v1.Route.created_at:type_name -> google.protobuf.Timestamp
+	18, // 4: routes.v1.Route.updated_at:type_name -> google.protobuf.Timestamp
+	15, // 5: routes.v1.Route.match_headers:type_name -> routes.v1.Route.MatchHeadersEntry
+	17, // 6: routes.v1.Route.idle_timeout:type_name -> google.protobuf.Duration
+	17, // 7: routes.v1.Route.max_lifetime:type_name -> google.protobuf.Duration
+	16, // 8: routes.v1.RouteUpdate.match_headers:type_name -> routes.v1.RouteUpdate.MatchHeadersEntry
+	17, // 9: routes.v1.RouteUpdate.idle_timeout:type_name -> google.protobuf.Duration
+	17, // 10: routes.v1.RouteUpdate.max_lifetime:type_name -> google.protobuf.Duration
+	0,  // 11: routes.v1.CreateRouteRequest.create:type_name -> routes.v1.RouteCreate
+	1,  // 12: routes.v1.CreateRouteResponse.route:type_name -> routes.v1.Route
+	1,  // 13: routes.v1.GetRouteResponse.route:type_name -> routes.v1.Route
+	2,  // 14: routes.v1.ListRoutesResponse.partials:type_name -> routes.v1.RoutePartial
+	3,  // 15: routes.v1.UpdateRouteRequest.update:type_name -> routes.v1.RouteUpdate
+	1,  // 16: routes.v1.UpdateRouteResponse.route:type_name -> routes.v1.Route
+	4,  // 17: routes.v1.RouteService.CreateRoute:input_type -> routes.v1.CreateRouteRequest
+	6,  // 18: routes.v1.RouteService.GetRoute:input_type -> routes.v1.GetRouteRequest
+	8,  // 19: routes.v1.RouteService.ListRoutes:input_type -> routes.v1.ListRoutesRequest
+	10, // 20: routes.v1.RouteService.UpdateRoute:input_type -> routes.v1.UpdateRouteRequest
+	12, // 21: routes.v1.RouteService.DeleteRoute:input_type -> routes.v1.DeleteRouteRequest
+	5,  // 22: routes.v1.RouteService.CreateRoute:output_type -> routes.v1.CreateRouteResponse
+	7,  // 23: routes.v1.RouteService.GetRoute:output_type -> routes.v1.GetRouteResponse
+	9,  // 24: routes.v1.RouteService.ListRoutes:output_type -> routes.v1.ListRoutesResponse
+	11, // 25: routes.v1.RouteService.UpdateRoute:output_type -> routes.v1.UpdateRouteResponse
+	13, // 26: routes.v1.RouteService.DeleteRoute:output_type -> routes.v1.DeleteRouteResponse
+	22, // [22:27] is the sub-list for method output_type
+	17, // [17:22] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
 }
 
 func init() { file_pb_routes_v1_route_service_proto_init() }
@@ -892,7 +1086,7 @@ func file_pb_routes_v1_route_service_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_routes_v1_route_service_proto_rawDesc), len(file_pb_routes_v1_route_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   14,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -9,6 +9,7 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	reflect "reflect"
 	sync "sync"
 	unsafe "unsafe"
@@ -76,6 +77,125 @@ func (REVERSETUNNELPROTOCOL) EnumDescriptor() ([]byte, []int) {
 	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{0}
 }
 
+type COMPRESSION int32
+
+const (
+	COMPRESSION_COMPRESSION_NONE COMPRESSION = 0
+	COMPRESSION_COMPRESSION_GZIP COMPRESSION = 1
+	COMPRESSION_COMPRESSION_ZSTD COMPRESSION = 2
+)
+
+// Enum value maps for COMPRESSION.
+var (
+	COMPRESSION_name = map[int32]string{
+		0: "COMPRESSION_NONE",
+		1: "COMPRESSION_GZIP",
+		2: "COMPRESSION_ZSTD",
+	}
+	COMPRESSION_value = map[string]int32{
+		"COMPRESSION_NONE": 0,
+		"COMPRESSION_GZIP": 1,
+		"COMPRESSION_ZSTD": 2,
+	}
+)
+
+func (x COMPRESSION) Enum() *COMPRESSION {
+	p := new(COMPRESSION)
+	*p = x
+	return p
+}
+
+func (x COMPRESSION) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (COMPRESSION) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes[1].Descriptor()
+}
+
+func (COMPRESSION) Type() protoreflect.EnumType {
+	return &file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes[1]
+}
+
+func (x COMPRESSION) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use COMPRESSION.Descriptor instead.
+func (COMPRESSION) EnumDescriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{1}
+}
+
+type CLOSEREASON int32
+
+const (
+	CLOSEREASON_CLOSEREASON_UNSPECIFIED     CLOSEREASON = 0
+	CLOSEREASON_CLOSEREASON_NORMAL          CLOSEREASON = 1
+	CLOSEREASON_CLOSEREASON_ROUTE_NOT_FOUND CLOSEREASON = 2
+	CLOSEREASON_CLOSEREASON_DIAL_REFUSED    CLOSEREASON = 3
+	CLOSEREASON_CLOSEREASON_DIAL_TIMEOUT    CLOSEREASON = 4
+	CLOSEREASON_CLOSEREASON_BACKEND_RESET   CLOSEREASON = 5
+	CLOSEREASON_CLOSEREASON_IDLE_TIMEOUT    CLOSEREASON = 6
+	CLOSEREASON_CLOSEREASON_POLICY_DENIED   CLOSEREASON = 7
+	CLOSEREASON_CLOSEREASON_MAX_LIFETIME    CLOSEREASON = 8
+	CLOSEREASON_CLOSEREASON_INTERNAL        CLOSEREASON = 9
+)
+
+// Enum value maps for CLOSEREASON.
+var (
+	CLOSEREASON_name = map[int32]string{
+		0: "CLOSEREASON_UNSPECIFIED",
+		1: "CLOSEREASON_NORMAL",
+		2: "CLOSEREASON_ROUTE_NOT_FOUND",
+		3: "CLOSEREASON_DIAL_REFUSED",
+		4: "CLOSEREASON_DIAL_TIMEOUT",
+		5: "CLOSEREASON_BACKEND_RESET",
+		6: "CLOSEREASON_IDLE_TIMEOUT",
+		7: "CLOSEREASON_POLICY_DENIED",
+		8: "CLOSEREASON_MAX_LIFETIME",
+		9: "CLOSEREASON_INTERNAL",
+	}
+	CLOSEREASON_value = map[string]int32{
+		"CLOSEREASON_UNSPECIFIED":     0,
+		"CLOSEREASON_NORMAL":          1,
+		"CLOSEREASON_ROUTE_NOT_FOUND": 2,
+		"CLOSEREASON_DIAL_REFUSED":    3,
+		"CLOSEREASON_DIAL_TIMEOUT":    4,
+		"CLOSEREASON_BACKEND_RESET":   5,
+		"CLOSEREASON_IDLE_TIMEOUT":    6,
+		"CLOSEREASON_POLICY_DENIED":   7,
+		"CLOSEREASON_MAX_LIFETIME":    8,
+		"CLOSEREASON_INTERNAL":        9,
+	}
+)
+
+func (x CLOSEREASON) Enum() *CLOSEREASON {
+	p := new(CLOSEREASON)
+	*p = x
+	return p
+}
+
+func (x CLOSEREASON) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CLOSEREASON) Descriptor() protoreflect.EnumDescriptor {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes[2].Descriptor()
+}
+
+func (CLOSEREASON) Type() protoreflect.EnumType {
+	return &file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes[2]
+}
+
+func (x CLOSEREASON) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CLOSEREASON.Descriptor instead.
+func (CLOSEREASON) EnumDescriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{2}
+}
+
 type TunnelMessage struct {
 	state     protoimpl.MessageState `protogen:"open.v1"`
 	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
@@ -85,7 +205,15 @@ type TunnelMessage struct {
 	//	*TunnelMessage_NewConnection
 	//	*TunnelMessage_CloseConnection
 	//	*TunnelMessage_RouteUpdates
+	//	*TunnelMessage_Datagram
+	//	*TunnelMessage_WindowUpdate
+	//	*TunnelMessage_Ping
+	//	*TunnelMessage_Pong
+	//	*TunnelMessage_GoAway
+	//	*TunnelMessage_EndOfStream
 	Payload       isTunnelMessage_Payload `protobuf_oneof:"payload"`
+	Seq           uint64                  `protobuf:"varint,7,opt,name=seq,proto3" json:"seq,omitempty"`
+	Compression   COMPRESSION             `protobuf:"varint,12,opt,name=compression,proto3,enum=rtunnel.v1.COMPRESSION" json:"compression,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -170,6 +298,74 @@ func (x *TunnelMessage) GetRouteUpdates() *Route {
 	return nil
 }
 
+func (x *TunnelMessage) GetDatagram() []byte {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_Datagram); ok {
+			return x.Datagram
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetWindowUpdate() *WindowUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_WindowUpdate); ok {
+			return x.WindowUpdate
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetPing() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_Ping); ok {
+			return x.Ping
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetPong() *Heartbeat {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_Pong); ok {
+			return x.Pong
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetGoAway() *GoAway {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_GoAway); ok {
+			return x.GoAway
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetEndOfStream() *EndOfStream {
+	if x != nil {
+		if x, ok := x.Payload.(*TunnelMessage_EndOfStream); ok {
+			return x.EndOfStream
+		}
+	}
+	return nil
+}
+
+func (x *TunnelMessage) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *TunnelMessage) GetCompression() COMPRESSION {
+	if x != nil {
+		return x.Compression
+	}
+	return COMPRESSION_COMPRESSION_NONE
+}
+
 type isTunnelMessage_Payload interface {
 	isTunnelMessage_Payload()
 }
@@ -190,6 +386,30 @@ type TunnelMessage_RouteUpdates struct {
 	RouteUpdates *Route `protobuf:"bytes,5,opt,name=route_updates,json=routeUpdates,proto3,oneof"`
 }
 
+type TunnelMessage_Datagram struct {
+	Datagram []byte `protobuf:"bytes,6,opt,name=datagram,proto3,oneof"`
+}
+
+type TunnelMessage_WindowUpdate struct {
+	WindowUpdate *WindowUpdate `protobuf:"bytes,8,opt,name=window_update,json=windowUpdate,proto3,oneof"`
+}
+
+type TunnelMessage_Ping struct {
+	Ping *Heartbeat `protobuf:"bytes,9,opt,name=ping,proto3,oneof"`
+}
+
+type TunnelMessage_Pong struct {
+	Pong *Heartbeat `protobuf:"bytes,10,opt,name=pong,proto3,oneof"`
+}
+
+type TunnelMessage_GoAway struct {
+	GoAway *GoAway `protobuf:"bytes,11,opt,name=go_away,json=goAway,proto3,oneof"`
+}
+
+type TunnelMessage_EndOfStream struct {
+	EndOfStream *EndOfStream `protobuf:"bytes,13,opt,name=end_of_stream,json=endOfStream,proto3,oneof"`
+}
+
 func (*TunnelMessage_Data) isTunnelMessage_Payload() {}
 
 func (*TunnelMessage_NewConnection) isTunnelMessage_Payload() {}
@@ -198,6 +418,187 @@ func (*TunnelMessage_CloseConnection) isTunnelMessage_Payload() {}
 
 func (*TunnelMessage_RouteUpdates) isTunnelMessage_Payload() {}
 
+func (*TunnelMessage_Datagram) isTunnelMessage_Payload() {}
+
+func (*TunnelMessage_WindowUpdate) isTunnelMessage_Payload() {}
+
+func (*TunnelMessage_Ping) isTunnelMessage_Payload() {}
+
+func (*TunnelMessage_Pong) isTunnelMessage_Payload() {}
+
+func (*TunnelMessage_GoAway) isTunnelMessage_Payload() {}
+
+func (*TunnelMessage_EndOfStream) isTunnelMessage_Payload() {}
+
+// EndOfStream sender finished writing the session, ordered by seq after its data
+type EndOfStream struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EndOfStream) Reset() {
+	*x = EndOfStream{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EndOfStream) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EndOfStream) ProtoMessage() {}
+
+func (x *EndOfStream) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EndOfStream.ProtoReflect.Descriptor instead.
+func (*EndOfStream) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{1}
+}
+
+type GoAway struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Reason        string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GoAway) Reset() {
+	*x = GoAway{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GoAway) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GoAway) ProtoMessage() {}
+
+func (x *GoAway) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GoAway.ProtoReflect.Descriptor instead.
+func (*GoAway) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GoAway) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type Heartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Nonce         uint64                 `protobuf:"varint,1,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Heartbeat) GetNonce() uint64 {
+	if x != nil {
+		return x.Nonce
+	}
+	return 0
+}
+
+type WindowUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Credit        uint32                 `protobuf:"varint,1,opt,name=credit,proto3" json:"credit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WindowUpdate) Reset() {
+	*x = WindowUpdate{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WindowUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WindowUpdate) ProtoMessage() {}
+
+func (x *WindowUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WindowUpdate.ProtoReflect.Descriptor instead.
+func (*WindowUpdate) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *WindowUpdate) GetCredit() uint32 {
+	if x != nil {
+		return x.Credit
+	}
+	return 0
+}
+
 type Route struct {
 	state               protoimpl.MessageState `protogen:"open.v1"`
 	Hostname            string                 `protobuf:"bytes,1,opt,name=hostname,proto3" json:"hostname,omitempty"`
@@ -205,13 +606,16 @@ type Route struct {
 	DestinationIp       string                 `protobuf:"bytes,3,opt,name=destination_ip,json=destinationIp,proto3" json:"destination_ip,omitempty"`
 	DestinationPort     uint32                 `protobuf:"varint,4,opt,name=destination_port,json=destinationPort,proto3" json:"destination_port,omitempty"`
 	IsDeleted           bool                   `protobuf:"varint,5,opt,name=is_deleted,json=isDeleted,proto3" json:"is_deleted,omitempty"`
+	Id                  string                 `protobuf:"bytes,6,opt,name=id,proto3" json:"id,omitempty"`
+	PathPrefix          string                 `protobuf:"bytes,7,opt,name=path_prefix,json=pathPrefix,proto3" json:"path_prefix,omitempty"`
+	MatchHeaders        map[string]string      `protobuf:"bytes,8,rep,name=match_headers,json=matchHeaders,proto3" json:"match_headers,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields       protoimpl.UnknownFields
 	sizeCache           protoimpl.SizeCache
 }
 
 func (x *Route) Reset() {
 	*x = Route{}
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[1]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -223,7 +627,7 @@ func (x *Route) String() string {
 func (*Route) ProtoMessage() {}
 
 func (x *Route) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[1]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -236,7 +640,7 @@ func (x *Route) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Route.ProtoReflect.Descriptor instead.
 func (*Route) Descriptor() ([]byte, []int) {
-	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{1}
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *Route) GetHostname() string {
@@ -274,17 +678,43 @@ func (x *Route) GetIsDeleted() bool {
 	return false
 }
 
+func (x *Route) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Route) GetPathPrefix() string {
+	if x != nil {
+		return x.PathPrefix
+	}
+	return ""
+}
+
+func (x *Route) GetMatchHeaders() map[string]string {
+	if x != nil {
+		return x.MatchHeaders
+	}
+	return nil
+}
+
 type NewConnection struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Protocol      REVERSETUNNELPROTOCOL  `protobuf:"varint,1,opt,name=protocol,proto3,enum=rtunnel.v1.REVERSETUNNELPROTOCOL" json:"protocol,omitempty"`
-	Destination   string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Protocol        REVERSETUNNELPROTOCOL  `protobuf:"varint,1,opt,name=protocol,proto3,enum=rtunnel.v1.REVERSETUNNELPROTOCOL" json:"protocol,omitempty"`
+	Destination     string                 `protobuf:"bytes,2,opt,name=destination,proto3" json:"destination,omitempty"`
+	RouteId         string                 `protobuf:"bytes,3,opt,name=route_id,json=routeId,proto3" json:"route_id,omitempty"`
+	WildcardLabel   string                 `protobuf:"bytes,4,opt,name=wildcard_label,json=wildcardLabel,proto3" json:"wildcard_label,omitempty"`
+	DedicatedStream bool                   `protobuf:"varint,5,opt,name=dedicated_stream,json=dedicatedStream,proto3" json:"dedicated_stream,omitempty"`
+	IdleTimeout     *durationpb.Duration   `protobuf:"bytes,6,opt,name=idle_timeout,json=idleTimeout,proto3" json:"idle_timeout,omitempty"`
+	MaxLifetime     *durationpb.Duration   `protobuf:"bytes,7,opt,name=max_lifetime,json=maxLifetime,proto3" json:"max_lifetime,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *NewConnection) Reset() {
 	*x = NewConnection{}
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[2]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -296,7 +726,7 @@ func (x *NewConnection) String() string {
 func (*NewConnection) ProtoMessage() {}
 
 func (x *NewConnection) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[2]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -309,7 +739,7 @@ func (x *NewConnection) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use NewConnection.ProtoReflect.Descriptor instead.
 func (*NewConnection) Descriptor() ([]byte, []int) {
-	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{2}
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{6}
 }
 
 func (x *NewConnection) GetProtocol() REVERSETUNNELPROTOCOL {
@@ -326,16 +756,53 @@ func (x *NewConnection) GetDestination() string {
 	return ""
 }
 
+func (x *NewConnection) GetRouteId() string {
+	if x != nil {
+		return x.RouteId
+	}
+	return ""
+}
+
+func (x *NewConnection) GetWildcardLabel() string {
+	if x != nil {
+		return x.WildcardLabel
+	}
+	return ""
+}
+
+func (x *NewConnection) GetDedicatedStream() bool {
+	if x != nil {
+		return x.DedicatedStream
+	}
+	return false
+}
+
+func (x *NewConnection) GetIdleTimeout() *durationpb.Duration {
+	if x != nil {
+		return x.IdleTimeout
+	}
+	return nil
+}
+
+func (x *NewConnection) GetMaxLifetime() *durationpb.Duration {
+	if x != nil {
+		return x.MaxLifetime
+	}
+	return nil
+}
+
 type CloseConnection struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	StatusCode    uint32                 `protobuf:"varint,1,opt,name=status_code,json=statusCode,proto3" json:"status_code,omitempty"`
+	Reason        CLOSEREASON            `protobuf:"varint,2,opt,name=reason,proto3,enum=rtunnel.v1.CLOSEREASON" json:"reason,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CloseConnection) Reset() {
 	*x = CloseConnection{}
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[3]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -347,7 +814,7 @@ func (x *CloseConnection) String() string {
 func (*CloseConnection) ProtoMessage() {}
 
 func (x *CloseConnection) ProtoReflect() protoreflect.Message {
-	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[3]
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -360,7 +827,7 @@ func (x *CloseConnection) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CloseConnection.ProtoReflect.Descriptor instead.
 func (*CloseConnection) Descriptor() ([]byte, []int) {
-	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{3}
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *CloseConnection) GetStatusCode() uint32 {
@@ -370,41 +837,290 @@ func (x *CloseConnection) GetStatusCode() uint32 {
 	return 0
 }
 
+func (x *CloseConnection) GetReason() CLOSEREASON {
+	if x != nil {
+		return x.Reason
+	}
+	return CLOSEREASON_CLOSEREASON_UNSPECIFIED
+}
+
+func (x *CloseConnection) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type RefreshTokenRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTokenRequest) Reset() {
+	*x = RefreshTokenRequest{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenRequest) ProtoMessage() {}
+
+func (x *RefreshTokenRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTokenRequest.ProtoReflect.Descriptor instead.
+func (*RefreshTokenRequest) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{8}
+}
+
+type RefreshTokenResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Token         string                 `protobuf:"bytes,1,opt,name=token,proto3" json:"token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RefreshTokenResponse) Reset() {
+	*x = RefreshTokenResponse{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RefreshTokenResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RefreshTokenResponse) ProtoMessage() {}
+
+func (x *RefreshTokenResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RefreshTokenResponse.ProtoReflect.Descriptor instead.
+func (*RefreshTokenResponse) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RefreshTokenResponse) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type EnrollRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// pem encoded certificate request of the agent key
+	Csr           []byte `protobuf:"bytes,1,opt,name=csr,proto3" json:"csr,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollRequest) Reset() {
+	*x = EnrollRequest{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollRequest) ProtoMessage() {}
+
+func (x *EnrollRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollRequest.ProtoReflect.Descriptor instead.
+func (*EnrollRequest) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *EnrollRequest) GetCsr() []byte {
+	if x != nil {
+		return x.Csr
+	}
+	return nil
+}
+
+type EnrollResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// pem encoded client certificate
+	Certificate []byte `protobuf:"bytes,1,opt,name=certificate,proto3" json:"certificate,omitempty"`
+	// pem encoded internal ca certificate, pinned by the agent
+	Ca            []byte `protobuf:"bytes,2,opt,name=ca,proto3" json:"ca,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EnrollResponse) Reset() {
+	*x = EnrollResponse{}
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EnrollResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EnrollResponse) ProtoMessage() {}
+
+func (x *EnrollResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EnrollResponse.ProtoReflect.Descriptor instead.
+func (*EnrollResponse) Descriptor() ([]byte, []int) {
+	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *EnrollResponse) GetCertificate() []byte {
+	if x != nil {
+		return x.Certificate
+	}
+	return nil
+}
+
+func (x *EnrollResponse) GetCa() []byte {
+	if x != nil {
+		return x.Ca
+	}
+	return nil
+}
+
 var File_pb_rtunnel_v1_rtunnel_service_proto protoreflect.FileDescriptor
 
 const file_pb_rtunnel_v1_rtunnel_service_proto_rawDesc = "" +
 	"\n" +
 	"#pb/rtunnel/v1/rtunnel_service.proto\x12\n" +
-	"rtunnel.v1\"\x97\x02\n" +
+	"rtunnel.v1\x1a\x1egoogle/protobuf/duration.proto\"\x8b\x05\n" +
 	"\rTunnelMessage\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x14\n" +
 	"\x04data\x18\x02 \x01(\fH\x00R\x04data\x12B\n" +
 	"\x0enew_connection\x18\x03 \x01(\v2\x19.rtunnel.v1.NewConnectionH\x00R\rnewConnection\x12H\n" +
 	"\x10close_connection\x18\x04 \x01(\v2\x1b.rtunnel.v1.CloseConnectionH\x00R\x0fcloseConnection\x128\n" +
-	"\rroute_updates\x18\x05 \x01(\v2\x11.rtunnel.v1.RouteH\x00R\frouteUpdatesB\t\n" +
-	"\apayload\"\xc7\x01\n" +
+	"\rroute_updates\x18\x05 \x01(\v2\x11.rtunnel.v1.RouteH\x00R\frouteUpdates\x12\x1c\n" +
+	"\bdatagram\x18\x06 \x01(\fH\x00R\bdatagram\x12?\n" +
+	"\rwindow_update\x18\b \x01(\v2\x18.rtunnel.v1.WindowUpdateH\x00R\fwindowUpdate\x12+\n" +
+	"\x04ping\x18\t \x01(\v2\x15.rtunnel.v1.HeartbeatH\x00R\x04ping\x12+\n" +
+	"\x04pong\x18\n" +
+	" \x01(\v2\x15.rtunnel.v1.HeartbeatH\x00R\x04pong\x12-\n" +
+	"\ago_away\x18\v \x01(\v2\x12.rtunnel.v1.GoAwayH\x00R\x06goAway\x12=\n" +
+	"\rend_of_stream\x18\r \x01(\v2\x17.rtunnel.v1.EndOfStreamH\x00R\vendOfStream\x12\x10\n" +
+	"\x03seq\x18\a \x01(\x04R\x03seq\x129\n" +
+	"\vcompression\x18\f \x01(\x0e2\x17.rtunnel.v1.COMPRESSIONR\vcompressionB\t\n" +
+	"\apayload\"\r\n" +
+	"\vEndOfStream\" \n" +
+	"\x06GoAway\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\"!\n" +
+	"\tHeartbeat\x12\x14\n" +
+	"\x05nonce\x18\x01 \x01(\x04R\x05nonce\"&\n" +
+	"\fWindowUpdate\x12\x16\n" +
+	"\x06credit\x18\x01 \x01(\rR\x06credit\"\x83\x03\n" +
 	"\x05Route\x12\x1a\n" +
 	"\bhostname\x18\x01 \x01(\tR\bhostname\x121\n" +
 	"\x14destination_protocol\x18\x02 \x01(\tR\x13destinationProtocol\x12%\n" +
 	"\x0edestination_ip\x18\x03 \x01(\tR\rdestinationIp\x12)\n" +
 	"\x10destination_port\x18\x04 \x01(\rR\x0fdestinationPort\x12\x1d\n" +
 	"\n" +
-	"is_deleted\x18\x05 \x01(\bR\tisDeleted\"p\n" +
+	"is_deleted\x18\x05 \x01(\bR\tisDeleted\x12\x0e\n" +
+	"\x02id\x18\x06 \x01(\tR\x02id\x12\x1f\n" +
+	"\vpath_prefix\x18\a \x01(\tR\n" +
+	"pathPrefix\x12H\n" +
+	"\rmatch_headers\x18\b \x03(\v2#.rtunnel.v1.Route.MatchHeadersEntryR\fmatchHeaders\x1a?\n" +
+	"\x11MatchHeadersEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xd9\x02\n" +
 	"\rNewConnection\x12=\n" +
 	"\bprotocol\x18\x01 \x01(\x0e2!.rtunnel.v1.REVERSETUNNELPROTOCOLR\bprotocol\x12 \n" +
-	"\vdestination\x18\x02 \x01(\tR\vdestination\"2\n" +
+	"\vdestination\x18\x02 \x01(\tR\vdestination\x12\x19\n" +
+	"\broute_id\x18\x03 \x01(\tR\arouteId\x12%\n" +
+	"\x0ewildcard_label\x18\x04 \x01(\tR\rwildcardLabel\x12)\n" +
+	"\x10dedicated_stream\x18\x05 \x01(\bR\x0fdedicatedStream\x12<\n" +
+	"\fidle_timeout\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\vidleTimeout\x12<\n" +
+	"\fmax_lifetime\x18\a \x01(\v2\x19.google.protobuf.DurationR\vmaxLifetime\"}\n" +
 	"\x0fCloseConnection\x12\x1f\n" +
 	"\vstatus_code\x18\x01 \x01(\rR\n" +
-	"statusCode*\xbd\x01\n" +
+	"statusCode\x12/\n" +
+	"\x06reason\x18\x02 \x01(\x0e2\x17.rtunnel.v1.CLOSEREASONR\x06reason\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\"\x15\n" +
+	"\x13RefreshTokenRequest\",\n" +
+	"\x14RefreshTokenResponse\x12\x14\n" +
+	"\x05token\x18\x01 \x01(\tR\x05token\"!\n" +
+	"\rEnrollRequest\x12\x10\n" +
+	"\x03csr\x18\x01 \x01(\fR\x03csr\"B\n" +
+	"\x0eEnrollResponse\x12 \n" +
+	"\vcertificate\x18\x01 \x01(\fR\vcertificate\x12\x0e\n" +
+	"\x02ca\x18\x02 \x01(\fR\x02ca*\xbd\x01\n" +
 	"\x15REVERSETUNNELPROTOCOL\x12%\n" +
 	"!REVERSETUNNELPROTOCOL_UNSPECIFIED\x10\x00\x12\x1d\n" +
 	"\x19REVERSETUNNELPROTOCOL_TCP\x10\x01\x12\x1d\n" +
 	"\x19REVERSETUNNELPROTOCOL_UDP\x10\x02\x12\x1e\n" +
 	"\x1aREVERSETUNNELPROTOCOL_HTTP\x10\x03\x12\x1f\n" +
-	"\x1bREVERSETUNNELPROTOCOL_HTTPS\x10\x042e\n" +
+	"\x1bREVERSETUNNELPROTOCOL_HTTPS\x10\x04*O\n" +
+	"\vCOMPRESSION\x12\x14\n" +
+	"\x10COMPRESSION_NONE\x10\x00\x12\x14\n" +
+	"\x10COMPRESSION_GZIP\x10\x01\x12\x14\n" +
+	"\x10COMPRESSION_ZSTD\x10\x02*\xb3\x02\n" +
+	"\vCLOSEREASON\x12\x1b\n" +
+	"\x17CLOSEREASON_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12CLOSEREASON_NORMAL\x10\x01\x12\x1f\n" +
+	"\x1bCLOSEREASON_ROUTE_NOT_FOUND\x10\x02\x12\x1c\n" +
+	"\x18CLOSEREASON_DIAL_REFUSED\x10\x03\x12\x1c\n" +
+	"\x18CLOSEREASON_DIAL_TIMEOUT\x10\x04\x12\x1d\n" +
+	"\x19CLOSEREASON_BACKEND_RESET\x10\x05\x12\x1c\n" +
+	"\x18CLOSEREASON_IDLE_TIMEOUT\x10\x06\x12\x1d\n" +
+	"\x19CLOSEREASON_POLICY_DENIED\x10\a\x12\x1c\n" +
+	"\x18CLOSEREASON_MAX_LIFETIME\x10\b\x12\x18\n" +
+	"\x14CLOSEREASON_INTERNAL\x10\t2\xba\x01\n" +
 	"\x14ReverseTunnelService\x12M\n" +
-	"\x0fEstablishTunnel\x12\x19.rtunnel.v1.TunnelMessage\x1a\x19.rtunnel.v1.TunnelMessage\"\x00(\x010\x01B(Z&soft.structx.io/dino/protos/rtunnel/v1b\x06proto3"
+	"\x0fEstablishTunnel\x12\x19.rtunnel.v1.TunnelMessage\x1a\x19.rtunnel.v1.TunnelMessage\"\x00(\x010\x01\x12S\n" +
+	"\fRefreshToken\x12\x1f.rtunnel.v1.RefreshTokenRequest\x1a .rtunnel.v1.RefreshTokenResponse\"\x002V\n" +
+	"\x11EnrollmentService\x12A\n" +
+	"\x06Enroll\x12\x19.rtunnel.v1.EnrollRequest\x1a\x1a.rtunnel.v1.EnrollResponse\"\x00B(Z&soft.structx.io/dino/protos/rtunnel/v1b\x06proto3"
 
 var (
 	file_pb_rtunnel_v1_rtunnel_service_proto_rawDescOnce sync.Once
@@ -418,27 +1134,53 @@ func file_pb_rtunnel_v1_rtunnel_service_proto_rawDescGZIP() []byte {
 	return file_pb_rtunnel_v1_rtunnel_service_proto_rawDescData
 }
 
-var file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
+var file_pb_rtunnel_v1_rtunnel_service_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_pb_rtunnel_v1_rtunnel_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
 var file_pb_rtunnel_v1_rtunnel_service_proto_goTypes = []any{
-	(REVERSETUNNELPROTOCOL)(0), // 0: rtunnel.v1.REVERSETUNNELPROTOCOL
-	(*TunnelMessage)(nil),      // 1: rtunnel.v1.TunnelMessage
-	(*Route)(nil),              // 2: rtunnel.v1.Route
-	(*NewConnection)(nil),      // 3: rtunnel.v1.NewConnection
-	(*CloseConnection)(nil),    // 4: rtunnel.v1.CloseConnection
+	(REVERSETUNNELPROTOCOL)(0),   // 0: rtunnel.v1.REVERSETUNNELPROTOCOL
+	(COMPRESSION)(0),             // 1: rtunnel.v1.COMPRESSION
+	(CLOSEREASON)(0),             // 2: rtunnel.v1.CLOSEREASON
+	(*TunnelMessage)(nil),        // 3: rtunnel.v1.TunnelMessage
+	(*EndOfStream)(nil),          // 4: rtunnel.v1.EndOfStream
+	(*GoAway)(nil),               // 5: rtunnel.v1.GoAway
+	(*Heartbeat)(nil),            // 6: rtunnel.v1.Heartbeat
+	(*WindowUpdate)(nil),         // 7: rtunnel.v1.WindowUpdate
+	(*Route)(nil),                // 8: rtunnel.v1.Route
+	(*NewConnection)(nil),        // 9: rtunnel.v1.NewConnection
+	(*CloseConnection)(nil),      // 10: rtunnel.v1.CloseConnection
+	(*RefreshTokenRequest)(nil),  // 11: rtunnel.v1.RefreshTokenRequest
+	(*RefreshTokenResponse)(nil), // 12: rtunnel.v1.RefreshTokenResponse
+	(*EnrollRequest)(nil),        // 13: rtunnel.v1.EnrollRequest
+	(*EnrollResponse)(nil),       // 14: rtunnel.v1.EnrollResponse
+	nil,                          // 15: rtunnel.v1.Route.MatchHeadersEntry
+	(*durationpb.Duration)(nil),  // 16: google.protobuf.Duration
 }
 var file_pb_rtunnel_v1_rtunnel_service_proto_depIdxs = []int32{
-	3, // 0: rtunnel.v1.TunnelMessage.new_connection:type_name -> rtunnel.v1.NewConnection
-	4, // 1: rtunnel.v1.TunnelMessage.close_connection:type_name -> rtunnel.v1.CloseConnection
-	2, // 2: rtunnel.v1.TunnelMessage.route_updates:type_name -> rtunnel.v1.Route
-	0, // 3: rtunnel.v1.NewConnection.protocol:type_name -> rtunnel.v1.REVERSETUNNELPROTOCOL
-	1, // 4: rtunnel.v1.ReverseTunnelService.EstablishTunnel:input_type -> rtunnel.v1.TunnelMessage
-	1, // 5: rtunnel.v1.ReverseTunnelService.EstablishTunnel:output_type -> rtunnel.v1.TunnelMessage
-	5, // [5:6] is the sub-list for method output_type
-	4, // [4:5] is the sub-list for method input_type
-	4, // [4:4] is the sub-list for extension type_name
-	4, // [4:4] is the sub-list for extension extendee
-	0, // [0:4] is the sub-list for field type_name
+	9,  // 0: rtunnel.v1.TunnelMessage.new_connection:type_name -> rtunnel.v1.NewConnection
+	10, // 1: rtunnel.v1.TunnelMessage.close_connection:type_name -> rtunnel.v1.CloseConnection
+	8,  // 2: rtunnel.v1.TunnelMessage.route_updates:type_name -> rtunnel.v1.Route
+	7,  // 3: rtunnel.v1.TunnelMessage.window_update:type_name -> rtunnel.v1.WindowUpdate
+	6,  // 4: rtunnel.v1.TunnelMessage.ping:type_name -> rtunnel.v1.Heartbeat
+	6,  // 5: rtunnel.v1.TunnelMessage.pong:type_name -> rtunnel.v1.Heartbeat
+	5,  // 6: rtunnel.v1.TunnelMessage.go_away:type_name -> rtunnel.v1.GoAway
+	4,  // 7: rtunnel.v1.TunnelMessage.end_of_stream:type_name -> rtunnel.v1.EndOfStream
+	1,  // 8: rtunnel.v1.TunnelMessage.compression:type_name -> rtunnel.v1.COMPRESSION
+	15, // 9: rtunnel.v1.Route.match_headers:type_name -> rtunnel.v1.Route.MatchHeadersEntry
+	0,  // 10: rtunnel.v1.NewConnection.protocol:type_name -> rtunnel.v1.REVERSETUNNELPROTOCOL
+	16, // 11: rtunnel.v1.NewConnection.idle_timeout:type_name -> google.protobuf.Duration
+	16, // 12: rtunnel.v1.NewConnection.max_lifetime:type_name -> google.protobuf.Duration
+	2,  // 13: rtunnel.v1.CloseConnection.reason:type_name -> rtunnel.v1.CLOSEREASON
+	3,  // 14: rtunnel.v1.ReverseTunnelService.EstablishTunnel:input_type -> rtunnel.v1.TunnelMessage
+	11, // 15: rtunnel.v1.ReverseTunnelService.RefreshToken:input_type -> rtunnel.v1.RefreshTokenRequest
+	13, // 16: rtunnel.v1.EnrollmentService.Enroll:input_type -> rtunnel.v1.EnrollRequest
+	3,  // 17: rtunnel.v1.ReverseTunnelService.EstablishTunnel:output_type -> rtunnel.v1.TunnelMessage
+	12, // 18: rtunnel.v1.ReverseTunnelService.RefreshToken:output_type -> rtunnel.v1.RefreshTokenResponse
+	14, // 19: rtunnel.v1.EnrollmentService.Enroll:output_type -> rtunnel.v1.EnrollResponse
+	17, // [17:20] is the sub-list for method output_type
+	14, // [14:17] is the sub-list for method input_type
+	14, // [14:14] is the sub-list for extension type_name
+	14, // [14:14] is the sub-list for extension extendee
+	0,  // [0:14] is the sub-list for field type_name
 }
 
 func init() { file_pb_rtunnel_v1_rtunnel_service_proto_init() }
@@ -451,16 +1193,22 @@ func file_pb_rtunnel_v1_rtunnel_service_proto_init() {
 		(*TunnelMessage_NewConnection)(nil),
 		(*TunnelMessage_CloseConnection)(nil),
 		(*TunnelMessage_RouteUpdates)(nil),
+		(*TunnelMessage_Datagram)(nil),
+		(*TunnelMessage_WindowUpdate)(nil),
+		(*TunnelMessage_Ping)(nil),
+		(*TunnelMessage_Pong)(nil),
+		(*TunnelMessage_GoAway)(nil),
+		(*TunnelMessage_EndOfStream)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_rtunnel_v1_rtunnel_service_proto_rawDesc), len(file_pb_rtunnel_v1_rtunnel_service_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   4,
+			NumEnums:      3,
+			NumMessages:   13,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_pb_rtunnel_v1_rtunnel_service_proto_goTypes,
 		DependencyIndexes: file_pb_rtunnel_v1_rtunnel_service_proto_depIdxs,
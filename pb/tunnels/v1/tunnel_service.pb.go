@@ -9,6 +9,7 @@ package v1
 import (
 	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
 	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
 	emptypb "google.golang.org/protobuf/types/known/emptypb"
 	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
 	reflect "reflect"
@@ -29,6 +30,9 @@ type Tunnel struct {
 	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
 	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	IsActive      bool                   `protobuf:"varint,5,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	LastSeenAt    *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_seen_at,json=lastSeenAt,proto3" json:"last_seen_at,omitempty"`
+	Rtt           *durationpb.Duration   `protobuf:"bytes,7,opt,name=rtt,proto3" json:"rtt,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -91,6 +95,27 @@ func (x *Tunnel) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *Tunnel) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Tunnel) GetLastSeenAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeenAt
+	}
+	return nil
+}
+
+func (x *Tunnel) GetRtt() *durationpb.Duration {
+	if x != nil {
+		return x.Rtt
+	}
+	return nil
+}
+
 type TunnelPartial struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
@@ -681,18 +706,157 @@ func (x *DeleteTunnelResponse) GetEmpty() *emptypb.Empty {
 	return nil
 }
 
+type RotateTunnelCredentialsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Name  string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	// grace_period the previous token keeps verifying, unset revokes it right away
+	GracePeriod   *durationpb.Duration `protobuf:"bytes,2,opt,name=grace_period,json=gracePeriod,proto3" json:"grace_period,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RotateTunnelCredentialsRequest) Reset() {
+	*x = RotateTunnelCredentialsRequest{}
+	mi := &file_pb_tunnels_v1_tunnel_service_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateTunnelCredentialsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateTunnelCredentialsRequest) ProtoMessage() {}
+
+func (x *RotateTunnelCredentialsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_tunnels_v1_tunnel_service_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateTunnelCredentialsRequest.ProtoReflect.Descriptor instead.
+func (*RotateTunnelCredentialsRequest) Descriptor() ([]byte, []int) {
+	return file_pb_tunnels_v1_tunnel_service_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *RotateTunnelCredentialsRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *RotateTunnelCredentialsRequest) GetGracePeriod() *durationpb.Duration {
+	if x != nil {
+		return x.GracePeriod
+	}
+	return nil
+}
+
+type RotateTunnelCredentialsResponse struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Tunnel *Tunnel                `protobuf:"bytes,1,opt,name=tunnel,proto3" json:"tunnel,omitempty"`
+	// Types that are valid to be assigned to AuthDetails:
+	//
+	//	*RotateTunnelCredentialsResponse_SecretKey
+	AuthDetails       isRotateTunnelCredentialsResponse_AuthDetails `protobuf_oneof:"auth_details"`
+	PreviousExpiresAt *timestamppb.Timestamp                        `protobuf:"bytes,3,opt,name=previous_expires_at,json=previousExpiresAt,proto3" json:"previous_expires_at,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *RotateTunnelCredentialsResponse) Reset() {
+	*x = RotateTunnelCredentialsResponse{}
+	mi := &file_pb_tunnels_v1_tunnel_service_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RotateTunnelCredentialsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RotateTunnelCredentialsResponse) ProtoMessage() {}
+
+func (x *RotateTunnelCredentialsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_pb_tunnels_v1_tunnel_service_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RotateTunnelCredentialsResponse.ProtoReflect.Descriptor instead.
+func (*RotateTunnelCredentialsResponse) Descriptor() ([]byte, []int) {
+	return file_pb_tunnels_v1_tunnel_service_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *RotateTunnelCredentialsResponse) GetTunnel() *Tunnel {
+	if x != nil {
+		return x.Tunnel
+	}
+	return nil
+}
+
+func (x *RotateTunnelCredentialsResponse) GetAuthDetails() isRotateTunnelCredentialsResponse_AuthDetails {
+	if x != nil {
+		return x.AuthDetails
+	}
+	return nil
+}
+
+func (x *RotateTunnelCredentialsResponse) GetSecretKey() string {
+	if x != nil {
+		if x, ok := x.AuthDetails.(*RotateTunnelCredentialsResponse_SecretKey); ok {
+			return x.SecretKey
+		}
+	}
+	return ""
+}
+
+func (x *RotateTunnelCredentialsResponse) GetPreviousExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PreviousExpiresAt
+	}
+	return nil
+}
+
+type isRotateTunnelCredentialsResponse_AuthDetails interface {
+	isRotateTunnelCredentialsResponse_AuthDetails()
+}
+
+type RotateTunnelCredentialsResponse_SecretKey struct {
+	SecretKey string `protobuf:"bytes,2,opt,name=secret_key,json=secretKey,proto3,oneof"`
+}
+
+func (*RotateTunnelCredentialsResponse_SecretKey) isRotateTunnelCredentialsResponse_AuthDetails() {}
+
 var File_pb_tunnels_v1_tunnel_service_proto protoreflect.FileDescriptor
 
 const file_pb_tunnels_v1_tunnel_service_proto_rawDesc = "" +
 	"\n" +
-	"\"pb/tunnels/v1/tunnel_service.proto\x12\ttunnel.v1\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa2\x01\n" +
+	"\"pb/tunnels/v1/tunnel_service.proto\x12\ttunnel.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\xaa\x02\n" +
 	"\x06Tunnel\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x129\n" +
 	"\n" +
 	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"#\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1b\n" +
+	"\tis_active\x18\x05 \x01(\bR\bisActive\x12<\n" +
+	"\flast_seen_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\n" +
+	"lastSeenAt\x12+\n" +
+	"\x03rtt\x18\a \x01(\v2\x19.google.protobuf.DurationR\x03rtt\"#\n" +
 	"\rTunnelPartial\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\"D\n" +
 	"\fTunnelUpdate\x12\x19\n" +
@@ -725,13 +889,23 @@ const file_pb_tunnels_v1_tunnel_service_proto_rawDesc = "" +
 	"\x13DeleteTunnelRequest\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\"D\n" +
 	"\x14DeleteTunnelResponse\x12,\n" +
-	"\x05empty\x18\x01 \x01(\v2\x16.google.protobuf.EmptyR\x05empty2\xa2\x03\n" +
+	"\x05empty\x18\x01 \x01(\v2\x16.google.protobuf.EmptyR\x05empty\"r\n" +
+	"\x1eRotateTunnelCredentialsRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12<\n" +
+	"\fgrace_period\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\vgracePeriod\"\xc9\x01\n" +
+	"\x1fRotateTunnelCredentialsResponse\x12)\n" +
+	"\x06tunnel\x18\x01 \x01(\v2\x11.tunnel.v1.TunnelR\x06tunnel\x12\x1f\n" +
+	"\n" +
+	"secret_key\x18\x02 \x01(\tH\x00R\tsecretKey\x12J\n" +
+	"\x13previous_expires_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x11previousExpiresAtB\x0e\n" +
+	"\fauth_details2\x96\x04\n" +
 	"\rTunnelService\x12Q\n" +
 	"\fCreateTunnel\x12\x1e.tunnel.v1.CreateTunnelRequest\x1a\x1f.tunnel.v1.CreateTunnelResponse\"\x00\x12H\n" +
 	"\tGetTunnel\x12\x1b.tunnel.v1.GetTunnelRequest\x1a\x1c.tunnel.v1.GetTunnelResponse\"\x00\x12N\n" +
 	"\vListTunnels\x12\x1d.tunnel.v1.ListTunnelsRequest\x1a\x1e.tunnel.v1.ListTunnelsResponse\"\x00\x12Q\n" +
 	"\fUpdateTunnel\x12\x1e.tunnel.v1.UpdateTunnelRequest\x1a\x1f.tunnel.v1.UpdateTunnelResponse\"\x00\x12Q\n" +
-	"\fDeleteTunnel\x12\x1e.tunnel.v1.DeleteTunnelRequest\x1a\x1f.tunnel.v1.DeleteTunnelResponse\"\x00B'Z%soft.structx.io/dino/protos/tunnel/v1b\x06proto3"
+	"\fDeleteTunnel\x12\x1e.tunnel.v1.DeleteTunnelRequest\x1a\x1f.tunnel.v1.DeleteTunnelResponse\"\x00\x12r\n" +
+	"\x17RotateTunnelCredentials\x12).tunnel.v1.RotateTunnelCredentialsRequest\x1a*.tunnel.v1.RotateTunnelCredentialsResponse\"\x00B'Z%soft.structx.io/dino/protos/tunnel/v1b\x06proto3"
 
 var (
 	file_pb_tunnels_v1_tunnel_service_proto_rawDescOnce sync.Once
@@ -745,48 +919,58 @@ func file_pb_tunnels_v1_tunnel_service_proto_rawDescGZIP() []byte {
 	return file_pb_tunnels_v1_tunnel_service_proto_rawDescData
 }
 
-var file_pb_tunnels_v1_tunnel_service_proto_msgTypes = make([]protoimpl.MessageInfo, 13)
+var file_pb_tunnels_v1_tunnel_service_proto_msgTypes = make([]protoimpl.MessageInfo, 15)
 var file_pb_tunnels_v1_tunnel_service_proto_goTypes = []any{
-	(*Tunnel)(nil),                // 0: tunnel.v1.Tunnel
-	(*TunnelPartial)(nil),         // 1: tunnel.v1.TunnelPartial
-	(*TunnelUpdate)(nil),          // 2: tunnel.v1.TunnelUpdate
-	(*CreateTunnelRequest)(nil),   // 3: tunnel.v1.CreateTunnelRequest
-	(*CreateTunnelResponse)(nil),  // 4: tunnel.v1.CreateTunnelResponse
-	(*GetTunnelRequest)(nil),      // 5: tunnel.v1.GetTunnelRequest
-	(*GetTunnelResponse)(nil),     // 6: tunnel.v1.GetTunnelResponse
-	(*ListTunnelsRequest)(nil),    // 7: tunnel.v1.ListTunnelsRequest
-	(*ListTunnelsResponse)(nil),   // 8: tunnel.v1.ListTunnelsResponse
-	(*UpdateTunnelRequest)(nil),   // 9: tunnel.v1.UpdateTunnelRequest
-	(*UpdateTunnelResponse)(nil),  // 10: tunnel.v1.UpdateTunnelResponse
-	(*DeleteTunnelRequest)(nil),   // 11: tunnel.v1.DeleteTunnelRequest
-	(*DeleteTunnelResponse)(nil),  // 12: tunnel.v1.DeleteTunnelResponse
-	(*timestamppb.Timestamp)(nil), // 13: google.protobuf.Timestamp
-	(*emptypb.Empty)(nil),         // 14: google.protobuf.Empty
+	(*Tunnel)(nil),                          // 0: tunnel.v1.Tunnel
+	(*TunnelPartial)(nil),                   // 1: tunnel.v1.TunnelPartial
+	(*TunnelUpdate)(nil),                    // 2: tunnel.v1.TunnelUpdate
+	(*CreateTunnelRequest)(nil),             // 3: tunnel.v1.CreateTunnelRequest
+	(*CreateTunnelResponse)(nil),            // 4: tunnel.v1.CreateTunnelResponse
+	(*GetTunnelRequest)(nil),                // 5: tunnel.v1.GetTunnelRequest
+	(*GetTunnelResponse)(nil),               // 6: tunnel.v1.GetTunnelResponse
+	(*ListTunnelsRequest)(nil),              // 7: tunnel.v1.ListTunnelsRequest
+	(*ListTunnelsResponse)(nil),             // 8: tunnel.v1.ListTunnelsResponse
+	(*UpdateTunnelRequest)(nil),             // 9: tunnel.v1.UpdateTunnelRequest
+	(*UpdateTunnelResponse)(nil),            // 10: tunnel.v1.UpdateTunnelResponse
+	(*DeleteTunnelRequest)(nil),             // 11: tunnel.v1.DeleteTunnelRequest
+	(*DeleteTunnelResponse)(nil),            // 12: tunnel.v1.DeleteTunnelResponse
+	(*RotateTunnelCredentialsRequest)(nil),  // 13: tunnel.v1.RotateTunnelCredentialsRequest
+	(*RotateTunnelCredentialsResponse)(nil), // 14: tunnel.v1.RotateTunnelCredentialsResponse
+	(*timestamppb.Timestamp)(nil),           // 15: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),             // 16: google.protobuf.Duration
+	(*emptypb.Empty)(nil),                   // 17: google.protobuf.Empty
 }
 var file_pb_tunnels_v1_tunnel_service_proto_depIdxs = []int32{
-	13, // 0: tunnel.v1.Tunnel.created_at:type_name -> google.protobuf.Timestamp
-	13, // 1: tunnel.v1.Tunnel.updated_at:type_name -> google.protobuf.Timestamp
-	0,  // 2: tunnel.v1.CreateTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
-	0,  // 3: tunnel.v1.GetTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
-	1,  // 4: tunnel.v1.ListTunnelsResponse.tunnels:type_name -> tunnel.v1.TunnelPartial
-	2,  // 5: tunnel.v1.UpdateTunnelRequest.tunnel_update:type_name -> tunnel.v1.TunnelUpdate
-	0,  // 6: tunnel.v1.UpdateTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
-	14, // 7: tunnel.v1.DeleteTunnelResponse.empty:type_name -> google.protobuf.Empty
-	3,  // 8: tunnel.v1.TunnelService.CreateTunnel:input_type -> tunnel.v1.CreateTunnelRequest
-	5,  // 9: tunnel.v1.TunnelService.GetTunnel:input_type -> tunnel.v1.GetTunnelRequest
-	7,  // 10: tunnel.v1.TunnelService.ListTunnels:input_type -> tunnel.v1.ListTunnelsRequest
-	9,  // 11: tunnel.v1.TunnelService.UpdateTunnel:input_type -> tunnel.v1.UpdateTunnelRequest
-	11, // 12: tunnel.v1.TunnelService.DeleteTunnel:input_type -> tunnel.v1.DeleteTunnelRequest
-	4,  // 13: tunnel.v1.TunnelService.CreateTunnel:output_type -> tunnel.v1.CreateTunnelResponse
-	6,  // 14: tunnel.v1.TunnelService.GetTunnel:output_type -> tunnel.v1.GetTunnelResponse
-	8,  // 15: tunnel.v1.TunnelService.ListTunnels:output_type -> tunnel.v1.ListTunnelsResponse
-	10, // 16: tunnel.v1.TunnelService.UpdateTunnel:output_type -> tunnel.v1.UpdateTunnelResponse
-	12, // 17: tunnel.v1.TunnelService.DeleteTunnel:output_type -> tunnel.v1.DeleteTunnelResponse
-	13, // [13:18] is the sub-list for method output_type
-	8,  // [8:13] is the sub-list for method input_type
-	8,  // [8:8] is the sub-list for extension type_name
-	8,  // [8:8] is the sub-list for extension extendee
-	0,  // [0:8] is the sub-list for field type_name
+	15, // 0: tunnel.v1.Tunnel.created_at:type_name -> google.protobuf.Timestamp
+	15, // 1: tunnel.v1.Tunnel.updated_at:type_name -> google.protobuf.Timestamp
+	15, // 2: tunnel.v1.Tunnel.last_seen_at:type_name -> google.protobuf.Timestamp
+	16, // 3: tunnel.v1.Tunnel.rtt:type_name -> google.protobuf.Duration
+	0,  // 4: tunnel.v1.CreateTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
+	0,  // 5: tunnel.v1.GetTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
+	1,  // 6: tunnel.v1.ListTunnelsResponse.tunnels:type_name -> tunnel.v1.TunnelPartial
+	2,  // 7: tunnel.v1.UpdateTunnelRequest.tunnel_update:type_name -> tunnel.v1.TunnelUpdate
+	0,  // 8: tunnel.v1.UpdateTunnelResponse.tunnel:type_name -> tunnel.v1.Tunnel
+	17, // 9: tunnel.v1.DeleteTunnelResponse.empty:type_name -> google.protobuf.Empty
+	16, // 10: tunnel.v1.RotateTunnelCredentialsRequest.grace_period:type_name -> google.protobuf.Duration
+	0,  // 11: tunnel.v1.RotateTunnelCredentialsResponse.tunnel:type_name -> tunnel.v1.Tunnel
+	15, // 12: tunnel.v1.RotateTunnelCredentialsResponse.previous_expires_at:type_name -> google.protobuf.Timestamp
+	3,  // 13: tunnel.v1.TunnelService.CreateTunnel:input_type -> tunnel.v1.CreateTunnelRequest
+	5,  // 14: tunnel.v1.TunnelService.GetTunnel:input_type -> tunnel.v1.GetTunnelRequest
+	7,  // 15: tunnel.v1.TunnelService.ListTunnels:input_type -> tunnel.v1.ListTunnelsRequest
+	9,  // 16: tunnel.v1.TunnelService.UpdateTunnel:input_type -> tunnel.v1.UpdateTunnelRequest
+	11, // 17: tunnel.v1.TunnelService.DeleteTunnel:input_type -> tunnel.v1.DeleteTunnelRequest
+	13, // 18: tunnel.v1.TunnelService.RotateTunnelCredentials:input_type -> tunnel.v1.RotateTunnelCredentialsRequest
+	4,  // 19: tunnel.v1.TunnelService.CreateTunnel:output_type -> tunnel.v1.CreateTunnelResponse
+	6,  // 20: tunnel.v1.TunnelService.GetTunnel:output_type -> tunnel.v1.GetTunnelResponse
+	8,  // 21: tunnel.v1.TunnelService.ListTunnels:output_type -> tunnel.v1.ListTunnelsResponse
+	10, // 22: tunnel.v1.TunnelService.UpdateTunnel:output_type -> tunnel.v1.UpdateTunnelResponse
+	12, // 23: tunnel.v1.TunnelService.DeleteTunnel:output_type -> tunnel.v1.DeleteTunnelResponse
+	14, // 24: tunnel.v1.TunnelService.RotateTunnelCredentials:output_type -> tunnel.v1.RotateTunnelCredentialsResponse
+	19, // [19:25] is the sub-list for method output_type
+	13, // [13:19] is the sub-list for method input_type
+	13, // [13:13] is the sub-list for extension type_name
+	13, // [13:13] is the sub-list for extension extendee
+	0,  // [0:13] is the sub-list for field type_name
 }
 
 func init() { file_pb_tunnels_v1_tunnel_service_proto_init() }
@@ -797,13 +981,16 @@ func file_pb_tunnels_v1_tunnel_service_proto_init() {
 	file_pb_tunnels_v1_tunnel_service_proto_msgTypes[4].OneofWrappers = []any{
 		(*CreateTunnelResponse_SecretKey)(nil),
 	}
+	file_pb_tunnels_v1_tunnel_service_proto_msgTypes[14].OneofWrappers = []any{
+		(*RotateTunnelCredentialsResponse_SecretKey)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_pb_tunnels_v1_tunnel_service_proto_rawDesc), len(file_pb_tunnels_v1_tunnel_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   13,
+			NumMessages:   15,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
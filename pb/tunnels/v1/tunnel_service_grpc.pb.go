@@ -19,11 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	TunnelService_CreateTunnel_FullMethodName = "/tunnel.v1.TunnelService/CreateTunnel"
-	TunnelService_GetTunnel_FullMethodName    = "/tunnel.v1.TunnelService/GetTunnel"
-	TunnelService_ListTunnels_FullMethodName  = "/tunnel.v1.TunnelService/ListTunnels"
-	TunnelService_UpdateTunnel_FullMethodName = "/tunnel.v1.TunnelService/UpdateTunnel"
-	TunnelService_DeleteTunnel_FullMethodName = "/tunnel.v1.TunnelService/DeleteTunnel"
+	TunnelService_CreateTunnel_FullMethodName            = "/tunnel.v1.TunnelService/CreateTunnel"
+	TunnelService_GetTunnel_FullMethodName               = "/tunnel.v1.TunnelService/GetTunnel"
+	TunnelService_ListTunnels_FullMethodName             = "/tunnel.v1.TunnelService/ListTunnels"
+	TunnelService_UpdateTunnel_FullMethodName            = "/tunnel.v1.TunnelService/UpdateTunnel"
+	TunnelService_DeleteTunnel_FullMethodName            = "/tunnel.v1.TunnelService/DeleteTunnel"
+	TunnelService_RotateTunnelCredentials_FullMethodName = "/tunnel.v1.TunnelService/RotateTunnelCredentials"
 )
 
 // TunnelServiceClient is the client API for TunnelService service.
@@ -35,6 +36,7 @@ type TunnelServiceClient interface {
 	ListTunnels(ctx context.Context, in *ListTunnelsRequest, opts ...grpc.CallOption) (*ListTunnelsResponse, error)
 	UpdateTunnel(ctx context.Context, in *UpdateTunnelRequest, opts ...grpc.CallOption) (*UpdateTunnelResponse, error)
 	DeleteTunnel(ctx context.Context, in *DeleteTunnelRequest, opts ...grpc.CallOption) (*DeleteTunnelResponse, error)
+	RotateTunnelCredentials(ctx context.Context, in *RotateTunnelCredentialsRequest, opts ...grpc.CallOption) (*RotateTunnelCredentialsResponse, error)
 }
 
 type tunnelServiceClient struct {
@@ -95,6 +97,16 @@ func (c *tunnelServiceClient) DeleteTunnel(ctx context.Context, in *DeleteTunnel
 	return out, nil
 }
 
+func (c *tunnelServiceClient) RotateTunnelCredentials(ctx context.Context, in *RotateTunnelCredentialsRequest, opts ...grpc.CallOption) (*RotateTunnelCredentialsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RotateTunnelCredentialsResponse)
+	err := c.cc.Invoke(ctx, TunnelService_RotateTunnelCredentials_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TunnelServiceServer is the server API for TunnelService service.
 // All implementations must embed UnimplementedTunnelServiceServer
 // for forward compatibility.
@@ -104,6 +116,7 @@ type TunnelServiceServer interface {
 	ListTunnels(context.Context, *ListTunnelsRequest) (*ListTunnelsResponse, error)
 	UpdateTunnel(context.Context, *UpdateTunnelRequest) (*UpdateTunnelResponse, error)
 	DeleteTunnel(context.Context, *DeleteTunnelRequest) (*DeleteTunnelResponse, error)
+	RotateTunnelCredentials(context.Context, *RotateTunnelCredentialsRequest) (*RotateTunnelCredentialsResponse, error)
 	mustEmbedUnimplementedTunnelServiceServer()
 }
 
@@ -129,6 +142,9 @@ func (UnimplementedTunnelServiceServer) UpdateTunnel(context.Context, *UpdateTun
 func (UnimplementedTunnelServiceServer) DeleteTunnel(context.Context, *DeleteTunnelRequest) (*DeleteTunnelResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method DeleteTunnel not implemented")
 }
+func (UnimplementedTunnelServiceServer) RotateTunnelCredentials(context.Context, *RotateTunnelCredentialsRequest) (*RotateTunnelCredentialsResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RotateTunnelCredentials not implemented")
+}
 func (UnimplementedTunnelServiceServer) mustEmbedUnimplementedTunnelServiceServer() {}
 func (UnimplementedTunnelServiceServer) testEmbeddedByValue()                       {}
 
@@ -240,6 +256,24 @@ func _TunnelService_DeleteTunnel_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TunnelService_RotateTunnelCredentials_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateTunnelCredentialsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TunnelServiceServer).RotateTunnelCredentials(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TunnelService_RotateTunnelCredentials_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TunnelServiceServer).RotateTunnelCredentials(ctx, req.(*RotateTunnelCredentialsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TunnelService_ServiceDesc is the grpc.ServiceDesc for TunnelService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -267,6 +301,10 @@ var TunnelService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteTunnel",
 			Handler:    _TunnelService_DeleteTunnel_Handler,
 		},
+		{
+			MethodName: "RotateTunnelCredentials",
+			Handler:    _TunnelService_RotateTunnelCredentials_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "pb/tunnels/v1/tunnel_service.proto",
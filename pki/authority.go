@@ -0,0 +1,259 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// caLifetime of the internal ca, it is not rotated
+	caLifetime = 10 * 365 * 24 * time.Hour
+	// caCommonName subject of the internal ca
+	caCommonName = "dino internal ca"
+	// clockSkew issued certificates are valid this long before they are signed
+	clockSkew = time.Minute
+)
+
+var (
+	// ErrInvalidRequest certificate request is malformed or not signed by its key
+	ErrInvalidRequest = errors.New("invalid certificate request")
+)
+
+// Authority internal certificate authority, issues the tunnel gateway
+// certificate and the client certificates of enrolled agents
+type Authority interface {
+	// CertificatePEM ca certificate agents pin
+	CertificatePEM() []byte
+	// Pool verifies client certificates issued by the ca
+	Pool() *x509.CertPool
+	// IssueClient sign the pem encoded certificate request of an agent, the
+	// certificate names the tunnel as its common name
+	IssueClient(tunnelID string, csrPEM []byte) ([]byte, error)
+	// ServerCertificate tunnel gateway certificate, reissued once two thirds of its lifetime passed
+	ServerCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error)
+}
+
+type caImpl struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     crypto.Signer
+	pool    *x509.CertPool
+
+	// lifetime of issued certificates
+	lifetime time.Duration
+	// hostnames dns names and ip addresses of the gateway certificate
+	hostnames []string
+
+	mtx    sync.Mutex
+	server *tls.Certificate
+}
+
+// interface compliance
+var _ Authority = (*caImpl)(nil)
+
+// newAuthority ca of a pem encoded certificate and its key
+func newAuthority(certPEM []byte, key crypto.Signer, lifetime time.Duration, hostnames []string) (*caImpl, error) {
+	if lifetime <= 0 {
+		return nil, fmt.Errorf("certificate lifetime must be positive: %s", lifetime)
+	}
+
+	block, _ := pem.Decode(certPEM)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("missing ca certificate pem block")
+	}
+
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCertificate: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &caImpl{
+		cert:      cert,
+		certPEM:   certPEM,
+		key:       key,
+		pool:      pool,
+		lifetime:  lifetime,
+		hostnames: hostnames,
+	}, nil
+}
+
+// generateCA self-signed ca certificate and its pkcs8 encoded key
+func generateCA() ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("ecdsa.GenerateKey: %w", err)
+	}
+
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: caCommonName},
+		NotBefore:             now.Add(-clockSkew),
+		NotAfter:              now.Add(caLifetime),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.CreateCertificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.MarshalPKCS8PrivateKey: %w", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyDER, nil
+}
+
+// CertificatePEM implements Authority.
+func (ca *caImpl) CertificatePEM() []byte {
+	return ca.certPEM
+}
+
+// Pool implements Authority.
+func (ca *caImpl) Pool() *x509.CertPool {
+	return ca.pool
+}
+
+// IssueClient implements Authority.
+func (ca *caImpl) IssueClient(tunnelID string, csrPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("%w: missing pem block", ErrInvalidRequest)
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRequest, err)
+	}
+
+	// only the key of the request is used, the subject is always the tunnel
+	der, err := ca.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: tunnelID},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, csr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// ServerCertificate implements Authority.
+func (ca *caImpl) ServerCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	ca.mtx.Lock()
+	defer ca.mtx.Unlock()
+
+	if ca.server != nil && time.Now().Before(renewAt(ca.server.Leaf)) {
+		return ca.server, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa.GenerateKey: %w", err)
+	}
+
+	template := &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "dino tunnel gateway"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, name := range ca.hostnames {
+		if ip := net.ParseIP(name); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, name)
+		}
+	}
+
+	der, err := ca.issue(template, &key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("x509.ParseCertificate: %w", err)
+	}
+
+	// agents pinning the ca by fingerprint find it in the chain
+	ca.server = &tls.Certificate{
+		Certificate: [][]byte{der, ca.cert.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	return ca.server, nil
+}
+
+// issue sign template for pub, valid for the certificate lifetime
+func (ca *caImpl) issue(template *x509.Certificate, pub any) ([]byte, error) {
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	template.SerialNumber = serial
+	template.NotBefore = now.Add(-clockSkew)
+	template.NotAfter = now.Add(ca.lifetime)
+	if template.NotAfter.After(ca.cert.NotAfter) {
+		template.NotAfter = ca.cert.NotAfter
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, pub, ca.key)
+	if err != nil {
+		return nil, fmt.Errorf("x509.CreateCertificate: %w", err)
+	}
+	return der, nil
+}
+
+// Fingerprint hex encoded sha256 of the der encoded certificate, agents
+// pin the ca with it
+func Fingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// renewAt time a third of the lifetime of cert is left
+func renewAt(cert *x509.Certificate) time.Time {
+	return cert.NotAfter.Add(-cert.NotAfter.Sub(cert.NotBefore) / 3)
+}
+
+// serialNumber random 128 bit certificate serial
+func serialNumber() (*big.Int, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("rand.Int: %w", err)
+	}
+	return serial, nil
+}
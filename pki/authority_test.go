@@ -0,0 +1,108 @@
+package pki
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type AuthoritySuite struct {
+	suite.Suite
+
+	ca *caImpl
+}
+
+func (suite *AuthoritySuite) SetupTest() {
+	certPEM, keyDER, err := generateCA()
+	suite.Require().NoError(err)
+
+	key, err := x509.ParsePKCS8PrivateKey(keyDER)
+	suite.Require().NoError(err)
+
+	suite.ca, err = newAuthority(certPEM, key.(crypto.Signer), time.Hour, []string{"tunnel.dino.local", "127.0.0.1"})
+	suite.Require().NoError(err)
+}
+
+func (suite *AuthoritySuite) csr(commonName string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: commonName},
+	}, key)
+	suite.Require().NoError(err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+func (suite *AuthoritySuite) TestIssueClient() {
+	// the subject of the request is replaced by the tunnel
+	certPEM, err := suite.ca.IssueClient("tunnel-id", suite.csr("other-tunnel"))
+	suite.Require().NoError(err)
+
+	block, _ := pem.Decode(certPEM)
+	suite.Require().NotNil(block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	suite.Require().NoError(err)
+
+	suite.Equal("tunnel-id", cert.Subject.CommonName)
+	suite.WithinDuration(time.Now().Add(time.Hour), cert.NotAfter, time.Minute)
+
+	_, err = cert.Verify(x509.VerifyOptions{
+		Roots:     suite.ca.Pool(),
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	suite.NoError(err)
+}
+
+func (suite *AuthoritySuite) TestIssueClientInvalidRequest() {
+	_, err := suite.ca.IssueClient("tunnel-id", []byte("not a csr"))
+	suite.ErrorIs(err, ErrInvalidRequest)
+
+	csr := suite.csr("tunnel-id")
+	block, _ := pem.Decode(csr)
+	// corrupt the signature
+	block.Bytes[len(block.Bytes)-1] ^= 0xff
+	_, err = suite.ca.IssueClient("tunnel-id", pem.EncodeToMemory(block))
+	suite.ErrorIs(err, ErrInvalidRequest)
+}
+
+func (suite *AuthoritySuite) TestServerCertificate() {
+	cert, err := suite.ca.ServerCertificate(nil)
+	suite.Require().NoError(err)
+
+	for _, name := range []string{"tunnel.dino.local", "127.0.0.1"} {
+		_, err = cert.Leaf.Verify(x509.VerifyOptions{
+			DNSName: name,
+			Roots:   suite.ca.Pool(),
+		})
+		suite.NoError(err, name)
+	}
+
+	// the chain carries the ca for agents pinning its fingerprint
+	suite.Require().Len(cert.Certificate, 2)
+	suite.Equal(suite.ca.cert.Raw, cert.Certificate[1])
+
+	cached, err := suite.ca.ServerCertificate(nil)
+	suite.Require().NoError(err)
+	suite.Same(cert, cached)
+
+	// reissued once two thirds of the lifetime passed
+	suite.ca.server.Leaf.NotBefore = time.Now().Add(-time.Hour)
+	suite.ca.server.Leaf.NotAfter = time.Now().Add(time.Minute)
+	renewed, err := suite.ca.ServerCertificate(nil)
+	suite.Require().NoError(err)
+	suite.NotSame(cert, renewed)
+}
+
+func TestAuthoritySuite(t *testing.T) {
+	suite.Run(t, new(AuthoritySuite))
+}
@@ -0,0 +1,113 @@
+package pki
+
+import (
+	"context"
+	"crypto"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/structx/teapot"
+	"go.uber.org/fx"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/database"
+	"soft.structx.io/dino/pki/queries"
+	"soft.structx.io/dino/setup"
+)
+
+// Params
+type Params struct {
+	fx.In
+
+	Cfg    *setup.Server
+	Logger *teapot.Logger
+
+	DBTX   database.DBTX
+	Cipher auth.KeyCipher
+}
+
+// Result
+type Result struct {
+	fx.Out
+
+	Authority Authority
+}
+
+// Module
+var Module = fx.Module("pki", fx.Provide(newModule))
+
+func newModule(p Params) (Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*10)
+	defer cancel()
+
+	certPEM, key, err := loadAuthority(ctx, p.Logger, p.DBTX, p.Cipher)
+	if err != nil {
+		return Result{}, fmt.Errorf("load certificate authority: %w", err)
+	}
+
+	ca, err := newAuthority(certPEM, key, p.Cfg.CertLifetime, p.Cfg.CertHostnames)
+	if err != nil {
+		return Result{}, fmt.Errorf("newAuthority: %w", err)
+	}
+	p.Logger.Info("certificate authority", teapot.String("fingerprint", Fingerprint(ca.cert)))
+
+	return Result{Authority: ca}, nil
+}
+
+// caKeyData additional data the ca key is sealed with, tunnel signing keys
+// are sealed with their tunnel id so neither opens in place of the other
+var caKeyData = []byte("dino certificate authority")
+
+// loadAuthority stored ca certificate and key, generated on first start
+func loadAuthority(ctx context.Context, logger *teapot.Logger, db database.DBTX, cipher auth.KeyCipher) ([]byte, crypto.Signer, error) {
+	q := queries.New(db)
+
+	sqlCA, err := q.SelectAuthority(ctx)
+	if errors.Is(err, pgx.ErrNoRows) {
+		logger.Info("generate certificate authority")
+
+		certPEM, keyDER, err := generateCA()
+		if err != nil {
+			return nil, nil, fmt.Errorf("generateCA: %w", err)
+		}
+
+		sealed, err := cipher.Seal(keyDER, caKeyData)
+		if err != nil {
+			return nil, nil, fmt.Errorf("seal ca key: %w", err)
+		}
+
+		// another replica may have stored its ca first, every replica uses the stored one
+		if err := q.InsertAuthority(ctx, queries.InsertAuthorityParams{
+			CertPem:   certPEM,
+			SealedKey: sealed,
+		}); err != nil {
+			return nil, nil, fmt.Errorf("failed to execute insert authority query: %w", err)
+		}
+
+		sqlCA, err = q.SelectAuthority(ctx)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to execute select authority query: %w", err)
+		}
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to execute select authority query: %w", err)
+	}
+
+	keyDER, err := cipher.Open(sqlCA.SealedKey, caKeyData)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open ca key: %w", err)
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(keyDER)
+	if err != nil {
+		return nil, nil, fmt.Errorf("x509.ParsePKCS8PrivateKey: %w", err)
+	}
+
+	key, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, nil, fmt.Errorf("unexpected ca key type %T", parsed)
+	}
+
+	return sqlCA.CertPem, key, nil
+}
@@ -0,0 +1,16 @@
+-- name: InsertAuthority :exec
+-- InsertAuthority keeps the ca of a replica that started first
+INSERT INTO dino.certificate_authority (
+    cert_pem,
+    sealed_key
+) VALUES (
+    $1, $2
+) ON CONFLICT (id) DO NOTHING;
+
+-- name: SelectAuthority :one
+SELECT
+    *
+FROM
+    dino.certificate_authority
+WHERE
+    id = 1;
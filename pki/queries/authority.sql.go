@@ -0,0 +1,51 @@
+// Code generated by sqlc. DO NOT EDIT.
+// versions:
+//   sqlc v1.30.0
+// source: authority.sql
+
+package queries
+
+import (
+	"context"
+)
+
+const insertAuthority = `-- name: InsertAuthority :exec
+INSERT INTO dino.certificate_authority (
+    cert_pem,
+    sealed_key
+) VALUES (
+    $1, $2
+) ON CONFLICT (id) DO NOTHING
+`
+
+type InsertAuthorityParams struct {
+	CertPem   []byte
+	SealedKey []byte
+}
+
+// InsertAuthority keeps the ca of a replica that started first
+func (q *Queries) InsertAuthority(ctx context.Context, arg InsertAuthorityParams) error {
+	_, err := q.db.Exec(ctx, insertAuthority, arg.CertPem, arg.SealedKey)
+	return err
+}
+
+const selectAuthority = `-- name: SelectAuthority :one
+SELECT
+    id, cert_pem, sealed_key, created_at
+FROM
+    dino.certificate_authority
+WHERE
+    id = 1
+`
+
+func (q *Queries) SelectAuthority(ctx context.Context) (DinoCertificateAuthority, error) {
+	row := q.db.QueryRow(ctx, selectAuthority)
+	var i DinoCertificateAuthority
+	err := row.Scan(
+		&i.ID,
+		&i.CertPem,
+		&i.SealedKey,
+		&i.CreatedAt,
+	)
+	return i, err
+}
@@ -0,0 +1,145 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/structx/teapot"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/routing"
+	"soft.structx.io/dino/setup"
+)
+
+const protocolHTTP = "http"
+
+// acmeIssuer obtain and renew certificates for enabled http routes
+type acmeIssuer struct {
+	ctx      context.Context
+	cancelFn context.CancelFunc
+
+	log *teapot.Logger
+
+	manager *autocert.Manager
+	broker  pubsub.Broker
+}
+
+func newACMEIssuer(
+	cfg *setup.Proxy,
+	logger *teapot.Logger,
+	routeSvc routes.Service,
+	cache autocert.Cache,
+	broker pubsub.Broker,
+) (*acmeIssuer, error) {
+	httpClient := http.DefaultClient
+	if cfg.ACMECAPath != "" {
+		pem, err := os.ReadFile(cfg.ACMECAPath)
+		if err != nil {
+			return nil, fmt.Errorf("os.ReadFile: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.ACMECAPath)
+		}
+
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+		httpClient = &http.Client{Transport: transport}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &acmeIssuer{
+		ctx:      ctx,
+		cancelFn: cancel,
+		log:      logger,
+		manager: &autocert.Manager{
+			Prompt: autocert.AcceptTOS,
+			Cache:  cache,
+			Email:  cfg.ACMEEmail,
+			Client: &acme.Client{
+				DirectoryURL: cfg.ACMEDirectoryURL,
+				HTTPClient:   httpClient,
+			},
+			HostPolicy: hostPolicy(routeSvc, cfg.ACMEHosts),
+		},
+		broker: broker,
+	}, nil
+}
+
+// hostPolicy issue certificates for hostnames of active exact routes, subdomains
+// of wildcard routes only when listed in allowed so handshakes cannot request
+// a certificate for every name under a wildcard
+func hostPolicy(routeSvc routes.Service, allowed []string) autocert.HostPolicy {
+	return func(ctx context.Context, host string) error {
+		active, err := routeSvc.Active(ctx, host, protocolHTTP)
+		if err != nil {
+			return fmt.Errorf("no active route for %s", host)
+		}
+
+		if active.Label != "" && !slices.Contains(allowed, host) {
+			return fmt.Errorf("%s is not an allowed wildcard subdomain", host)
+		}
+		return nil
+	}
+}
+
+func (a *acmeIssuer) start(_ context.Context) error {
+	ch := a.broker.Subscribe("dino.routes")
+	go a.subscription(ch)
+	return nil
+}
+
+func (a *acmeIssuer) stop(_ context.Context) error {
+	a.cancelFn()
+	return nil
+}
+
+// subscription request certificates for routes as soon as they are enabled
+func (a *acmeIssuer) subscription(ch chan string) {
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case msg := <-ch:
+			rcfg, err := pubsub.DecodeRouteConfig(msg)
+			if err != nil {
+				a.log.Error("decode route config", teapot.Error(err))
+				continue
+			}
+
+			if rcfg.IsDelete || rcfg.DestProtocol != protocolHTTP {
+				continue
+			}
+
+			// http-01 cannot issue wildcards, allowed subdomains are issued on first handshake
+			if routing.IsWildcard(rcfg.Hostname) {
+				continue
+			}
+
+			go a.warm(rcfg.Hostname)
+		}
+	}
+}
+
+// warm issue or load certificate for hostname, which also schedules its renewal
+func (a *acmeIssuer) warm(hostname string) {
+	if _, err := a.manager.GetCertificate(&tls.ClientHelloInfo{ServerName: hostname}); err != nil {
+		a.log.Error("acme certificate", teapot.String("hostname", hostname), teapot.Error(err))
+		return
+	}
+	a.log.Info("acme certificate ready", teapot.String("hostname", hostname))
+}
+
+// isChallenge request is an acme http-01 challenge
+func isChallenge(r *http.Request) bool {
+	return strings.HasPrefix(r.URL.Path, "/.well-known/acme-challenge/")
+}
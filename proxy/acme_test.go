@@ -0,0 +1,395 @@
+package proxy
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/setup"
+)
+
+// activeRoutes active route of every listed hostname
+type activeRoutes struct {
+	routes.Service
+
+	active map[string]routes.RouteMatch
+}
+
+// Active implements routes.Service.
+func (a activeRoutes) Active(_ context.Context, hostname, _ string) (routes.RouteMatch, error) {
+	if m, ok := a.active[hostname]; ok {
+		return m, nil
+	}
+	return routes.RouteMatch{}, routes.ErrNoMatch
+}
+
+// memoryCache autocert cache of the test issuer
+type memoryCache struct {
+	mtx  sync.Mutex
+	data map[string][]byte
+}
+
+// interface compliance
+var _ autocert.Cache = (*memoryCache)(nil)
+
+// Get implements autocert.Cache.
+func (m *memoryCache) Get(_ context.Context, key string) ([]byte, error) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	data, ok := m.data[key]
+	if !ok {
+		return nil, autocert.ErrCacheMiss
+	}
+	return data, nil
+}
+
+// Put implements autocert.Cache.
+func (m *memoryCache) Put(_ context.Context, key string, data []byte) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.data[key] = data
+	return nil
+}
+
+// Delete implements autocert.Cache.
+func (m *memoryCache) Delete(_ context.Context, key string) error {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.data, key)
+	return nil
+}
+
+// stubACME rfc 8555 directory answering http-01 challenges through
+// challenges and signing every order with a throwaway ca, signatures of
+// requests are not verified
+type stubACME struct {
+	srv *httptest.Server
+
+	ca  *x509.Certificate
+	key *ecdsa.PrivateKey
+
+	// challenges http-01 handler of the issuer under test
+	challenges http.Handler
+
+	mtx    sync.Mutex
+	domain string
+	valid  bool
+	csr    *x509.CertificateRequest
+	issued []string
+}
+
+func newStubACME() (*stubACME, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "stub acme ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &stubACME{ca: ca, key: key}
+	s.srv = httptest.NewTLSServer(http.HandlerFunc(s.serveHTTP))
+	return s, nil
+}
+
+func (s *stubACME) url(path string) string {
+	return s.srv.URL + path
+}
+
+func (s *stubACME) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Replay-Nonce", base64.RawURLEncoding.EncodeToString([]byte(time.Now().String())))
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	payload, err := jwsPayload(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	switch r.URL.Path {
+	case "/directory":
+		writeJSON(w, http.StatusOK, map[string]string{
+			"newNonce":   s.url("/nonce"),
+			"newAccount": s.url("/account"),
+			"newOrder":   s.url("/order"),
+			"revokeCert": s.url("/revoke"),
+			"keyChange":  s.url("/key-change"),
+		})
+	case "/account":
+		w.Header().Set("Location", s.url("/account/1"))
+		writeJSON(w, http.StatusCreated, map[string]string{"status": "valid"})
+	case "/order":
+		var order struct {
+			Identifiers []struct {
+				Value string `json:"value"`
+			} `json:"identifiers"`
+		}
+		if err := json.Unmarshal(payload, &order); err != nil || len(order.Identifiers) != 1 {
+			http.Error(w, "invalid order", http.StatusBadRequest)
+			return
+		}
+		s.domain = order.Identifiers[0].Value
+		s.valid = false
+		s.csr = nil
+
+		w.Header().Set("Location", s.url("/order/1"))
+		writeJSON(w, http.StatusCreated, s.order())
+	case "/order/1":
+		writeJSON(w, http.StatusOK, s.order())
+	case "/authz/1":
+		writeJSON(w, http.StatusOK, s.authorization())
+	case "/challenge/1":
+		// validate the key authorization the issuer serves for the token
+		rec := httptest.NewRecorder()
+		s.challenges.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://"+s.domain+"/.well-known/acme-challenge/token", nil))
+		s.valid = rec.Code == http.StatusOK && strings.HasPrefix(rec.Body.String(), "token.")
+		writeJSON(w, http.StatusOK, s.challenge())
+	case "/finalize/1":
+		var finalize struct {
+			CSR string `json:"csr"`
+		}
+		if err := json.Unmarshal(payload, &finalize); err != nil {
+			http.Error(w, "invalid finalize", http.StatusBadRequest)
+			return
+		}
+		der, err := base64.RawURLEncoding.DecodeString(finalize.CSR)
+		if err != nil {
+			http.Error(w, "invalid csr", http.StatusBadRequest)
+			return
+		}
+		if s.csr, err = x509.ParseCertificateRequest(der); err != nil {
+			http.Error(w, "invalid csr", http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, s.order())
+	case "/cert/1":
+		chain, err := s.certificate()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		s.issued = append(s.issued, s.domain)
+		w.Header().Set("Content-Type", "application/pem-certificate-chain")
+		_, _ = w.Write(chain)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *stubACME) order() map[string]any {
+	status := "pending"
+	if s.csr != nil {
+		status = "valid"
+	} else if s.valid {
+		status = "ready"
+	}
+
+	order := map[string]any{
+		"status":         status,
+		"identifiers":    []map[string]string{{"type": "dns", "value": s.domain}},
+		"authorizations": []string{s.url("/authz/1")},
+		"finalize":       s.url("/finalize/1"),
+	}
+	if s.csr != nil {
+		order["certificate"] = s.url("/cert/1")
+	}
+	return order
+}
+
+func (s *stubACME) authorization() map[string]any {
+	status := "pending"
+	if s.valid {
+		status = "valid"
+	}
+	return map[string]any{
+		"status":     status,
+		"identifier": map[string]string{"type": "dns", "value": s.domain},
+		"challenges": []map[string]any{s.challenge()},
+	}
+}
+
+func (s *stubACME) challenge() map[string]any {
+	status := "pending"
+	if s.valid {
+		status = "valid"
+	}
+	return map[string]any{
+		"type":   "http-01",
+		"url":    s.url("/challenge/1"),
+		"token":  "token",
+		"status": status,
+	}
+}
+
+// certificate pem chain for the key and names of the finalized request
+func (s *stubACME) certificate() ([]byte, error) {
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: s.domain},
+		DNSNames:     s.csr.DNSNames,
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, s.ca, s.csr.PublicKey, s.key)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	return append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.ca.Raw})...), nil
+}
+
+// jwsPayload decoded payload of a flattened jws request body
+func jwsPayload(r *http.Request) ([]byte, error) {
+	if r.Method != http.MethodPost {
+		return nil, nil
+	}
+
+	var jws struct {
+		Payload string `json:"payload"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&jws); err != nil {
+		return nil, fmt.Errorf("decode jws: %w", err)
+	}
+	return base64.RawURLEncoding.DecodeString(jws.Payload)
+}
+
+// hello client hello of a client supporting ecdsa certificates
+func hello(serverName string) *tls.ClientHelloInfo {
+	return &tls.ClientHelloInfo{
+		ServerName:        serverName,
+		CipherSuites:      []uint16{tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256},
+		SignatureSchemes:  []tls.SignatureScheme{tls.ECDSAWithP256AndSHA256},
+		SupportedCurves:   []tls.CurveID{tls.CurveP256},
+		SupportedVersions: []uint16{tls.VersionTLS13},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+type ACMESuite struct {
+	suite.Suite
+
+	acme   *stubACME
+	issuer *acmeIssuer
+	cache  *memoryCache
+}
+
+func (suite *ACMESuite) SetupTest() {
+	var err error
+	suite.acme, err = newStubACME()
+	suite.Require().NoError(err)
+	suite.T().Cleanup(suite.acme.srv.Close)
+
+	// the directory is trusted like a pebble test ca
+	caPath := filepath.Join(suite.T().TempDir(), "acme.pem")
+	suite.Require().NoError(os.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: suite.acme.srv.Certificate().Raw,
+	}), 0o600))
+
+	suite.cache = &memoryCache{data: map[string][]byte{}}
+	suite.issuer, err = newACMEIssuer(
+		&setup.Proxy{
+			ACMEEmail:        "ops@dino.local",
+			ACMEDirectoryURL: suite.acme.url("/directory"),
+			ACMECAPath:       caPath,
+			ACMEHosts:        []string{"allowed.preview.dino.local"},
+		},
+		teapot.New(teapot.WithWriter(io.Discard)),
+		activeRoutes{active: map[string]routes.RouteMatch{
+			"web.dino.local":             {TunnelUID: "tunnel"},
+			"allowed.preview.dino.local": {TunnelUID: "tunnel", Label: "allowed"},
+			"other.preview.dino.local":   {TunnelUID: "tunnel", Label: "other"},
+		}},
+		suite.cache,
+		nil,
+	)
+	suite.Require().NoError(err)
+	suite.acme.challenges = suite.issuer.manager.HTTPHandler(nil)
+}
+
+func (suite *ACMESuite) TestIssue() {
+	cert, err := suite.issuer.manager.GetCertificate(hello("web.dino.local"))
+	suite.Require().NoError(err)
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	suite.Require().NoError(err)
+	suite.Equal([]string{"web.dino.local"}, leaf.DNSNames)
+	suite.Equal(suite.acme.ca.Subject, leaf.Issuer)
+
+	// later handshakes are served from the cache
+	_, err = suite.cache.Get(context.Background(), "web.dino.local")
+	suite.Require().NoError(err)
+
+	_, err = suite.issuer.manager.GetCertificate(hello("web.dino.local"))
+	suite.Require().NoError(err)
+	suite.Equal([]string{"web.dino.local"}, suite.acme.issued)
+}
+
+func (suite *ACMESuite) TestHostPolicy() {
+	policy := suite.issuer.manager.HostPolicy
+
+	suite.NoError(policy(context.Background(), "web.dino.local"))
+	suite.NoError(policy(context.Background(), "allowed.preview.dino.local"))
+
+	// subdomains of a wildcard route are only issued when allowed
+	suite.Error(policy(context.Background(), "other.preview.dino.local"))
+	suite.Error(policy(context.Background(), "unrouted.dino.local"))
+
+	_, err := suite.issuer.manager.GetCertificate(hello("other.preview.dino.local"))
+	suite.Error(err)
+	suite.Empty(suite.acme.issued)
+}
+
+func TestACMESuite(t *testing.T) {
+	suite.Run(t, new(ACMESuite))
+}
@@ -1,15 +1,27 @@
 package proxy
 
 import (
-	"io"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
 	"net"
 	"net/http"
-	"sync"
+	"net/http/httputil"
+	"strings"
+	"time"
 
 	"github.com/structx/teapot"
 	"go.uber.org/fx"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/internal/certificates"
 	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/routing"
 	"soft.structx.io/dino/sessions"
+	"soft.structx.io/dino/setup"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
 // Handler
@@ -21,11 +33,19 @@ type Handler interface {
 type Params struct {
 	fx.In
 
+	Lc fx.Lifecycle
+
 	Logger *teapot.Logger
 
+	Cfg *setup.Proxy
+
 	Mux sessions.Multiplexer
 
-	RouteService routes.Service
+	RouteService       routes.Service
+	CertificateService certificates.Service
+	ACMECache          autocert.Cache
+
+	Broker pubsub.Broker
 }
 
 // Result
@@ -33,6 +53,9 @@ type Result struct {
 	fx.Out
 
 	Handler http.Handler
+
+	// TLSConfig https proxy config, nil when tls is disabled
+	TLSConfig *tls.Config `name:"proxy_tls"`
 }
 
 type handler struct {
@@ -40,26 +63,130 @@ type handler struct {
 
 	routeSvc routes.Service
 
+	// challenges answers acme http-01 challenges, nil when acme is disabled
+	challenges http.Handler
+
 	mux sessions.Multiplexer
+
+	proxy *httputil.ReverseProxy
+	// transport pools idle sessions per route
+	transport *http.Transport
+}
+
+func newHandler(
+	logger *teapot.Logger,
+	routeSvc routes.Service,
+	mux sessions.Multiplexer,
+	challenges http.Handler,
+	responseHeaderTimeout time.Duration,
+) *handler {
+	h := &handler{
+		log:        logger,
+		routeSvc:   routeSvc,
+		challenges: challenges,
+		mux:        mux,
+	}
+
+	h.transport = &http.Transport{
+		DialContext:           h.dial,
+		ResponseHeaderTimeout: responseHeaderTimeout,
+		MaxIdleConnsPerHost:   16,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	h.proxy = &httputil.ReverseProxy{
+		Rewrite:        h.rewrite,
+		Transport:      h.transport,
+		ModifyResponse: h.modifyResponse,
+		ErrorHandler:   h.proxyError,
+	}
+	mux.OnDrain(h.transport.CloseIdleConnections)
+
+	return h
 }
 
 // Module
 var Module = fx.Module("proxy", fx.Provide(newModule))
 
-func newModule(p Params) Result {
-	return Result{
-		Handler: &handler{
-			log:      p.Logger,
-			routeSvc: p.RouteService,
-			mux:      p.Mux,
-		},
+func newModule(p Params) (Result, error) {
+	var (
+		tlsConfig  *tls.Config
+		challenges http.Handler
+	)
+	if p.Cfg.TLSEnabled {
+		fallback, err := loadFallbackCert(p.Cfg.TLSCertPath, p.Cfg.TLSKeyPath)
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to load default certificate: %w", err)
+		}
+
+		var acmeManager *autocert.Manager
+		if p.Cfg.ACMEEnabled {
+			ai, err := newACMEIssuer(p.Cfg, p.Logger, p.RouteService, p.ACMECache, p.Broker)
+			if err != nil {
+				return Result{}, fmt.Errorf("failed to create acme issuer: %w", err)
+			}
+			p.Lc.Append(fx.Hook{
+				OnStart: ai.start,
+				OnStop:  ai.stop,
+			})
+
+			acmeManager = ai.manager
+			challenges = ai.manager.HTTPHandler(nil)
+		}
+
+		tlsConfig = newTLSConfig(newCertStore(p.Logger, p.CertificateService, acmeManager, fallback))
 	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	up, err := newUDPProxy(ctx, p.Logger, p.Cfg.UDPIdleTimeout, p.RouteService, p.Mux)
+	if err != nil {
+		cancel()
+		return Result{}, fmt.Errorf("failed to create udp proxy: %w", err)
+	}
+	ur := newPortReservations(p.Logger, p.Cfg.Host, protocolUDP, up.bind, p.RouteService, p.Broker)
+
+	tp := newTCPProxy(ctx, p.Logger, p.RouteService, p.Mux)
+	tr := newPortReservations(p.Logger, p.Cfg.Host, protocolTCP, tp.bind, p.RouteService, p.Broker)
+
+	p.Lc.Append(fx.Hook{
+		OnStart: tr.start,
+		OnStop:  tr.stop,
+	})
+	p.Lc.Append(fx.Hook{
+		OnStart: ur.start,
+		OnStop:  ur.stop,
+	})
+	if p.Cfg.PassthroughPort != "" {
+		addr := net.JoinHostPort(p.Cfg.Host, p.Cfg.PassthroughPort)
+		sp := newSNIProxy(ctx, p.Logger, addr, p.Cfg.ReadHeaderTimeout, p.RouteService, p.Mux)
+		p.Lc.Append(fx.Hook{
+			OnStart: sp.start,
+			OnStop:  sp.stop,
+		})
+	}
+
+	p.Lc.Append(fx.Hook{
+		OnStop: func(_ context.Context) error {
+			cancel()
+			return nil
+		},
+	})
+
+	return Result{
+		Handler:   newHandler(p.Logger, p.RouteService, p.Mux, challenges, p.Cfg.ResponseHeaderTimeout),
+		TLSConfig: tlsConfig,
+	}, nil
 }
 
 // ServeHTTP
 func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
+	if h.challenges != nil && isChallenge(r) {
+		h.challenges.ServeHTTP(w, r)
+		return
+	}
+
 	hostname := r.Header.Get("host")
 	if hostname == "" {
 		hostname = r.Host
@@ -75,72 +202,130 @@ func (h *handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// host header carries no port on default http and https ports
 	host, _, err := net.SplitHostPort(hostname)
 	if err != nil {
-		h.log.Error("split host and port", teapot.Error(err))
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+		host = hostname
 	}
 
-	activeUID, err := h.routeSvc.Active(ctx, host)
+	match, err := h.routeSvc.Match(ctx, routes.RouteRequest{
+		Hostname: host,
+		Path:     r.URL.Path,
+		Header:   r.Header,
+	})
 	if err != nil {
-		h.log.Error("active route", teapot.Error(err))
+		h.log.Error("match route", teapot.String("hostname", host), teapot.Error(err))
 		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
 		return
 	}
 
-	hijacker, ok := w.(http.Hijacker)
+	ctx = withRouteTarget(ctx, routeTarget{
+		routeID:     match.RouteID,
+		tunnelUID:   match.TunnelUID,
+		hostname:    host,
+		label:       match.Label,
+		protocol:    match.Protocol,
+		pathPrefix:  match.PathPrefix,
+		stripPrefix: match.StripPrefix,
+		timeouts:    routeTimeouts(match),
+	})
+	ctx = context.WithValue(ctx, controllerKey{}, http.NewResponseController(w))
+	h.proxy.ServeHTTP(w, r.WithContext(ctx))
+}
+
+// controllerKey request context key of the client response controller
+type controllerKey struct{}
+
+// modifyResponse lift the server write timeout for event streams, upgraded
+// connections are hijacked which already clears it
+func (h *handler) modifyResponse(resp *http.Response) error {
+	ct, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if ct != "text/event-stream" {
+		return nil
+	}
+
+	rc, ok := resp.Request.Context().Value(controllerKey{}).(*http.ResponseController)
 	if !ok {
-		h.log.Error("hijacking not supported")
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+		return nil
 	}
 
-	// hijack end user connection
-	conn, buf, err := hijacker.Hijack()
-	if err != nil {
-		h.log.Error("hijacker.Hijack", teapot.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return fmt.Errorf("failed to clear write deadline: %w", err)
 	}
-	defer func() { _ = conn.Close() }()
 
-	rc, wc, cleanup, ok := h.mux.UnarySession(ctx, activeUID)
-	if !ok {
-		h.log.Error("unary session invalid")
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
+	return nil
+}
+
+// rewrite forward request to the matched route keeping the original host
+func (h *handler) rewrite(pr *httputil.ProxyRequest) {
+	target, _ := routeTargetFrom(pr.In.Context())
+
+	// idle connections are pooled by url host, one pool per route and
+	// wildcard label since labels may map to different agent ports
+	pr.Out.URL.Scheme = "http"
+	pr.Out.URL.Host = target.routeID
+	if target.label != "" {
+		pr.Out.URL.Host = target.label + "." + target.routeID
 	}
-	defer cleanup()
+	pr.Out.Host = pr.In.Host
 
-	if err := r.Write(wc); err != nil {
-		h.log.Error("r.Write", teapot.Error(err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		return
+	if target.stripPrefix && target.pathPrefix != "" {
+		pr.Out.URL.Path = routing.StripPrefix(pr.In.URL.Path, target.pathPrefix)
+		pr.Out.URL.RawPath = ""
+		pr.Out.Header.Set("X-Forwarded-Prefix", strings.TrimSuffix(target.pathPrefix, "/"))
 	}
 
-	var wg sync.WaitGroup
+	pr.SetXForwarded()
+}
 
-	wg.Add(2)
-	go func() {
-		defer wg.Done()
-		go func() {
-			if _, err := io.Copy(wc, buf); err != nil {
-				h.log.Error("io.Copy", teapot.Error(err))
-				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-				return
-			}
-		}()
-	}()
-
-	go func() {
-		defer wg.Done()
-		if _, err := io.Copy(conn, rc); err != nil {
-			h.log.Error("io.Copy", teapot.Error(err))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-			return
-		}
-	}()
+// dial implements http.Transport.DialContext.
+func (h *handler) dial(ctx context.Context, _, _ string) (net.Conn, error) {
+	return dialSession(ctx, h.mux)
+}
+
+// proxyError reply with the status of the close reason sent by the agent,
+// 504 when the tunneled backend timed out and 502 otherwise
+func (h *handler) proxyError(w http.ResponseWriter, r *http.Request, err error) {
+	code := http.StatusBadGateway
+
+	var (
+		netErr   net.Error
+		closeErr *tunnelnet.CloseError
+	)
+	if errors.As(err, &closeErr) {
+		code = closeStatus(closeErr.Reason)
+	} else if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		code = http.StatusGatewayTimeout
+	}
+
+	h.log.Error("proxy request",
+		teapot.String("host", r.Host),
+		teapot.Int("status", code),
+		teapot.Error(err))
 
-	wg.Wait()
+	if closeErr != nil {
+		http.Error(w, closeErr.Reason.String(), code)
+		return
+	}
+
+	if errors.Is(err, sessions.ErrTunnelClosed) {
+		// agent dropped mid request, tell the client why
+		http.Error(w, sessions.ErrTunnelClosed.Error(), code)
+		return
+	}
+	w.WriteHeader(code)
+}
+
+// closeStatus http status of a session closed by the agent for reason
+func closeStatus(reason tunnelnet.CloseReason) int {
+	switch reason {
+	case tunnelnet.CloseRouteNotFound:
+		return http.StatusNotFound
+	case tunnelnet.CloseDialTimeout, tunnelnet.CloseIdleTimeout, tunnelnet.CloseMaxLifetime:
+		return http.StatusGatewayTimeout
+	case tunnelnet.ClosePolicyDenied:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadGateway
+	}
 }
@@ -1 +1,327 @@
 package proxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"golang.org/x/net/websocket"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// fakeRoutes every request matches the same route
+type fakeRoutes struct {
+	routes.Service
+
+	match routes.RouteMatch
+}
+
+// Match implements routes.Service.
+func (f fakeRoutes) Match(context.Context, routes.RouteRequest) (routes.RouteMatch, error) {
+	return f.match, nil
+}
+
+// Active implements routes.Service.
+func (f fakeRoutes) Active(context.Context, string, string) (routes.RouteMatch, error) {
+	return f.match, nil
+}
+
+// fakeTunnel serves every session with backend as if the agent dialed it
+type fakeTunnel struct {
+	conns chan net.Conn
+	done  chan struct{}
+	once  sync.Once
+
+	mtx     sync.Mutex
+	open    int
+	hooks   []func()
+	targets []sessions.Target
+}
+
+// interface compliance
+var _ sessions.Multiplexer = (*fakeTunnel)(nil)
+var _ net.Listener = (*fakeTunnel)(nil)
+
+func newFakeTunnel(backend http.Handler) *fakeTunnel {
+	ft := &fakeTunnel{
+		conns: make(chan net.Conn),
+		done:  make(chan struct{}),
+	}
+	go func() { _ = http.Serve(ft, backend) }()
+	return ft
+}
+
+// RegisterTunnel implements sessions.Multiplexer.
+func (f *fakeTunnel) RegisterTunnel(context.Context, tunnelnet.Conn, string, string) (<-chan struct{}, error) {
+	return f.done, nil
+}
+
+// Drain implements sessions.Multiplexer.
+func (f *fakeTunnel) Drain(ctx context.Context) error {
+	f.mtx.Lock()
+	hooks := f.hooks
+	f.mtx.Unlock()
+
+	for _, hook := range hooks {
+		hook()
+	}
+
+	for f.sessions() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond * 10):
+		}
+	}
+	return nil
+}
+
+// OnDrain implements sessions.Multiplexer.
+func (f *fakeTunnel) OnDrain(hook func()) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.hooks = append(f.hooks, hook)
+}
+
+// sessions open sessions
+func (f *fakeTunnel) sessions() int {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.open
+}
+
+// DeregisterTunnel implements sessions.Multiplexer.
+func (f *fakeTunnel) DeregisterTunnel(context.Context, tunnelnet.Conn, string) error { return nil }
+
+// SyncRoutes implements sessions.Multiplexer.
+func (f *fakeTunnel) SyncRoutes(context.Context, tunnelnet.Conn, string) error { return nil }
+
+// UnarySession implements sessions.Multiplexer.
+func (f *fakeTunnel) UnarySession(_ context.Context, target sessions.Target) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool) {
+	server, agent := net.Pipe()
+	select {
+	case f.conns <- agent:
+	case <-f.done:
+		return nil, nil, nil, false
+	}
+
+	f.mtx.Lock()
+	f.open++
+	f.targets = append(f.targets, target)
+	f.mtx.Unlock()
+
+	return server, server, func() {
+		_ = server.Close()
+
+		f.mtx.Lock()
+		f.open--
+		f.mtx.Unlock()
+	}, true
+}
+
+// Accept implements net.Listener.
+func (f *fakeTunnel) Accept() (net.Conn, error) {
+	select {
+	case conn := <-f.conns:
+		return conn, nil
+	case <-f.done:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close implements net.Listener.
+func (f *fakeTunnel) Close() error {
+	f.once.Do(func() { close(f.done) })
+	return nil
+}
+
+// Addr implements net.Listener.
+func (f *fakeTunnel) Addr() net.Addr { return tunnelAddr("fake") }
+
+type HTTPSuite struct {
+	suite.Suite
+}
+
+func (suite *HTTPSuite) serve(backend http.Handler) *httptest.Server {
+	srv, _ := suite.serveRoute(backend, routes.RouteMatch{RouteID: "route", TunnelUID: "tunnel", Protocol: protocolHTTP})
+	return srv
+}
+
+func (suite *HTTPSuite) serveRoute(backend http.Handler, match routes.RouteMatch) (*httptest.Server, *fakeTunnel) {
+	ft := newFakeTunnel(backend)
+	suite.T().Cleanup(func() { _ = ft.Close() })
+
+	srv := httptest.NewServer(newHandler(teapot.New(), fakeRoutes{match: match}, ft, nil, time.Second))
+	suite.T().Cleanup(srv.Close)
+
+	return srv, ft
+}
+
+func (suite *HTTPSuite) TestWebSocketEcho() {
+	srv := suite.serve(websocket.Handler(func(ws *websocket.Conn) {
+		_, _ = io.Copy(ws, ws)
+	}))
+
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/echo"
+	ws, err := websocket.Dial(url, "", srv.URL)
+	suite.Require().NoError(err)
+	defer func() { _ = ws.Close() }()
+
+	for i := range 3 {
+		msg := fmt.Sprintf("hello %d", i)
+		suite.Require().NoError(websocket.Message.Send(ws, msg))
+
+		var reply string
+		suite.Require().NoError(websocket.Message.Receive(ws, &reply))
+		suite.Equal(msg, reply)
+	}
+}
+
+func (suite *HTTPSuite) TestServerSentEvents() {
+	release := make(chan struct{})
+	defer close(release)
+
+	srv := suite.serve(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = io.WriteString(w, "data: first\n\n")
+		http.NewResponseController(w).Flush()
+
+		// keep the stream open until the client saw the first event
+		<-release
+	}))
+
+	resp, err := http.Get(srv.URL + "/events")
+	suite.Require().NoError(err)
+	defer func() { _ = resp.Body.Close() }()
+
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	suite.Require().NoError(err)
+	suite.Equal("data: first\n", line)
+}
+
+func (suite *HTTPSuite) TestStripPrefix() {
+	srv, _ := suite.serveRoute(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, r.URL.Path+" "+r.Header.Get("X-Forwarded-Prefix"))
+	}), routes.RouteMatch{
+		RouteID:     "route",
+		TunnelUID:   "tunnel",
+		PathPrefix:  "/v2/",
+		StripPrefix: true,
+	})
+
+	resp, err := http.Get(srv.URL + "/v2/users")
+	suite.Require().NoError(err)
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	suite.Require().NoError(err)
+	suite.Equal("/users /v2", string(body))
+}
+
+func (suite *HTTPSuite) TestSessionTarget() {
+	srv, ft := suite.serveRoute(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}), routes.RouteMatch{
+		RouteID:     "route",
+		TunnelUID:   "tunnel",
+		Protocol:    "h2c",
+		Label:       "api",
+		IdleTimeout: time.Minute,
+	})
+
+	resp, err := http.Get(srv.URL)
+	suite.Require().NoError(err)
+	defer func() { _ = resp.Body.Close() }()
+	suite.Require().Equal(http.StatusOK, resp.StatusCode)
+
+	host, _, err := net.SplitHostPort(srv.Listener.Addr().String())
+	suite.Require().NoError(err)
+
+	// the session is opened with the protocol of the matched route
+	ft.mtx.Lock()
+	defer ft.mtx.Unlock()
+	suite.Require().Len(ft.targets, 1)
+	suite.Equal(sessions.Target{
+		TunnelUID: "tunnel",
+		RouteID:   "route",
+		Hostname:  host,
+		Protocol:  "h2c",
+		Label:     "api",
+		Timeouts:  tunnelnet.Timeouts{Idle: time.Minute},
+	}, ft.targets[0])
+}
+
+func (suite *HTTPSuite) TestDrainIdleSessions() {
+	srv, ft := suite.serveRoute(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}), routes.RouteMatch{RouteID: "route", TunnelUID: "tunnel"})
+
+	resp, err := http.Get(srv.URL)
+	suite.Require().NoError(err)
+	_, err = io.Copy(io.Discard, resp.Body)
+	suite.Require().NoError(err)
+	suite.Require().NoError(resp.Body.Close())
+
+	// the completed request leaves its session pooled for the next one
+	suite.Equal(1, ft.sessions())
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	suite.NoError(ft.Drain(ctx))
+	suite.Zero(ft.sessions())
+}
+
+func (suite *HTTPSuite) TestGatewayTimeout() {
+	release := make(chan struct{})
+	defer close(release)
+
+	srv := suite.serve(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		<-release
+	}))
+
+	resp, err := http.Get(srv.URL)
+	suite.Require().NoError(err)
+	defer func() { _ = resp.Body.Close() }()
+
+	suite.Equal(http.StatusGatewayTimeout, resp.StatusCode)
+}
+
+func (suite *HTTPSuite) TestCloseReasonStatus() {
+	h := newHandler(teapot.New(teapot.WithWriter(io.Discard)), fakeRoutes{}, &fakeTunnel{}, nil, time.Second)
+
+	tt := []struct {
+		reason tunnelnet.CloseReason
+		code   int
+	}{
+		{tunnelnet.CloseRouteNotFound, http.StatusNotFound},
+		{tunnelnet.CloseDialRefused, http.StatusBadGateway},
+		{tunnelnet.CloseDialTimeout, http.StatusGatewayTimeout},
+		{tunnelnet.CloseBackendReset, http.StatusBadGateway},
+		{tunnelnet.ClosePolicyDenied, http.StatusForbidden},
+		{tunnelnet.CloseInternal, http.StatusBadGateway},
+	}
+	for _, tc := range tt {
+		rec := httptest.NewRecorder()
+		err := fmt.Errorf("read: %w", &tunnelnet.CloseError{Reason: tc.reason, Message: "backend"})
+		h.proxyError(rec, httptest.NewRequest(http.MethodGet, "/", nil), err)
+
+		suite.Equal(tc.code, rec.Code, tc.reason.String())
+		suite.Equal(tc.reason.String()+"\n", rec.Body.String())
+	}
+}
+
+func TestHTTPSuite(t *testing.T) {
+	suite.Run(t, new(HTTPSuite))
+}
@@ -0,0 +1,166 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/structx/teapot"
+	"go.uber.org/multierr"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/pubsub"
+)
+
+// bindFunc open a listener for hostname on addr and start serving it
+type bindFunc func(hostname, addr string) (io.Closer, error)
+
+type reservation struct {
+	port uint32
+	lis  io.Closer
+}
+
+// portReservations keeps a public listener open for every active route of protocol
+type portReservations struct {
+	ctx      context.Context
+	cancelFn context.CancelFunc
+
+	log *teapot.Logger
+
+	host     string
+	protocol string
+	bind     bindFunc
+
+	mtx          sync.Mutex
+	reservations map[string]*reservation
+
+	routeSvc routes.Service
+	broker   pubsub.Broker
+}
+
+func newPortReservations(
+	logger *teapot.Logger,
+	host, protocol string,
+	bind bindFunc,
+	routeSvc routes.Service,
+	broker pubsub.Broker,
+) *portReservations {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &portReservations{
+		ctx:          ctx,
+		cancelFn:     cancel,
+		log:          logger,
+		host:         host,
+		protocol:     protocol,
+		bind:         bind,
+		mtx:          sync.Mutex{},
+		reservations: map[string]*reservation{},
+		routeSvc:     routeSvc,
+		broker:       broker,
+	}
+}
+
+func (p *portReservations) start(ctx context.Context) error {
+	rs, err := p.routeSvc.Listeners(ctx, p.protocol)
+	if err != nil {
+		return fmt.Errorf("failed to list %s routes: %w", p.protocol, err)
+	}
+
+	for _, r := range rs {
+		if err := p.reserve(r.Hostname, r.PublicPort); err != nil {
+			p.log.Error("reserve route port", teapot.String("hostname", r.Hostname), teapot.Error(err))
+		}
+	}
+
+	ch := p.broker.Subscribe("dino.routes")
+	go p.subscription(ch)
+
+	return nil
+}
+
+func (p *portReservations) stop(_ context.Context) error {
+	p.cancelFn()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	var result error
+	for hostname, r := range p.reservations {
+		if err := r.lis.Close(); err != nil {
+			result = multierr.Append(result, fmt.Errorf("failed to close listener %s: %w", hostname, err))
+		}
+		delete(p.reservations, hostname)
+	}
+
+	return result
+}
+
+func (p *portReservations) subscription(ch chan string) {
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case msg := <-ch:
+			rcfg, err := pubsub.DecodeRouteConfig(msg)
+			if err != nil {
+				p.log.Error("decode route config", teapot.Error(err))
+				continue
+			}
+
+			if rcfg.IsDelete || rcfg.DestProtocol != p.protocol || rcfg.PublicPort == 0 {
+				p.release(rcfg.Hostname)
+				continue
+			}
+
+			if err := p.reserve(rcfg.Hostname, rcfg.PublicPort); err != nil {
+				p.log.Error("reserve route port", teapot.String("hostname", rcfg.Hostname), teapot.Error(err))
+			}
+		}
+	}
+}
+
+// reserve public port for hostname, replacing a previous reservation
+func (p *portReservations) reserve(hostname string, port uint32) error {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if r, ok := p.reservations[hostname]; ok {
+		if r.port == port {
+			return nil
+		}
+		_ = r.lis.Close()
+		delete(p.reservations, hostname)
+	}
+
+	addr := net.JoinHostPort(p.host, strconv.FormatUint(uint64(port), 10))
+	lis, err := p.bind(hostname, addr)
+	if err != nil {
+		return fmt.Errorf("bind %s: %w", addr, err)
+	}
+	p.reservations[hostname] = &reservation{port: port, lis: lis}
+
+	p.log.Info("start route listener",
+		teapot.String("protocol", p.protocol),
+		teapot.String("hostname", hostname),
+		teapot.String("addr", addr))
+
+	return nil
+}
+
+func (p *portReservations) release(hostname string) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	r, ok := p.reservations[hostname]
+	if !ok {
+		return
+	}
+	delete(p.reservations, hostname)
+
+	p.log.Info("close route listener", teapot.String("protocol", p.protocol), teapot.String("hostname", hostname))
+	if err := r.lis.Close(); err != nil {
+		p.log.Error("close route listener", teapot.Error(err))
+	}
+}
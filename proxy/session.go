@@ -0,0 +1,128 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// errSessionInvalid tunnel of the route is not connected
+var errSessionInvalid = errors.New("unary session invalid")
+
+// routeKey request context key of the matched route target
+type routeKey struct{}
+
+// routeTarget matched route a request is forwarded to
+type routeTarget struct {
+	routeID   string
+	tunnelUID string
+	hostname  string
+	label     string
+	// protocol destination protocol of the route
+	protocol string
+
+	pathPrefix  string
+	stripPrefix bool
+
+	timeouts tunnelnet.Timeouts
+}
+
+func withRouteTarget(ctx context.Context, target routeTarget) context.Context {
+	return context.WithValue(ctx, routeKey{}, target)
+}
+
+func routeTargetFrom(ctx context.Context) (routeTarget, bool) {
+	target, ok := ctx.Value(routeKey{}).(routeTarget)
+	return target, ok
+}
+
+// routeTimeouts session limits configured on the route, zero limits are
+// filled with the server defaults when the session opens
+func routeTimeouts(match routes.RouteMatch) tunnelnet.Timeouts {
+	return tunnelnet.Timeouts{
+		Idle:        match.IdleTimeout,
+		MaxLifetime: match.MaxLifetime,
+	}
+}
+
+// tunnelAddr address of a route reached through a tunnel
+type tunnelAddr string
+
+// Network implements net.Addr.
+func (a tunnelAddr) Network() string { return "tunnel" }
+
+// String implements net.Addr.
+func (a tunnelAddr) String() string { return string(a) }
+
+// sessionConn net.Conn over a tunnel session
+type sessionConn struct {
+	rc      tunnelnet.ReadCloser
+	wc      tunnelnet.WriteCloser
+	cleanup func()
+
+	addr      tunnelAddr
+	closeOnce sync.Once
+}
+
+// interface compliance
+var _ net.Conn = (*sessionConn)(nil)
+
+// dialSession open a tunnel session for the route target stored in ctx
+func dialSession(ctx context.Context, mux sessions.Multiplexer) (net.Conn, error) {
+	target, ok := routeTargetFrom(ctx)
+	if !ok {
+		return nil, errors.New("missing route target")
+	}
+
+	rc, wc, cleanup, ok := mux.UnarySession(ctx, sessions.Target{
+		TunnelUID: target.tunnelUID,
+		RouteID:   target.routeID,
+		Hostname:  target.hostname,
+		Protocol:  target.protocol,
+		Label:     target.label,
+		Timeouts:  target.timeouts,
+	})
+	if !ok {
+		return nil, errSessionInvalid
+	}
+
+	return &sessionConn{
+		rc:      rc,
+		wc:      wc,
+		cleanup: cleanup,
+		addr:    tunnelAddr(target.hostname),
+	}, nil
+}
+
+// Read implements net.Conn.
+func (s *sessionConn) Read(b []byte) (int, error) { return s.rc.Read(b) }
+
+// Write implements net.Conn.
+func (s *sessionConn) Write(b []byte) (int, error) { return s.wc.Write(b) }
+
+// Close implements net.Conn.
+func (s *sessionConn) Close() error {
+	s.closeOnce.Do(s.cleanup)
+	return nil
+}
+
+// LocalAddr implements net.Conn.
+func (s *sessionConn) LocalAddr() net.Addr { return s.addr }
+
+// RemoteAddr implements net.Conn.
+func (s *sessionConn) RemoteAddr() net.Addr { return s.addr }
+
+// SetDeadline implements net.Conn.
+func (s *sessionConn) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn.
+func (s *sessionConn) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn.
+func (s *sessionConn) SetWriteDeadline(time.Time) error { return nil }
@@ -0,0 +1,181 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+)
+
+const protocolHTTPS = "https"
+
+// errHelloRead aborts the handshake once the client hello was parsed
+var errHelloRead = errors.New("client hello read")
+
+// sniProxy forwards tls connections to the route matching their SNI without decrypting them
+type sniProxy struct {
+	ctx context.Context
+
+	log *teapot.Logger
+
+	addr         string
+	helloTimeout time.Duration
+	routeSvc     routes.Service
+	mux          sessions.Multiplexer
+	lis          net.Listener
+}
+
+func newSNIProxy(
+	ctx context.Context,
+	logger *teapot.Logger,
+	addr string,
+	helloTimeout time.Duration,
+	routeSvc routes.Service,
+	mux sessions.Multiplexer,
+) *sniProxy {
+	return &sniProxy{
+		ctx:          ctx,
+		log:          logger,
+		addr:         addr,
+		helloTimeout: helloTimeout,
+		routeSvc:     routeSvc,
+		mux:          mux,
+	}
+}
+
+func (s *sniProxy) start(_ context.Context) error {
+	lis, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("net.Listen: %w", err)
+	}
+	s.lis = lis
+
+	s.log.Info("start tls passthrough listener", teapot.String("addr", s.addr))
+	go s.serve()
+
+	return nil
+}
+
+func (s *sniProxy) stop(_ context.Context) error {
+	if err := s.lis.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+		return fmt.Errorf("failed to close tls passthrough listener: %w", err)
+	}
+	return nil
+}
+
+func (s *sniProxy) serve() {
+	for {
+		conn, err := s.lis.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.log.Error("accept tls conn", teapot.Error(err))
+			continue
+		}
+
+		go s.handleConn(conn)
+	}
+}
+
+func (s *sniProxy) handleConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	_ = conn.SetReadDeadline(time.Now().Add(s.helloTimeout))
+	hostname, peeked, err := peekServerName(conn)
+	if err != nil {
+		s.log.Error("peek client hello", teapot.Error(err))
+		return
+	}
+	_ = conn.SetReadDeadline(time.Time{})
+
+	active, err := s.routeSvc.Active(s.ctx, hostname, protocolHTTPS)
+	if err != nil {
+		s.log.Error("active route", teapot.String("hostname", hostname), teapot.Error(err))
+		return
+	}
+
+	rc, wc, cleanup, ok := s.mux.UnarySession(s.ctx, sessions.Target{
+		TunnelUID: active.TunnelUID,
+		Hostname:  hostname,
+		Protocol:  protocolHTTPS,
+		Timeouts:  routeTimeouts(active),
+	})
+	if !ok {
+		s.log.Error("unary session invalid", teapot.String("hostname", hostname))
+		return
+	}
+	defer cleanup()
+
+	// replay the client hello before piping the rest of the connection
+	if _, err := wc.Write(peeked); err != nil {
+		s.log.Error("write client hello", teapot.Error(err))
+		return
+	}
+
+	if err := pipe(conn, rc, wc); err != nil && !errors.Is(err, net.ErrClosed) {
+		s.log.Error("pipe tls conn", teapot.Error(err))
+	}
+}
+
+// peekServerName read the client hello of conn and return its SNI with every byte consumed
+func peekServerName(conn net.Conn) (string, []byte, error) {
+	var peeked bytes.Buffer
+	var hello *tls.ClientHelloInfo
+
+	err := tls.Server(readOnlyConn{r: io.TeeReader(conn, &peeked)}, &tls.Config{
+		GetConfigForClient: func(chi *tls.ClientHelloInfo) (*tls.Config, error) {
+			hello = chi
+			return nil, errHelloRead
+		},
+	}).Handshake()
+	if hello == nil {
+		return "", nil, fmt.Errorf("failed to read client hello: %w", err)
+	}
+
+	hostname := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+	if hostname == "" {
+		return "", nil, errors.New("client hello without server name")
+	}
+
+	return hostname, peeked.Bytes(), nil
+}
+
+// readOnlyConn feeds the handshake while discarding anything it writes back
+type readOnlyConn struct {
+	net.Conn
+	r io.Reader
+}
+
+// Read implements net.Conn.
+func (c readOnlyConn) Read(p []byte) (int, error) { return c.r.Read(p) }
+
+// Write implements net.Conn.
+func (c readOnlyConn) Write(p []byte) (int, error) { return 0, io.ErrClosedPipe }
+
+// Close implements net.Conn.
+func (c readOnlyConn) Close() error { return nil }
+
+// SetDeadline implements net.Conn.
+func (c readOnlyConn) SetDeadline(time.Time) error { return nil }
+
+// SetReadDeadline implements net.Conn.
+func (c readOnlyConn) SetReadDeadline(time.Time) error { return nil }
+
+// SetWriteDeadline implements net.Conn.
+func (c readOnlyConn) SetWriteDeadline(time.Time) error { return nil }
+
+// LocalAddr implements net.Conn.
+func (c readOnlyConn) LocalAddr() net.Addr { return nil }
+
+// RemoteAddr implements net.Conn.
+func (c readOnlyConn) RemoteAddr() net.Addr { return nil }
@@ -0,0 +1,208 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+)
+
+// hostRoutes only hostname has an active https route
+type hostRoutes struct {
+	routes.Service
+
+	hostname string
+	match    routes.RouteMatch
+}
+
+// Active implements routes.Service.
+func (h hostRoutes) Active(_ context.Context, hostname, protocol string) (routes.RouteMatch, error) {
+	if hostname != h.hostname || protocol != protocolHTTPS {
+		return routes.RouteMatch{}, routes.ErrNoMatch
+	}
+	return h.match, nil
+}
+
+// recordedConn keeps a copy of every byte read from or written to conn
+type recordedConn struct {
+	net.Conn
+
+	mtx     sync.Mutex
+	read    bytes.Buffer
+	written bytes.Buffer
+}
+
+// Read implements net.Conn.
+func (r *recordedConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	r.mtx.Lock()
+	r.read.Write(p[:n])
+	r.mtx.Unlock()
+	return n, err
+}
+
+// Write implements net.Conn.
+func (r *recordedConn) Write(p []byte) (int, error) {
+	r.mtx.Lock()
+	r.written.Write(p)
+	r.mtx.Unlock()
+	return r.Conn.Write(p)
+}
+
+func (r *recordedConn) bytes() (read, written []byte) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return bytes.Clone(r.read.Bytes()), bytes.Clone(r.written.Bytes())
+}
+
+type SNISuite struct {
+	suite.Suite
+
+	tunnel *backendTunnel
+
+	// backend tls server conns as received through the tunnel
+	mtx      sync.Mutex
+	backends []*recordedConn
+
+	addr string
+}
+
+func (suite *SNISuite) SetupTest() {
+	suite.backends = nil
+	cert := suite.certificate("app.dino.local")
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	// tls echo backend, the handshake only succeeds on an unaltered stream
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			rc := &recordedConn{Conn: conn}
+			suite.mtx.Lock()
+			suite.backends = append(suite.backends, rc)
+			suite.mtx.Unlock()
+
+			go func() {
+				defer func() { _ = conn.Close() }()
+				srv := tls.Server(rc, &tls.Config{Certificates: []tls.Certificate{cert}})
+				_, _ = io.Copy(srv, srv)
+			}()
+		}
+	}()
+
+	suite.tunnel = &backendTunnel{backend: lis.Addr().String()}
+	sp := newSNIProxy(context.Background(), teapot.New(teapot.WithWriter(io.Discard)), "127.0.0.1:0", time.Second,
+		hostRoutes{hostname: "app.dino.local", match: routes.RouteMatch{TunnelUID: "tunnel"}}, suite.tunnel)
+	suite.Require().NoError(sp.start(context.Background()))
+	suite.T().Cleanup(func() { _ = sp.stop(context.Background()) })
+
+	suite.addr = sp.lis.Addr().String()
+}
+
+// certificate self signed leaf of hostname
+func (suite *SNISuite) certificate(hostname string) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: hostname},
+		DNSNames:     []string{hostname},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	suite.Require().NoError(err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// dial tls client of passthrough listener with serverName as SNI
+func (suite *SNISuite) dial(serverName string) (*tls.Conn, *recordedConn) {
+	conn, err := net.Dial("tcp", suite.addr)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = conn.Close() })
+	suite.Require().NoError(conn.SetDeadline(time.Now().Add(5 * time.Second)))
+
+	rc := &recordedConn{Conn: conn}
+	// #nosec G402 -- the backend certificate is self signed
+	return tls.Client(rc, &tls.Config{ServerName: serverName, InsecureSkipVerify: true}), rc
+}
+
+func (suite *SNISuite) TestForwardUnchanged() {
+	client, rc := suite.dial("App.Dino.Local.")
+
+	suite.Require().NoError(client.Handshake())
+	_, err := io.WriteString(client, "hello")
+	suite.Require().NoError(err)
+
+	reply := make([]byte, len("hello"))
+	_, err = io.ReadFull(client, reply)
+	suite.Require().NoError(err)
+	suite.Equal("hello", string(reply))
+
+	suite.mtx.Lock()
+	suite.Require().Len(suite.backends, 1)
+	backend := suite.backends[0]
+	suite.mtx.Unlock()
+
+	// every byte the client sent, client hello included, reached the backend as is
+	_, sent := rc.bytes()
+	suite.Eventually(func() bool {
+		received, _ := backend.bytes()
+		return bytes.Equal(sent, received)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	// and every byte the backend sent reached the client as is
+	suite.Eventually(func() bool {
+		_, backendSent := backend.bytes()
+		clientReceived, _ := rc.bytes()
+		return bytes.Equal(backendSent, clientReceived)
+	}, 2*time.Second, 10*time.Millisecond)
+
+	suite.tunnel.mtx.Lock()
+	defer suite.tunnel.mtx.Unlock()
+	suite.Require().Len(suite.tunnel.targets, 1)
+	suite.Equal(sessions.Target{TunnelUID: "tunnel", Hostname: "app.dino.local", Protocol: protocolHTTPS}, suite.tunnel.targets[0])
+}
+
+func (suite *SNISuite) TestUnknownServerName() {
+	for _, serverName := range []string{"unknown.dino.local", ""} {
+		client, _ := suite.dial(serverName)
+		suite.Error(client.Handshake(), serverName)
+	}
+
+	suite.tunnel.mtx.Lock()
+	defer suite.tunnel.mtx.Unlock()
+	suite.Empty(suite.tunnel.targets)
+
+	suite.mtx.Lock()
+	defer suite.mtx.Unlock()
+	suite.Empty(suite.backends)
+}
+
+func TestSNISuite(t *testing.T) {
+	suite.Run(t, new(SNISuite))
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+const protocolTCP = "tcp"
+
+// tcpProxy forwards raw connections accepted on route public ports
+type tcpProxy struct {
+	ctx context.Context
+
+	log *teapot.Logger
+
+	routeSvc routes.Service
+	mux      sessions.Multiplexer
+}
+
+func newTCPProxy(ctx context.Context, logger *teapot.Logger, routeSvc routes.Service, mux sessions.Multiplexer) *tcpProxy {
+	return &tcpProxy{
+		ctx:      ctx,
+		log:      logger,
+		routeSvc: routeSvc,
+		mux:      mux,
+	}
+}
+
+// bind implements bindFunc.
+func (t *tcpProxy) bind(hostname, addr string) (io.Closer, error) {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("net.Listen: %w", err)
+	}
+
+	go t.serve(hostname, lis)
+
+	return lis, nil
+}
+
+func (t *tcpProxy) serve(hostname string, lis net.Listener) {
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			t.log.Error("accept tcp conn", teapot.Error(err))
+			continue
+		}
+
+		go t.handleConn(hostname, conn)
+	}
+}
+
+func (t *tcpProxy) handleConn(hostname string, conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	active, err := t.routeSvc.Active(t.ctx, hostname, protocolTCP)
+	if err != nil {
+		t.log.Error("active route", teapot.String("hostname", hostname), teapot.Error(err))
+		return
+	}
+
+	rc, wc, cleanup, ok := t.mux.UnarySession(t.ctx, sessions.Target{
+		TunnelUID: active.TunnelUID,
+		Hostname:  hostname,
+		Protocol:  protocolTCP,
+		Timeouts:  routeTimeouts(active),
+	})
+	if !ok {
+		t.log.Error("unary session invalid", teapot.String("hostname", hostname))
+		return
+	}
+	defer cleanup()
+
+	if err := pipe(conn, rc, wc); err != nil && !errors.Is(err, net.ErrClosed) {
+		t.log.Error("pipe tcp conn", teapot.Error(err))
+	}
+}
+
+// pipe copy bytes in both directions until both sides finished writing or
+// a copy failed, a side that finished writing is half closed so the other
+// can still answer
+func pipe(conn net.Conn, rc io.Reader, wc io.Writer) error {
+	errCh := make(chan error, 2)
+
+	go func() {
+		_, err := io.Copy(wc, conn)
+		if err == nil {
+			err = tunnelnet.CloseWrite(wc)
+		}
+		errCh <- err
+	}()
+
+	go func() {
+		_, err := io.Copy(conn, rc)
+		if err == nil {
+			err = tunnelnet.CloseWrite(conn)
+		}
+		errCh <- err
+	}()
+
+	for range 2 {
+		if err := <-errCh; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// backendTunnel serves every session with a tcp conn to backend as if the
+// agent dialed it, half closes travel through like end of stream frames
+type backendTunnel struct {
+	sessions.Multiplexer
+
+	backend string
+
+	mtx     sync.Mutex
+	targets []sessions.Target
+}
+
+// UnarySession implements sessions.Multiplexer.
+func (b *backendTunnel) UnarySession(_ context.Context, target sessions.Target) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool) {
+	conn, err := net.Dial("tcp", b.backend)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+
+	b.mtx.Lock()
+	b.targets = append(b.targets, target)
+	b.mtx.Unlock()
+
+	return conn, conn, func() { _ = conn.Close() }, true
+}
+
+type TCPSuite struct {
+	suite.Suite
+
+	tunnel *backendTunnel
+}
+
+// listen tcp backend serving every conn with handle
+func (suite *TCPSuite) listen(handle func(net.Conn)) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = conn.Close() }()
+				handle(conn)
+			}()
+		}
+	}()
+	return lis.Addr().String()
+}
+
+// proxy public port of a tcp route forwarding to handle
+func (suite *TCPSuite) proxy(handle func(net.Conn)) string {
+	suite.tunnel = &backendTunnel{backend: suite.listen(handle)}
+
+	tp := newTCPProxy(context.Background(), teapot.New(teapot.WithWriter(io.Discard)),
+		fakeRoutes{match: routes.RouteMatch{TunnelUID: "tunnel"}}, suite.tunnel)
+
+	lis, err := tp.bind("db.dino.local", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	return lis.(net.Listener).Addr().String()
+}
+
+func (suite *TCPSuite) TestForward() {
+	addr := suite.proxy(func(conn net.Conn) {
+		_, _ = io.Copy(conn, conn)
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	suite.Require().NoError(err)
+	defer func() { _ = conn.Close() }()
+	suite.Require().NoError(conn.SetDeadline(time.Now().Add(5 * time.Second)))
+
+	for _, msg := range []string{"hello", "world"} {
+		_, err := io.WriteString(conn, msg)
+		suite.Require().NoError(err)
+
+		reply := make([]byte, len(msg))
+		_, err = io.ReadFull(conn, reply)
+		suite.Require().NoError(err)
+		suite.Equal(msg, string(reply))
+	}
+
+	suite.tunnel.mtx.Lock()
+	defer suite.tunnel.mtx.Unlock()
+	suite.Require().Len(suite.tunnel.targets, 1)
+	suite.Equal(sessions.Target{TunnelUID: "tunnel", Hostname: "db.dino.local", Protocol: protocolTCP}, suite.tunnel.targets[0])
+}
+
+func (suite *TCPSuite) TestHalfClose() {
+	// the backend answers only once the client finished writing
+	addr := suite.proxy(func(conn net.Conn) {
+		request, err := io.ReadAll(conn)
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(conn, "received "+string(request))
+	})
+
+	conn, err := net.Dial("tcp", addr)
+	suite.Require().NoError(err)
+	defer func() { _ = conn.Close() }()
+	suite.Require().NoError(conn.SetDeadline(time.Now().Add(5 * time.Second)))
+
+	_, err = io.WriteString(conn, "request")
+	suite.Require().NoError(err)
+	suite.Require().NoError(conn.(*net.TCPConn).CloseWrite())
+
+	reply, err := io.ReadAll(conn)
+	suite.Require().NoError(err)
+	suite.Equal("received request", string(reply))
+}
+
+func TestTCPSuite(t *testing.T) {
+	suite.Run(t, new(TCPSuite))
+}
@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/structx/teapot"
+	"golang.org/x/crypto/acme/autocert"
+	"soft.structx.io/dino/internal/certificates"
+)
+
+// certCacheTTL duration a looked up certificate is served before reloading
+const certCacheTTL = time.Minute
+
+type cachedCert struct {
+	cert    *tls.Certificate
+	expires time.Time
+}
+
+// certStore select route certificates by SNI
+type certStore struct {
+	log *teapot.Logger
+
+	certSvc  certificates.Service
+	acme     *autocert.Manager // nil when acme is disabled
+	fallback *tls.Certificate
+
+	mtx   sync.RWMutex
+	cache map[string]cachedCert
+}
+
+func newCertStore(
+	logger *teapot.Logger,
+	certSvc certificates.Service,
+	acmeManager *autocert.Manager,
+	fallback *tls.Certificate,
+) *certStore {
+	return &certStore{
+		log:      logger,
+		certSvc:  certSvc,
+		acme:     acmeManager,
+		fallback: fallback,
+		mtx:      sync.RWMutex{},
+		cache:    map[string]cachedCert{},
+	}
+}
+
+// GetCertificate implements tls.Config.GetCertificate
+func (c *certStore) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	hostname := strings.ToLower(strings.TrimSuffix(hello.ServerName, "."))
+
+	if hostname != "" {
+		cert, err := c.lookup(hello.Context(), hostname)
+		if err != nil {
+			c.log.Error("lookup route certificate", teapot.String("hostname", hostname), teapot.Error(err))
+		} else if cert != nil {
+			return cert, nil
+		}
+	}
+
+	if hostname != "" && c.acme != nil {
+		cert, err := c.acme.GetCertificate(hello)
+		if err == nil {
+			return cert, nil
+		}
+		c.log.Debug("acme certificate", teapot.String("hostname", hostname), teapot.Error(err))
+	}
+
+	if c.fallback != nil {
+		return c.fallback, nil
+	}
+
+	return nil, fmt.Errorf("no certificate for hostname %q", hostname)
+}
+
+// lookup cached route certificate, nil when route has none
+func (c *certStore) lookup(ctx context.Context, hostname string) (*tls.Certificate, error) {
+	now := time.Now()
+
+	c.mtx.RLock()
+	cached, ok := c.cache[hostname]
+	c.mtx.RUnlock()
+	if ok && now.Before(cached.expires) {
+		return cached.cert, nil
+	}
+
+	cert, err := c.certSvc.Lookup(ctx, hostname)
+	if err != nil && !errors.Is(err, certificates.ErrCertificateNotFound) {
+		return nil, err
+	}
+
+	// cache misses as well to keep unknown hostnames off the database
+	c.mtx.Lock()
+	c.cache[hostname] = cachedCert{cert: cert, expires: now.Add(certCacheTTL)}
+	c.mtx.Unlock()
+
+	return cert, nil
+}
+
+func newTLSConfig(store *certStore) *tls.Config {
+	return &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: store.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+}
+
+func loadFallbackCert(certFile, keyFile string) (*tls.Certificate, error) {
+	if certFile == "" || keyFile == "" {
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("tls.LoadX509KeyPair: %w", err)
+	}
+
+	return &cert, nil
+}
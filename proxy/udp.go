@@ -0,0 +1,258 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+const (
+	protocolUDP = "udp"
+
+	// maxDatagramSize largest udp payload
+	maxDatagramSize = 64 * 1024
+)
+
+// udpSession tunnel session bound to a single source address
+type udpSession struct {
+	rc       tunnelnet.ReadCloser
+	wc       tunnelnet.WriteCloser
+	cleanup  func()
+	lastSeen atomic.Int64
+	close    sync.Once
+}
+
+func (u *udpSession) touch() {
+	u.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (u *udpSession) idle(now time.Time, timeout time.Duration) bool {
+	return now.Sub(time.Unix(0, u.lastSeen.Load())) > timeout
+}
+
+func (u *udpSession) release() {
+	u.close.Do(u.cleanup)
+}
+
+// udpListener public packet conn with its per source sessions
+type udpListener struct {
+	hostname string
+	pc       net.PacketConn
+
+	mtx      sync.Mutex
+	sessions map[string]*udpSession
+
+	done chan struct{}
+}
+
+// Close implements io.Closer.
+func (u *udpListener) Close() error {
+	close(u.done)
+	err := u.pc.Close()
+
+	u.mtx.Lock()
+	defer u.mtx.Unlock()
+	for addr, s := range u.sessions {
+		s.release()
+		delete(u.sessions, addr)
+	}
+
+	return err
+}
+
+// udpProxy forwards datagrams received on route public ports
+type udpProxy struct {
+	ctx context.Context
+
+	log *teapot.Logger
+
+	idleTimeout time.Duration
+
+	routeSvc routes.Service
+	mux      sessions.Multiplexer
+}
+
+func newUDPProxy(
+	ctx context.Context,
+	logger *teapot.Logger,
+	idleTimeout time.Duration,
+	routeSvc routes.Service,
+	mux sessions.Multiplexer,
+) (*udpProxy, error) {
+	// sessions are reaped every half idle timeout
+	if idleTimeout <= 0 {
+		return nil, fmt.Errorf("udp idle timeout must be positive: %s", idleTimeout)
+	}
+
+	return &udpProxy{
+		ctx:         ctx,
+		log:         logger,
+		idleTimeout: idleTimeout,
+		routeSvc:    routeSvc,
+		mux:         mux,
+	}, nil
+}
+
+// bind implements bindFunc.
+func (u *udpProxy) bind(hostname, addr string) (io.Closer, error) {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("net.ListenPacket: %w", err)
+	}
+
+	ul := &udpListener{
+		hostname: hostname,
+		pc:       pc,
+		mtx:      sync.Mutex{},
+		sessions: map[string]*udpSession{},
+		done:     make(chan struct{}),
+	}
+
+	go u.serve(ul)
+	go u.reap(ul)
+
+	return ul, nil
+}
+
+func (u *udpProxy) serve(ul *udpListener) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, src, err := ul.pc.ReadFrom(buf)
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			u.log.Error("read udp packet", teapot.Error(err))
+			continue
+		}
+
+		s, err := u.session(ul, src)
+		if err != nil {
+			u.log.Error("udp session", teapot.String("hostname", ul.hostname), teapot.Error(err))
+			continue
+		}
+		s.touch()
+
+		// tunnel frames keep a reference to the payload
+		pkt := make([]byte, n)
+		copy(pkt, buf[:n])
+
+		if _, err := s.wc.Write(pkt); err != nil {
+			u.log.Error("write udp packet", teapot.Error(err))
+			u.drop(ul, src.String(), s)
+		}
+	}
+}
+
+// session find or open the tunnel session for source address, the route
+// lookup and tunnel round trip run without holding the listener lock
+func (u *udpProxy) session(ul *udpListener, src net.Addr) (*udpSession, error) {
+	ul.mtx.Lock()
+	s, ok := ul.sessions[src.String()]
+	ul.mtx.Unlock()
+	if ok {
+		return s, nil
+	}
+
+	active, err := u.routeSvc.Active(u.ctx, ul.hostname, protocolUDP)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find active route: %w", err)
+	}
+
+	rc, wc, cleanup, ok := u.mux.UnarySession(u.ctx, sessions.Target{
+		TunnelUID: active.TunnelUID,
+		Hostname:  ul.hostname,
+		Protocol:  protocolUDP,
+		Timeouts:  routeTimeouts(active),
+	})
+	if !ok {
+		return nil, errors.New("unary session invalid")
+	}
+	s = &udpSession{rc: rc, wc: wc, cleanup: cleanup}
+
+	ul.mtx.Lock()
+	defer ul.mtx.Unlock()
+
+	select {
+	case <-ul.done:
+		s.release()
+		return nil, net.ErrClosed
+	default:
+	}
+
+	// source address was assigned a session while this one was opened
+	if current, ok := ul.sessions[src.String()]; ok {
+		s.release()
+		return current, nil
+	}
+	ul.sessions[src.String()] = s
+
+	go u.reply(ul, src, s)
+
+	return s, nil
+}
+
+// reply write datagrams received from the tunnel back to source address
+func (u *udpProxy) reply(ul *udpListener, src net.Addr, s *udpSession) {
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, err := s.rc.Read(buf)
+		if err != nil {
+			u.drop(ul, src.String(), s)
+			return
+		}
+		s.touch()
+
+		if _, err := ul.pc.WriteTo(buf[:n], src); err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			u.log.Error("write udp reply", teapot.Error(err))
+		}
+	}
+}
+
+// reap close sessions without traffic for longer than idle timeout
+func (u *udpProxy) reap(ul *udpListener) {
+	ticker := time.NewTicker(u.idleTimeout / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ul.done:
+			return
+		case now := <-ticker.C:
+			ul.mtx.Lock()
+			for addr, s := range ul.sessions {
+				if s.idle(now, u.idleTimeout) {
+					u.log.Debug("expire udp session", teapot.String("src", addr))
+					s.release()
+					delete(ul.sessions, addr)
+				}
+			}
+			ul.mtx.Unlock()
+		}
+	}
+}
+
+// drop release session unless source address was already assigned a new one
+func (u *udpProxy) drop(ul *udpListener, addr string, s *udpSession) {
+	s.release()
+
+	ul.mtx.Lock()
+	defer ul.mtx.Unlock()
+
+	if current, ok := ul.sessions[addr]; ok && current == s {
+		delete(ul.sessions, addr)
+	}
+}
@@ -0,0 +1,170 @@
+package proxy
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/internal/routes"
+	"soft.structx.io/dino/sessions"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// datagramTunnel serves every session with a udp conn to backend as if the
+// agent dialed it, a connected udp conn keeps datagram boundaries
+type datagramTunnel struct {
+	sessions.Multiplexer
+
+	backend string
+
+	mtx      sync.Mutex
+	targets  []sessions.Target
+	released int
+}
+
+// UnarySession implements sessions.Multiplexer.
+func (d *datagramTunnel) UnarySession(_ context.Context, target sessions.Target) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool) {
+	conn, err := net.Dial("udp", d.backend)
+	if err != nil {
+		return nil, nil, nil, false
+	}
+
+	d.mtx.Lock()
+	d.targets = append(d.targets, target)
+	d.mtx.Unlock()
+
+	return conn, conn, func() {
+		_ = conn.Close()
+
+		d.mtx.Lock()
+		d.released++
+		d.mtx.Unlock()
+	}, true
+}
+
+func (d *datagramTunnel) counts() (opened, released int) {
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	return len(d.targets), d.released
+}
+
+type UDPSuite struct {
+	suite.Suite
+
+	tunnel *datagramTunnel
+}
+
+// echo udp backend answering every datagram with its payload
+func (suite *UDPSuite) echo() string {
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = pc.Close() })
+
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, src, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			_, _ = pc.WriteTo(buf[:n], src)
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+// proxy public port of a udp route forwarding to an echo backend
+func (suite *UDPSuite) proxy(idleTimeout time.Duration) *udpListener {
+	suite.tunnel = &datagramTunnel{backend: suite.echo()}
+
+	up, err := newUDPProxy(context.Background(), teapot.New(teapot.WithWriter(io.Discard)), idleTimeout,
+		fakeRoutes{match: routes.RouteMatch{TunnelUID: "tunnel"}}, suite.tunnel)
+	suite.Require().NoError(err)
+
+	lis, err := up.bind("dns.dino.local", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	return lis.(*udpListener)
+}
+
+// dial client socket of proxy public port
+func (suite *UDPSuite) dial(ul *udpListener) net.Conn {
+	conn, err := net.Dial("udp", ul.pc.LocalAddr().String())
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = conn.Close() })
+	suite.Require().NoError(conn.SetDeadline(time.Now().Add(5 * time.Second)))
+	return conn
+}
+
+func (suite *UDPSuite) TestIdleTimeoutRequired() {
+	for _, idleTimeout := range []time.Duration{0, -time.Second} {
+		_, err := newUDPProxy(context.Background(), teapot.New(teapot.WithWriter(io.Discard)), idleTimeout,
+			fakeRoutes{}, &datagramTunnel{})
+		suite.Error(err, idleTimeout)
+	}
+}
+
+func (suite *UDPSuite) TestRoundTrip() {
+	ul := suite.proxy(time.Minute)
+
+	// every source address gets its own session
+	for _, client := range []net.Conn{suite.dial(ul), suite.dial(ul)} {
+		for _, msg := range []string{"hello", "world"} {
+			_, err := io.WriteString(client, msg)
+			suite.Require().NoError(err)
+
+			reply := make([]byte, maxDatagramSize)
+			n, err := client.Read(reply)
+			suite.Require().NoError(err)
+			suite.Equal(msg, string(reply[:n]))
+		}
+	}
+
+	opened, released := suite.tunnel.counts()
+	suite.Equal(2, opened)
+	suite.Equal(0, released)
+
+	suite.tunnel.mtx.Lock()
+	defer suite.tunnel.mtx.Unlock()
+	suite.Equal(sessions.Target{TunnelUID: "tunnel", Hostname: "dns.dino.local", Protocol: protocolUDP}, suite.tunnel.targets[0])
+}
+
+func (suite *UDPSuite) TestReapIdle() {
+	ul := suite.proxy(100 * time.Millisecond)
+	client := suite.dial(ul)
+
+	_, err := io.WriteString(client, "ping")
+	suite.Require().NoError(err)
+	reply := make([]byte, maxDatagramSize)
+	_, err = client.Read(reply)
+	suite.Require().NoError(err)
+
+	suite.Eventually(func() bool {
+		_, released := suite.tunnel.counts()
+		return released == 1
+	}, 2*time.Second, 10*time.Millisecond)
+
+	ul.mtx.Lock()
+	suite.Empty(ul.sessions)
+	ul.mtx.Unlock()
+
+	// the next datagram opens a new session
+	_, err = io.WriteString(client, "pong")
+	suite.Require().NoError(err)
+	n, err := client.Read(reply)
+	suite.Require().NoError(err)
+	suite.Equal("pong", string(reply[:n]))
+
+	opened, _ := suite.tunnel.counts()
+	suite.Equal(2, opened)
+}
+
+func TestUDPSuite(t *testing.T) {
+	suite.Run(t, new(UDPSuite))
+}
@@ -4,7 +4,9 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"sync"
+	"time"
 )
 
 // Msg
@@ -20,22 +22,89 @@ type RouteConfig struct {
 	DestProtocol string `json:"dest_protocol"`
 	DestAddr     string `json:"dest_addr"`
 	DestPort     uint32 `json:"dest_port"`
-	IsDelete     bool   `json:"is_delete"`
+	PublicPort   uint32 `json:"public_port"`
+
+	RouteID    string            `json:"route_id"`
+	PathPrefix string            `json:"path_prefix"`
+	Headers    map[string]string `json:"headers"`
+
+	IsDelete bool `json:"is_delete"`
 }
 
+// routeConfig alias without the [Msg] methods to avoid recursive encoding
+type routeConfig RouteConfig
+
 // MarshalJSON implements [Msg].
 func (r *RouteConfig) MarshalJSON() ([]byte, error) {
-	return json.Marshal(r)
+	return json.Marshal((*routeConfig)(r))
 }
 
 // UnmarshalJSON implements [Msg].
 func (r *RouteConfig) UnmarshalJSON(b []byte) error {
-	return json.Unmarshal(b, &r)
+	return json.Unmarshal(b, (*routeConfig)(r))
 }
 
 // interface compliance
 var _ Msg = (*RouteConfig)(nil)
 
+// DecodeRouteConfig decode route config message received from subscription
+func DecodeRouteConfig(s string) (*RouteConfig, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode string: %w", err)
+	}
+
+	var cfg RouteConfig
+	err = cfg.UnmarshalJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+// CredentialsRotated
+type CredentialsRotated struct {
+	TunnelUID string `json:"tunnel_uid"`
+	// KeyID fingerprint of the signing key issued by the rotation, connections
+	// authenticated with any other key are revoked
+	KeyID string `json:"key_id"`
+	// RevokeAt end of the grace period of the replaced token
+	RevokeAt time.Time `json:"revoke_at"`
+}
+
+// credentialsRotated alias without the [Msg] methods to avoid recursive encoding
+type credentialsRotated CredentialsRotated
+
+// MarshalJSON implements [Msg].
+func (c *CredentialsRotated) MarshalJSON() ([]byte, error) {
+	return json.Marshal((*credentialsRotated)(c))
+}
+
+// UnmarshalJSON implements [Msg].
+func (c *CredentialsRotated) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, (*credentialsRotated)(c))
+}
+
+// interface compliance
+var _ Msg = (*CredentialsRotated)(nil)
+
+// DecodeCredentialsRotated decode credentials rotated message received from subscription
+func DecodeCredentialsRotated(s string) (*CredentialsRotated, error) {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode string: %w", err)
+	}
+
+	var rotated CredentialsRotated
+	err = rotated.UnmarshalJSON(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
+	}
+
+	return &rotated, nil
+}
+
 // Broker
 type Broker interface {
 	Publish(string, interface{}) error
@@ -44,10 +113,13 @@ type Broker interface {
 	Unsubsribe(string)
 }
 
+// subscriber channel buffer size
+const subscriberBuffer = 64
+
 // inmemory implementation of message broker
 type inmemoryBroker struct {
 	mtx  sync.RWMutex
-	subs map[string]chan string
+	subs map[string][]chan string
 }
 
 // interface compliance
@@ -56,7 +128,7 @@ var _ Broker = (*inmemoryBroker)(nil)
 func newBroker() Broker {
 	return &inmemoryBroker{
 		mtx:  sync.RWMutex{},
-		subs: map[string]chan string{},
+		subs: map[string][]chan string{},
 	}
 }
 
@@ -69,14 +141,17 @@ func (i *inmemoryBroker) Publish(topic string, payload interface{}) error {
 
 	encoded := base64.StdEncoding.EncodeToString(jsonbytes)
 
-	i.mtx.Lock()
-	defer i.mtx.Unlock()
-
-	if ch, ok := i.subs[topic]; !ok {
-		i.subs[topic] = make(chan string)
-		i.subs[topic] <- encoded
-	} else {
-		ch <- encoded
+	i.mtx.RLock()
+	subs := slices.Clone(i.subs[topic])
+	i.mtx.RUnlock()
+
+	// every subscriber receives a copy of the message, a subscriber with a
+	// full buffer misses it instead of stalling the publisher
+	for _, ch := range subs {
+		select {
+		case ch <- encoded:
+		default:
+		}
 	}
 
 	return nil
@@ -84,14 +159,12 @@ func (i *inmemoryBroker) Publish(topic string, payload interface{}) error {
 
 // Subscribe implements [Broker].
 func (i *inmemoryBroker) Subscribe(topic string) chan string {
-	i.mtx.RLock()
-	defer i.mtx.RUnlock()
-	if ch, ok := i.subs[topic]; !ok {
-		i.subs[topic] = make(chan string)
-		return i.subs[topic]
-	} else {
-		return ch
-	}
+	i.mtx.Lock()
+	defer i.mtx.Unlock()
+
+	ch := make(chan string, subscriberBuffer)
+	i.subs[topic] = append(i.subs[topic], ch)
+	return ch
 }
 
 // Unsubsribe implements [Broker].
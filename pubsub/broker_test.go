@@ -0,0 +1,48 @@
+package pubsub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BrokerSuite struct {
+	suite.Suite
+
+	broker Broker
+}
+
+func (suite *BrokerSuite) SetupTest() {
+	suite.broker = newBroker()
+}
+
+func (suite *BrokerSuite) TestPublishStalledSubscriber() {
+	stalled := suite.broker.Subscribe("dino.routes")
+	for range subscriberBuffer {
+		suite.Require().NoError(suite.broker.Publish("dino.routes", &RouteConfig{Hostname: "stalled.dino.local"}))
+	}
+
+	ch := suite.broker.Subscribe("dino.routes")
+
+	done := make(chan error, 1)
+	go func() {
+		done <- suite.broker.Publish("dino.routes", &RouteConfig{Hostname: "web.dino.local"})
+	}()
+
+	select {
+	case err := <-done:
+		suite.NoError(err)
+	case <-time.After(time.Second):
+		suite.FailNow("publish blocked on a full subscriber")
+	}
+
+	cfg, err := DecodeRouteConfig(<-ch)
+	suite.Require().NoError(err)
+	suite.Equal("web.dino.local", cfg.Hostname)
+	suite.Len(stalled, subscriberBuffer)
+}
+
+func TestBrokerSuite(t *testing.T) {
+	suite.Run(t, new(BrokerSuite))
+}
@@ -0,0 +1,85 @@
+package routing
+
+import (
+	"net/http"
+	"strings"
+)
+
+// Rule route match conditions beyond hostname
+type Rule struct {
+	// ID route the rule belongs to
+	ID string
+
+	// PathPrefix request path must start with, empty matches every path
+	PathPrefix string
+	// Headers request header values that must all be present
+	Headers map[string]string
+}
+
+// Matches request path and header satisfy every rule condition
+func (r Rule) Matches(path string, header http.Header) bool {
+	if !hasPathPrefix(path, r.PathPrefix) {
+		return false
+	}
+
+	for name, value := range r.Headers {
+		if header.Get(name) != value {
+			return false
+		}
+	}
+
+	return true
+}
+
+// moreSpecific r wins over o, longer path prefixes first then more header conditions
+func (r Rule) moreSpecific(o Rule) bool {
+	if len(r.PathPrefix) != len(o.PathPrefix) {
+		return len(r.PathPrefix) > len(o.PathPrefix)
+	}
+	return len(r.Headers) > len(o.Headers)
+}
+
+// MostSpecific matching rule for request path and header
+func MostSpecific(rules []Rule, path string, header http.Header) (Rule, bool) {
+	var (
+		best  Rule
+		found bool
+	)
+
+	for _, r := range rules {
+		if !r.Matches(path, header) {
+			continue
+		}
+
+		if !found || r.moreSpecific(best) {
+			best = r
+			found = true
+		}
+	}
+
+	return best, found
+}
+
+// StripPrefix remove rule path prefix from path keeping it absolute
+func StripPrefix(path, prefix string) string {
+	trimmed := strings.TrimPrefix(path, strings.TrimSuffix(prefix, "/"))
+	if !strings.HasPrefix(trimmed, "/") {
+		trimmed = "/" + trimmed
+	}
+	return trimmed
+}
+
+// hasPathPrefix prefix matches whole path segments, /v2 matches /v2/users but
+// not /v20, a trailing slash is ignored so /v2/ also matches the bare /v2
+func hasPathPrefix(path, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return true
+	}
+
+	if !strings.HasPrefix(path, prefix) {
+		return false
+	}
+
+	return len(path) == len(prefix) || path[len(prefix)] == '/'
+}
@@ -0,0 +1,107 @@
+package routing
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type RuleSuite struct {
+	suite.Suite
+}
+
+func (suite *RuleSuite) TestHasPathPrefix() {
+	tt := []struct {
+		path, prefix string
+		match        bool
+	}{
+		{"/anything", "", true},
+		{"/anything", "/", true},
+		{"/v2", "/v2", true},
+		{"/v2/users", "/v2", true},
+		{"/v20", "/v2", false},
+		{"/v2", "/v2/", true},
+		{"/v2/", "/v2/", true},
+		{"/v2/users", "/v2/", true},
+		{"/v20/users", "/v2/", false},
+		{"/v", "/v2", false},
+		{"/api/v2/users", "/api/v2", true},
+		{"/api/v20", "/api/v2", false},
+	}
+
+	for _, tc := range tt {
+		suite.Equal(tc.match, hasPathPrefix(tc.path, tc.prefix), "path %q prefix %q", tc.path, tc.prefix)
+	}
+}
+
+func (suite *RuleSuite) TestStripPrefix() {
+	tt := []struct {
+		path, prefix, stripped string
+	}{
+		{"/v2/users", "/v2/", "/users"},
+		{"/v2/users", "/v2", "/users"},
+		{"/v2", "/v2/", "/"},
+		{"/v2/", "/v2/", "/"},
+		{"/v2", "/v2", "/"},
+		{"/users", "", "/users"},
+		{"/users", "/", "/users"},
+		{"/api/v2/users/1", "/api/v2", "/users/1"},
+	}
+
+	for _, tc := range tt {
+		suite.Equal(tc.stripped, StripPrefix(tc.path, tc.prefix), "path %q prefix %q", tc.path, tc.prefix)
+	}
+}
+
+func (suite *RuleSuite) TestMostSpecific() {
+	rules := []Rule{
+		{ID: "catch-all"},
+		{ID: "v2", PathPrefix: "/v2/"},
+		{ID: "v2-users", PathPrefix: "/v2/users"},
+		{ID: "v2-beta", PathPrefix: "/v2/", Headers: map[string]string{"X-Beta": "1"}},
+		{ID: "v2-beta-eu", PathPrefix: "/v2/", Headers: map[string]string{"X-Beta": "1", "X-Region": "eu"}},
+		{ID: "v3-tenant", PathPrefix: "/v3", Headers: map[string]string{"x-tenant": "a"}},
+	}
+
+	tt := []struct {
+		name   string
+		path   string
+		header http.Header
+		id     string
+	}{
+		{"no rule matches", "/", nil, "catch-all"},
+		{"path prefix", "/v2/orders", nil, "v2"},
+		{"bare prefix", "/v2", nil, "v2"},
+		{"segment boundary", "/v20", nil, "catch-all"},
+		{"longest prefix", "/v2/users/1", nil, "v2-users"},
+		{"longer prefix over headers", "/v2/users", http.Header{"X-Beta": {"1"}}, "v2-users"},
+		{"header match", "/v2/orders", http.Header{"X-Beta": {"1"}}, "v2-beta"},
+		{"header value mismatch", "/v2/orders", http.Header{"X-Beta": {"0"}}, "v2"},
+		{"more headers", "/v2/orders", http.Header{"X-Beta": {"1"}, "X-Region": {"eu"}}, "v2-beta-eu"},
+		{"header name case", "/v3", http.Header{"X-Tenant": {"a"}}, "v3-tenant"},
+	}
+
+	for _, tc := range tt {
+		rule, ok := MostSpecific(rules, tc.path, tc.header)
+		suite.True(ok, tc.name)
+		suite.Equal(tc.id, rule.ID, tc.name)
+	}
+}
+
+func (suite *RuleSuite) TestMostSpecificNoMatch() {
+	rules := []Rule{
+		{ID: "v2", PathPrefix: "/v2"},
+		{ID: "beta", Headers: map[string]string{"X-Beta": "1"}},
+	}
+
+	_, ok := MostSpecific(rules, "/v1", http.Header{})
+	suite.False(ok)
+
+	_, ok = MostSpecific(nil, "/", nil)
+	suite.False(ok)
+}
+
+func TestRuleSuite(t *testing.T) {
+	suite.Run(t, new(RuleSuite))
+}
@@ -0,0 +1,41 @@
+package routing
+
+import (
+	"errors"
+	"strings"
+)
+
+// wildcardPrefix leading label of a wildcard hostname
+const wildcardPrefix = "*."
+
+// ErrInvalidWildcard
+var ErrInvalidWildcard = errors.New("wildcard must be the whole leftmost label of a hostname with at least two labels")
+
+// Wildcard pattern matching hostname and the label it substitutes,
+// sub.preview.example.com matches *.preview.example.com with label sub
+func Wildcard(hostname string) (pattern, label string, ok bool) {
+	label, parent, found := strings.Cut(hostname, ".")
+	if !found || label == "" || label == "*" || parent == "" {
+		return "", "", false
+	}
+	return wildcardPrefix + parent, label, true
+}
+
+// IsWildcard hostname is a wildcard pattern
+func IsWildcard(hostname string) bool {
+	return strings.HasPrefix(hostname, wildcardPrefix)
+}
+
+// ValidateWildcard hostname only uses a single leading wildcard label
+// over a parent with at least two labels, *.example.com but not *.com
+func ValidateWildcard(hostname string) error {
+	if !strings.Contains(hostname, "*") {
+		return nil
+	}
+
+	parent, ok := strings.CutPrefix(hostname, wildcardPrefix)
+	if !ok || strings.Contains(parent, "*") || !strings.Contains(strings.Trim(parent, "."), ".") {
+		return ErrInvalidWildcard
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package routing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type WildcardSuite struct {
+	suite.Suite
+}
+
+func (suite *WildcardSuite) TestWildcard() {
+	tt := []struct {
+		hostname, pattern, label string
+		ok                       bool
+	}{
+		{"sub.preview.example.com", "*.preview.example.com", "sub", true},
+		{"feature-1.dino.local", "*.dino.local", "feature-1", true},
+		{"example.com", "*.com", "example", true},
+		{"localhost", "", "", false},
+		{".example.com", "", "", false},
+		{"*.example.com", "", "", false},
+		{"example.", "", "", false},
+	}
+
+	for _, tc := range tt {
+		pattern, label, ok := Wildcard(tc.hostname)
+		suite.Equal(tc.ok, ok, tc.hostname)
+		suite.Equal(tc.pattern, pattern, tc.hostname)
+		suite.Equal(tc.label, label, tc.hostname)
+	}
+}
+
+func (suite *WildcardSuite) TestIsWildcard() {
+	suite.True(IsWildcard("*.example.com"))
+	suite.False(IsWildcard("example.com"))
+	suite.False(IsWildcard("a.*.example.com"))
+}
+
+func (suite *WildcardSuite) TestValidateWildcard() {
+	tt := []struct {
+		hostname string
+		valid    bool
+	}{
+		{"example.com", true},
+		{"*.example.com", true},
+		{"*.preview.example.com", true},
+		{"*.example.com.", true},
+		{"*.com", false},
+		{"*.com.", false},
+		{"*", false},
+		{"*.", false},
+		{"a.*.b", false},
+		{"a.*.example.com", false},
+		{"*.*.example.com", false},
+		{"**.example.com", false},
+		{"feature-*.example.com", false},
+		{"example.*", false},
+	}
+
+	for _, tc := range tt {
+		err := ValidateWildcard(tc.hostname)
+		if tc.valid {
+			suite.NoError(err, tc.hostname)
+		} else {
+			suite.ErrorIs(err, ErrInvalidWildcard, tc.hostname)
+		}
+	}
+}
+
+func TestWildcardSuite(t *testing.T) {
+	suite.Run(t, new(WildcardSuite))
+}
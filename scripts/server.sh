@@ -5,5 +5,6 @@ export LOG_LEVEL=DEBUG
 export DB_HOST=localhost
 export DB_PORT=30432
 
-export SERVER_SSL_CERT_PATH=.certs/backend.cert
-export SERVER_SSL_KEY_PATH=.certs/backend.key
+export AUTH_MASTER_KEY=di/DCDeLvvMpilq34lzeTJb8HUPVG1Tm6t29GEuHEms=
+
+export API_ROOT_TOKEN=dino-development
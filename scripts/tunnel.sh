@@ -1,4 +1,5 @@
 #!/bin/bash
 
 export TUNNEL_ID=CHANGEME
-export TUNNEL_TOKEN=CHANGEME
\ No newline at end of file
+export TUNNEL_TOKEN=CHANGEME
+export TUNNEL_CA_FINGERPRINT=CHANGEME
\ No newline at end of file
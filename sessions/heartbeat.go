@@ -0,0 +1,50 @@
+package sessions
+
+import (
+	"sync"
+	"time"
+)
+
+// heartbeat pings sent on a tunnel connection still waiting for a pong
+type heartbeat struct {
+	mtx   sync.Mutex
+	nonce uint64
+	sent  map[uint64]time.Time
+}
+
+func newHeartbeat() *heartbeat {
+	return &heartbeat{sent: make(map[uint64]time.Time)}
+}
+
+// ping nonce of the next ping, false once misses pings went unanswered
+func (h *heartbeat) ping(misses int) (uint64, bool) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	if len(h.sent) >= misses {
+		return 0, false
+	}
+
+	h.nonce++
+	h.sent[h.nonce] = time.Now()
+	return h.nonce, true
+}
+
+// pong round trip of the ping answered with nonce, older pings are settled
+// as well since the agent answers in order
+func (h *heartbeat) pong(nonce uint64) (time.Duration, bool) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+
+	sent, ok := h.sent[nonce]
+	if !ok {
+		return 0, false
+	}
+
+	for n := range h.sent {
+		if n <= nonce {
+			delete(h.sent, n)
+		}
+	}
+	return time.Since(sent), true
+}
@@ -0,0 +1,48 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type HeartbeatSuite struct {
+	suite.Suite
+}
+
+func (suite *HeartbeatSuite) TestMissedPings() {
+	hb := newHeartbeat()
+
+	for range 3 {
+		_, ok := hb.ping(3)
+		suite.Require().True(ok)
+	}
+
+	_, ok := hb.ping(3)
+	suite.False(ok)
+}
+
+func (suite *HeartbeatSuite) TestPongSettlesOlderPings() {
+	hb := newHeartbeat()
+
+	first, _ := hb.ping(3)
+	second, _ := hb.ping(3)
+
+	rtt, ok := hb.pong(second)
+	suite.Require().True(ok)
+	suite.GreaterOrEqual(rtt, time.Duration(0))
+
+	// first was settled by the later pong
+	_, ok = hb.pong(first)
+	suite.False(ok)
+
+	for range 3 {
+		_, ok := hb.ping(3)
+		suite.True(ok)
+	}
+}
+
+func TestHeartbeatSuite(t *testing.T) {
+	suite.Run(t, new(HeartbeatSuite))
+}
@@ -1,11 +1,15 @@
 package sessions
 
 import (
+	"fmt"
+
 	"github.com/structx/teapot"
 	"go.uber.org/fx"
 	"soft.structx.io/dino/internal/routes"
 	"soft.structx.io/dino/internal/tunnel"
 	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/setup"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
 // Params
@@ -14,7 +18,9 @@ type Params struct {
 
 	Lc fx.Lifecycle
 
-	Logger *teapot.Logger
+	Cfg      *setup.Server
+	ProxyCfg *setup.Proxy
+	Logger   *teapot.Logger
 
 	TunnelService tunnel.Service `name:"tunnel_service"`
 	RouteService  routes.Service
@@ -32,8 +38,26 @@ type Result struct {
 // Module
 var Module = fx.Module("sessions", fx.Provide(newModule))
 
-func newModule(p Params) Result {
-	mux := newMux(p.Logger, p.Broker, p.TunnelService, p.RouteService)
+func newModule(p Params) (Result, error) {
+	switch p.Cfg.DuplicateTunnel {
+	case duplicateBalance, duplicateReplace, duplicateReject:
+	default:
+		return Result{}, fmt.Errorf("invalid duplicate tunnel policy %q", p.Cfg.DuplicateTunnel)
+	}
+
+	if p.ProxyCfg.SessionIdleTimeout < 0 || p.ProxyCfg.SessionMaxLifetime < 0 {
+		return Result{}, fmt.Errorf("session timeouts must not be negative: idle %s, max lifetime %s",
+			p.ProxyCfg.SessionIdleTimeout, p.ProxyCfg.SessionMaxLifetime)
+	}
+
+	if p.Cfg.HeartbeatInterval > 0 && p.Cfg.HeartbeatMisses < 1 {
+		return Result{}, fmt.Errorf("heartbeat misses must be positive: %d", p.Cfg.HeartbeatMisses)
+	}
+
+	mux := newMux(p.Logger, p.Broker, p.TunnelService, p.RouteService, p.Cfg, tunnelnet.Timeouts{
+		Idle:        p.ProxyCfg.SessionIdleTimeout,
+		MaxLifetime: p.ProxyCfg.SessionMaxLifetime,
+	})
 
 	p.Lc.Append(fx.Hook{
 		OnStart: mux.start,
@@ -42,5 +66,5 @@ func newModule(p Params) Result {
 
 	return Result{
 		Mux: mux,
-	}
+	}, nil
 }
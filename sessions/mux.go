@@ -2,28 +2,82 @@ package sessions
 
 import (
 	"context"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/structx/teapot"
 	"soft.structx.io/dino/internal/routes"
 	"soft.structx.io/dino/internal/tunnel"
 	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/setup"
 	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
+// drainPollInterval between checks for sessions still open while draining
+const drainPollInterval = 100 * time.Millisecond
+
+// reapInterval between checks for sessions past their idle timeout or max lifetime
+const reapInterval = time.Second
+
+const (
+	// duplicateBalance connections of the same tunnel share its sessions
+	duplicateBalance = "balance"
+	// duplicateReplace a new connection of a connected tunnel closes the old one
+	duplicateReplace = "replace"
+	// duplicateReject a new connection of a connected tunnel is refused
+	duplicateReject = "reject"
+)
+
+var (
+	// ErrTunnelClosed agent disconnected while the session was open
+	ErrTunnelClosed = errors.New("tunnel disconnected")
+	// ErrTunnelConnected tunnel already has a connection and duplicates are rejected
+	ErrTunnelConnected = errors.New("tunnel already connected")
+	// ErrDraining server is shutting down and takes no new tunnels
+	ErrDraining = errors.New("server draining")
+	// ErrCredentialsRevoked agent authenticated with rotated credentials past their grace period
+	ErrCredentialsRevoked = errors.New("tunnel credentials revoked")
+)
+
 // Multiplexer
 type Multiplexer interface {
-	// RegisterTunnel
-	RegisterTunnel(context.Context, tunnelnet.Conn, string) error
+	// RegisterTunnel serve sessions over conn authenticated with the signing
+	// key of key id, the returned channel is closed once the connection
+	// stopped serving the tunnel
+	RegisterTunnel(ctx context.Context, conn tunnelnet.Conn, tunnelUID, keyID string) (<-chan struct{}, error)
+
+	// DeregisterTunnel remove conn once the agent disconnected
+	DeregisterTunnel(context.Context, tunnelnet.Conn, string) error
+
+	// UnarySession open a session on the tunnel of target
+	UnarySession(context.Context, Target) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool)
 
-	// UnarySession
-	UnarySession(context.Context, string) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool)
+	// SyncRoutes send every route of the tunnel to a newly registered conn
+	SyncRoutes(context.Context, tunnelnet.Conn, string) error
 
-	// SyncRoutes
-	SyncRoutes(context.Context, string) error
+	// Drain tell every agent the server is going away, refuse new sessions
+	// and close the tunnels once open sessions finished or ctx is done
+	Drain(context.Context) error
+
+	// OnDrain run f once draining started, before open sessions are awaited,
+	// session pools close their idle sessions there
+	OnDrain(f func())
+}
+
+// Target matched route a session is opened for
+type Target struct {
+	TunnelUID string
+	// RouteID empty when the route was matched by hostname only
+	RouteID  string
+	Hostname string
+	Protocol string
+	// Label subdomain matched by a wildcard route
+	Label string
+	// Timeouts limits of the route, zero limits use the server defaults
+	Timeouts tunnelnet.Timeouts
 }
 
 type sessionMultiplexer struct {
@@ -33,12 +87,30 @@ type sessionMultiplexer struct {
 	log *teapot.Logger
 
 	mtx     sync.Mutex
-	tunnels map[string]*activeTunnel
+	tunnels map[string]tunnelPool
+	// next rotates the first connection considered for a session
+	next atomic.Uint64
+	// draining set once shutdown started
+	draining atomic.Bool
 
 	tunnelSvc tunnel.Service
 	routeSvc  routes.Service
 
 	broker pubsub.Broker
+
+	// duplicate policy of a second connection for a connected tunnel
+	duplicate string
+
+	// heartbeatInterval between pings of every connection, zero disables them
+	heartbeatInterval time.Duration
+	// heartbeatMisses unanswered pings before a connection is declared dead
+	heartbeatMisses int
+
+	// timeouts default session limits of routes without their own
+	timeouts tunnelnet.Timeouts
+
+	// drainHooks run before waiting on open sessions
+	drainHooks []func()
 }
 
 func newMux(
@@ -46,79 +118,144 @@ func newMux(
 	broker pubsub.Broker,
 	tsvc tunnel.Service,
 	rsvc routes.Service,
+	cfg *setup.Server,
+	timeouts tunnelnet.Timeouts,
 ) *sessionMultiplexer {
 	ctx, cancel := context.WithCancel(context.Background())
 	return &sessionMultiplexer{
-		log:       logger,
-		ctx:       ctx,
-		cancelFn:  cancel,
-		mtx:       sync.Mutex{},
-		tunnels:   make(map[string]*activeTunnel),
-		tunnelSvc: tsvc,
-		routeSvc:  rsvc,
-		broker:    broker,
+		log:               logger,
+		ctx:               ctx,
+		cancelFn:          cancel,
+		mtx:               sync.Mutex{},
+		tunnels:           make(map[string]tunnelPool),
+		tunnelSvc:         tsvc,
+		routeSvc:          rsvc,
+		broker:            broker,
+		duplicate:         cfg.DuplicateTunnel,
+		heartbeatInterval: cfg.HeartbeatInterval,
+		heartbeatMisses:   cfg.HeartbeatMisses,
+		timeouts:          timeouts,
 	}
 }
 
 // RegisterTunnel
-func (m *sessionMultiplexer) RegisterTunnel(ctx context.Context, conn tunnelnet.Conn, tunnelUID string) error {
+func (m *sessionMultiplexer) RegisterTunnel(ctx context.Context, conn tunnelnet.Conn, tunnelUID, keyID string) (<-chan struct{}, error) {
+	tunnel := newActiveTunnel(m.log, tunnelUID, keyID, conn)
+
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	if m.draining.Load() {
+		m.mtx.Unlock()
+		return nil, ErrDraining
+	}
+	live := m.tunnels[tunnelUID].live()
+
+	var replaced tunnelPool
+	switch {
+	case len(live) > 0 && m.duplicate == duplicateReject:
+		m.mtx.Unlock()
+		return nil, ErrTunnelConnected
+	case m.duplicate == duplicateReplace:
+		replaced = live
+		m.tunnels[tunnelUID] = tunnelPool{tunnel}
+	default:
+		m.tunnels[tunnelUID] = append(live, tunnel)
+	}
+	m.mtx.Unlock()
 
-	m.tunnels[tunnelUID] = &activeTunnel{
-		streamID: tunnelUID,
-		mtx:      sync.Mutex{},
-		sessions: make(map[string]*activeSession),
-		stream:   conn,
+	for _, prev := range replaced {
+		// sessions of the replaced connection fail, clients retry on the new one
+		m.log.Info("replace tunnel connection", teapot.String("tunnel", tunnelUID))
+		prev.close(ErrTunnelClosed)
 	}
-	go m.tunnels[tunnelUID].worker()
 
-	return nil
+	go tunnel.worker()
+	go tunnel.reaper(reapInterval)
+	if m.heartbeatInterval > 0 {
+		go m.heartbeat(tunnel)
+	}
+
+	if err := m.tunnelSvc.SetActive(ctx, tunnelUID, true); err != nil {
+		m.log.Error("set tunnel active", teapot.String("tunnel", tunnelUID), teapot.Error(err))
+	}
+
+	return tunnel.done, nil
 }
 
-// UnarySession
-func (m *sessionMultiplexer) UnarySession(ctx context.Context, tunnelUID string) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool) {
+// DeregisterTunnel
+func (m *sessionMultiplexer) DeregisterTunnel(ctx context.Context, conn tunnelnet.Conn, tunnelUID string) error {
 	m.mtx.Lock()
-	defer m.mtx.Unlock()
+	rest, tunnel := m.tunnels[tunnelUID].without(conn)
+	rest = rest.live()
+	if len(rest) > 0 {
+		m.tunnels[tunnelUID] = rest
+	} else {
+		delete(m.tunnels, tunnelUID)
+	}
+	m.mtx.Unlock()
 
-	tunnel, ok := m.tunnels[tunnelUID]
-	if !ok {
-		return nil, nil, nil, false
+	if tunnel != nil {
+		tunnel.close(ErrTunnelClosed)
 	}
 
-	session := tunnel.registerSession()
-	err := session.openConn()
-	if err != nil {
-		m.log.Error("open session connection", teapot.Error(err))
+	if len(rest) > 0 {
+		// other connections keep the tunnel active
+		return nil
+	}
+
+	if err := m.tunnelSvc.SetActive(ctx, tunnelUID, false); err != nil {
+		return fmt.Errorf("tunnelSvc.SetActive: %w", err)
+	}
+	return nil
+}
+
+// UnarySession
+func (m *sessionMultiplexer) UnarySession(ctx context.Context, target Target) (tunnelnet.ReadCloser, tunnelnet.WriteCloser, func(), bool) {
+	if m.draining.Load() {
 		return nil, nil, nil, false
 	}
 
-	cleanup := func() {
-		if err := tunnel.deregisterSession(session.sessionID); err != nil {
-			m.log.Error("deregister session", teapot.Error(err))
+	m.mtx.Lock()
+	pool := m.tunnels[target.TunnelUID]
+	m.mtx.Unlock()
+
+	target.Timeouts = target.Timeouts.Or(m.timeouts)
+	for range pool {
+		tunnel := pool.pick(m.next.Add(1))
+		if tunnel == nil {
+			break
+		}
+
+		session, err := tunnel.openSession(ctx, target)
+		if err != nil && tunnel.isClosed() {
+			// connection dropped while opening, fail over to the next one
+			m.log.Debug("open session on closed connection", teapot.String("tunnel", target.TunnelUID), teapot.Error(err))
+			continue
+		} else if err != nil {
+			m.log.Error("open session", teapot.Error(err))
+			return nil, nil, nil, false
 		}
-	}
 
-	return session, session, cleanup, true
+		cleanup := func() {
+			if err := tunnel.deregisterSession(session.sessionID); err != nil {
+				m.log.Error("deregister session", teapot.Error(err))
+			}
+		}
+
+		return session, session, cleanup, true
+	}
 
+	return nil, nil, nil, false
 }
 
 // SyncRoutes
-func (m *sessionMultiplexer) SyncRoutes(ctx context.Context, tunnelUID string) error {
+func (m *sessionMultiplexer) SyncRoutes(ctx context.Context, conn tunnelnet.Conn, tunnelUID string) error {
 	routes, err := m.routeSvc.Sync(ctx, tunnelUID)
 	if err != nil {
 		return fmt.Errorf("failed to sync routes: %w", err)
 	}
 
-	m.mtx.Lock()
-	tunnel, ok := m.tunnels[tunnelUID]
-	m.mtx.Unlock()
-	if !ok {
-		return errors.New("tunnel is not active")
-	}
-
 	for _, r := range routes {
-		if _, err := tunnel.stream.Write(&tunnelnet.DataFrame{
+		if _, err := conn.Write(&tunnelnet.DataFrame{
 			SessionID:      tunnelUID,
 			IsControlFrame: true,
 			RouteUpdate: &tunnelnet.RouteUpdate{
@@ -127,6 +264,9 @@ func (m *sessionMultiplexer) SyncRoutes(ctx context.Context, tunnelUID string) e
 				DestIP:       r.DestinationIP,
 				DestPort:     r.DestinationPort,
 				IsDelete:     !r.Enabled,
+				RouteID:      r.ID,
+				PathPrefix:   r.PathPrefix,
+				Headers:      r.MatchHeaders,
 			},
 			NewConn:   nil,
 			CloseConn: nil,
@@ -139,33 +279,151 @@ func (m *sessionMultiplexer) SyncRoutes(ctx context.Context, tunnelUID string) e
 	return nil
 }
 
+// OnDrain
+func (m *sessionMultiplexer) OnDrain(f func()) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	m.drainHooks = append(m.drainHooks, f)
+}
+
+// Drain
+func (m *sessionMultiplexer) Drain(ctx context.Context) error {
+	m.mtx.Lock()
+	m.draining.Store(true)
+	var tunnels tunnelPool
+	for _, pool := range m.tunnels {
+		tunnels = append(tunnels, pool.live()...)
+	}
+	hooks := m.drainHooks
+	m.mtx.Unlock()
+
+	for _, t := range tunnels {
+		// a hung agent stops reading, the send must not stall the drain
+		go func() {
+			if _, err := t.stream.Write(&tunnelnet.DataFrame{
+				SessionID:      t.streamID,
+				IsControlFrame: true,
+				GoAway:         &tunnelnet.GoAway{Reason: "server shutting down"},
+			}); err != nil {
+				m.log.Debug("send go away", teapot.String("tunnel", t.streamID), teapot.Error(err))
+			}
+		}()
+	}
+
+	// pooled keep-alive sessions carry no request, waiting on them only
+	// runs out the drain timeout
+	for _, f := range hooks {
+		f()
+	}
+
+	err := waitIdle(ctx, tunnels)
+
+	m.closeAll()
+	return err
+}
+
+// waitIdle block until no session is open on tunnels or ctx is done
+func waitIdle(ctx context.Context, tunnels tunnelPool) error {
+	ticker := time.NewTicker(drainPollInterval)
+	defer ticker.Stop()
+
+	for {
+		open := tunnels.load()
+		if open == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%d sessions still open: %w", open, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// heartbeat ping the agent of t every interval and record the round trip,
+// the connection is closed once too many pings went unanswered
+func (m *sessionMultiplexer) heartbeat(t *activeTunnel) {
+	ticker := time.NewTicker(m.heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.done:
+			return
+		case rtt := <-t.rtts:
+			if err := m.tunnelSvc.Heartbeat(m.ctx, t.streamID, rtt); err != nil {
+				m.log.Error("record tunnel heartbeat", teapot.String("tunnel", t.streamID), teapot.Error(err))
+			}
+		case <-ticker.C:
+			nonce, ok := t.heartbeat.ping(m.heartbeatMisses)
+			if !ok {
+				m.log.Info("tunnel missed heartbeats", teapot.String("tunnel", t.streamID), teapot.Int("misses", m.heartbeatMisses))
+				t.close(ErrTunnelClosed)
+				return
+			}
+
+			// a hung agent stops reading, the send must not stall the ticker
+			go func() {
+				if _, err := t.stream.Write(&tunnelnet.DataFrame{
+					SessionID:      t.streamID,
+					IsControlFrame: true,
+					Ping:           &tunnelnet.Heartbeat{Nonce: nonce},
+				}); err != nil {
+					m.log.Debug("send heartbeat", teapot.String("tunnel", t.streamID), teapot.Error(err))
+				}
+			}()
+		}
+	}
+}
+
 func (m *sessionMultiplexer) start(_ context.Context) error {
-	ch := m.broker.Subscribe("dino.routes.*")
+	ch := m.broker.Subscribe("dino.routes")
 	go m.subscription(ch)
+
+	rotations := m.broker.Subscribe("dino.tunnels.credentials")
+	go m.rotations(rotations)
 	return nil
 }
 
 func (m *sessionMultiplexer) stop(_ context.Context) error {
 	m.cancelFn()
+	m.closeAll()
 	return nil
 }
 
+// closeAll abort every tunnel and its sessions
+func (m *sessionMultiplexer) closeAll() {
+	m.mtx.Lock()
+	tunnels := m.tunnels
+	m.tunnels = make(map[string]tunnelPool)
+	m.mtx.Unlock()
+
+	for _, pool := range tunnels {
+		for _, t := range pool {
+			t.close(ErrTunnelClosed)
+		}
+	}
+}
+
 func (m *sessionMultiplexer) subscription(ch chan string) {
 	for {
 		select {
 		case <-m.ctx.Done():
 			return
 		case msg := <-ch:
-			rcfg, err := decodeMessge(msg)
+			rcfg, err := pubsub.DecodeRouteConfig(msg)
 			if err != nil {
 				m.log.Error("decode route config", teapot.Error(err))
 				continue
 			}
 
-			if t, ok := m.tunnels[rcfg.TunnelUID]; !ok {
-				// tunnel is not active continue looping
-				continue
-			} else {
+			m.mtx.Lock()
+			pool := m.tunnels[rcfg.TunnelUID]
+			m.mtx.Unlock()
+
+			// every connection of the tunnel routes the same hostnames
+			for _, t := range pool.live() {
 				if _, err := t.stream.Write(&tunnelnet.DataFrame{
 					SessionID: t.streamID,
 					RouteUpdate: &tunnelnet.RouteUpdate{
@@ -174,6 +432,9 @@ func (m *sessionMultiplexer) subscription(ch chan string) {
 						DestIP:       rcfg.DestAddr,
 						DestPort:     rcfg.DestPort,
 						IsDelete:     rcfg.IsDelete,
+						RouteID:      rcfg.RouteID,
+						PathPrefix:   rcfg.PathPrefix,
+						Headers:      rcfg.Headers,
 					},
 				}); err != nil {
 					m.log.Error("write route config", teapot.Error(err))
@@ -183,17 +444,35 @@ func (m *sessionMultiplexer) subscription(ch chan string) {
 	}
 }
 
-func decodeMessge(s string) (*pubsub.RouteConfig, error) {
-	b64, err := base64.StdEncoding.DecodeString(s)
-	if err != nil {
-		return nil, fmt.Errorf("failed to decode string: %w", err)
+func (m *sessionMultiplexer) rotations(ch chan string) {
+	for {
+		select {
+		case <-m.ctx.Done():
+			return
+		case msg := <-ch:
+			rotated, err := pubsub.DecodeCredentialsRotated(msg)
+			if err != nil {
+				m.log.Error("decode credentials rotated", teapot.Error(err))
+				continue
+			}
+			m.revoke(rotated)
+		}
 	}
+}
 
-	var cfg pubsub.RouteConfig
-	err = cfg.UnmarshalJSON(b64)
-	if err != nil {
-		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
-	}
+// revoke close connections of the tunnel authenticated with a signing key
+// other than the one issued by the rotation once its grace period ended
+func (m *sessionMultiplexer) revoke(rotated *pubsub.CredentialsRotated) {
+	m.mtx.Lock()
+	pool := m.tunnels[rotated.TunnelUID]
+	m.mtx.Unlock()
 
-	return &cfg, nil
+	for _, t := range pool.live() {
+		if t.keyID != rotated.KeyID {
+			m.log.Debug("schedule tunnel revocation",
+				teapot.String("tunnel", rotated.TunnelUID),
+				teapot.String("revoke_at", rotated.RevokeAt.String()))
+			t.revoke(rotated.RevokeAt)
+		}
+	}
 }
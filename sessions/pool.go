@@ -0,0 +1,57 @@
+package sessions
+
+import tunnelnet "soft.structx.io/dino/tunnel/net"
+
+// tunnelPool agent connections serving the same tunnel
+type tunnelPool []*activeTunnel
+
+// live connections still serving sessions
+func (p tunnelPool) live() tunnelPool {
+	var live tunnelPool
+	for _, t := range p {
+		if !t.isClosed() {
+			live = append(live, t)
+		}
+	}
+	return live
+}
+
+// without pool minus the connection of conn, nil when conn is not pooled
+func (p tunnelPool) without(conn tunnelnet.Conn) (tunnelPool, *activeTunnel) {
+	for i, t := range p {
+		if t.stream == conn {
+			rest := append(tunnelPool{}, p[:i]...)
+			return append(rest, p[i+1:]...), t
+		}
+	}
+	return p, nil
+}
+
+// load sessions open across all connections
+func (p tunnelPool) load() int {
+	total := 0
+	for _, t := range p {
+		total += t.load()
+	}
+	return total
+}
+
+// pick least loaded live connection, ties are broken starting at offset so
+// idle connections share new sessions round robin
+func (p tunnelPool) pick(offset uint64) *activeTunnel {
+	var (
+		picked *activeTunnel
+		least  int
+	)
+	for i := range p {
+		t := p[(offset+uint64(i))%uint64(len(p))]
+		if t.isClosed() {
+			continue
+		}
+
+		if load := t.load(); picked == nil || load < least {
+			picked, least = t, load
+		}
+	}
+	return picked
+}
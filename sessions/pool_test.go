@@ -0,0 +1,121 @@
+package sessions
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"soft.structx.io/dino/pubsub"
+	"soft.structx.io/dino/setup"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+type PoolSuite struct {
+	suite.Suite
+
+	pool tunnelPool
+}
+
+func (suite *PoolSuite) SetupTest() {
+	logger := teapot.New(teapot.WithWriter(io.Discard))
+
+	suite.pool = nil
+	for range 3 {
+		conn := &idleConn{closed: make(chan struct{})}
+		suite.pool = append(suite.pool, newActiveTunnel(logger, "tunnel", "key", conn))
+	}
+}
+
+func (suite *PoolSuite) TestPickLeastLoaded() {
+	_, err := suite.pool[0].registerSession("a", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+	_, err = suite.pool[2].registerSession("b", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	for offset := range uint64(3) {
+		suite.Same(suite.pool[1], suite.pool.pick(offset))
+	}
+}
+
+func (suite *PoolSuite) TestPickRoundRobinWhenIdle() {
+	for offset := range uint64(3) {
+		suite.Same(suite.pool[offset], suite.pool.pick(offset))
+	}
+}
+
+func (suite *PoolSuite) TestPickSkipsClosed() {
+	suite.pool[0].close(ErrTunnelClosed)
+	suite.pool[1].close(ErrTunnelClosed)
+
+	suite.Same(suite.pool[2], suite.pool.pick(0))
+	suite.Len(suite.pool.live(), 1)
+
+	suite.pool[2].close(ErrTunnelClosed)
+	suite.Nil(suite.pool.pick(0))
+}
+
+func (suite *PoolSuite) TestWithout() {
+	rest, removed := suite.pool.without(suite.pool[1].stream)
+	suite.Same(suite.pool[1], removed)
+	suite.Equal(tunnelPool{suite.pool[0], suite.pool[2]}, rest)
+
+	_, removed = rest.without(suite.pool[1].stream)
+	suite.Nil(removed)
+}
+
+func (suite *PoolSuite) TestWaitIdle() {
+	suite.NoError(waitIdle(context.Background(), suite.pool))
+
+	_, err := suite.pool[1].registerSession("open", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*drainPollInterval)
+	defer cancel()
+	suite.ErrorIs(waitIdle(ctx, suite.pool), context.DeadlineExceeded)
+
+	suite.Require().NoError(suite.pool[1].deregisterSession("open"))
+	suite.NoError(waitIdle(context.Background(), suite.pool))
+}
+
+func (suite *PoolSuite) TestDrainHooks() {
+	m := newMux(teapot.New(teapot.WithWriter(io.Discard)), nil, nil, nil, &setup.Server{}, tunnelnet.Timeouts{})
+	m.tunnels["tunnel"] = suite.pool
+
+	_, err := suite.pool[0].registerSession("pooled", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	// the hook closes the idle session drain would otherwise wait on
+	m.OnDrain(func() {
+		suite.NoError(suite.pool[0].deregisterSession("pooled"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	suite.NoError(m.Drain(ctx))
+	suite.Nil(suite.pool.pick(0))
+}
+
+func (suite *PoolSuite) TestRevokeRotatedKey() {
+	m := newMux(teapot.New(teapot.WithWriter(io.Discard)), nil, nil, nil, &setup.Server{}, tunnelnet.Timeouts{})
+	m.tunnels["tunnel"] = suite.pool
+
+	// the second connection authenticated with the key the rotation issued,
+	// whatever the order of its connect and the rotation
+	suite.pool[0].keyID = "previous"
+	suite.pool[1].keyID = "rotated"
+	suite.pool[2].keyID = "older"
+
+	revokeAt := time.Now().Add(time.Hour)
+	m.revoke(&pubsub.CredentialsRotated{TunnelUID: "tunnel", KeyID: "rotated", RevokeAt: revokeAt})
+
+	suite.True(suite.pool[0].revoked(revokeAt))
+	suite.False(suite.pool[1].revoked(revokeAt))
+	suite.True(suite.pool[2].revoked(revokeAt))
+}
+
+func TestPoolSuite(t *testing.T) {
+	suite.Run(t, new(PoolSuite))
+}
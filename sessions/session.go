@@ -3,38 +3,109 @@ package sessions
 import (
 	"fmt"
 	"io"
+	"net"
+	"sync"
+	"time"
 
 	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
+// datagramBufferSize udp packets queued per session before newer ones are dropped
+const datagramBufferSize = 64
+
 type activeSession struct {
 	streamID  string
 	sessionID string
-	inbound   chan []byte
 	outbound  tunnelnet.Conn
+
+	// stream ordered, flow controlled payloads of tcp and http sessions
+	stream *tunnelnet.Stream
+	// datagrams udp packets, dropped when the reader falls behind
+	datagrams chan []byte
+	// dedicated transport stream carrying payloads instead of data frames
+	dedicated io.ReadWriteCloser
+
+	// done closed once either side ended the session
+	done      chan struct{}
+	closeOnce sync.Once
+	// err cause of a session aborted with its tunnel, nil when closed normally
+	err error
+
+	// deadline idle and lifetime limits, reaped by the tunnel once expired
+	deadline *tunnelnet.Deadline
+
+	// datagram every write is sent as a single packet
+	datagram bool
+}
+
+func newActiveSession(streamID, sessionID string, outbound tunnelnet.Conn, dedicated io.ReadWriteCloser, timeouts tunnelnet.Timeouts) *activeSession {
+	return &activeSession{
+		streamID:  streamID,
+		sessionID: sessionID,
+		outbound:  outbound,
+		dedicated: dedicated,
+		stream:    tunnelnet.NewStream(outbound, sessionID, tunnelnet.DefaultWindow),
+		datagrams: make(chan []byte, datagramBufferSize),
+		done:      make(chan struct{}),
+		deadline:  tunnelnet.NewDeadline(timeouts, time.Now()),
+	}
 }
 
 // Write implements net.WriteCloser.
 func (a *activeSession) Write(p []byte) (n int, err error) {
-	fmt.Println("outbound.Write")
+	a.deadline.Touch()
+
+	if a.dedicated != nil {
+		n, err := a.dedicated.Write(p)
+		return n, a.failure(err)
+	}
+
+	if !a.datagram {
+		return a.stream.Write(p)
+	}
+
+	select {
+	case <-a.done:
+		return 0, a.failure(net.ErrClosed)
+	default:
+	}
+
 	return a.outbound.Write(&tunnelnet.DataFrame{
-		IsControlFrame: false,
-		NewConn:        nil,
-		RouteUpdate:    nil,
-		SessionID:      a.sessionID,
-		Payload:        p,
+		IsDatagram: true,
+		SessionID:  a.sessionID,
+		Payload:    p,
 	})
 }
 
+// CloseWrite implements tunnelnet.CloseWriter.
+func (a *activeSession) CloseWrite() error {
+	if a.dedicated != nil {
+		return tunnelnet.CloseWrite(a.dedicated)
+	}
+	if a.datagram {
+		return nil
+	}
+	return a.stream.CloseWrite()
+}
+
 // Close implements net.ReadCloser.
 func (a *activeSession) Close() error {
-	defer close(a.inbound)
+	return a.closeWithReason(tunnelnet.CloseNormal)
+}
+
+// closeWithReason end the session and tell the agent why, reads and writes
+// of an abnormal close fail with the reason
+func (a *activeSession) closeWithReason(reason tunnelnet.CloseReason) error {
+	cc := &tunnelnet.CloseConn{Status: 1, Reason: reason}
+	if !a.finish(cc.Err()) {
+		// agent closed the session first
+		return nil
+	}
+
 	if _, err := a.outbound.Write(&tunnelnet.DataFrame{
 		SessionID:      a.sessionID,
 		IsControlFrame: true,
-		CloseConn: &tunnelnet.CloseConn{
-			Status: 1,
-		},
+		CloseConn:      cc,
 	}); err != nil {
 		return fmt.Errorf("outbound.Write: %w", err)
 	}
@@ -43,19 +114,98 @@ func (a *activeSession) Close() error {
 
 // Read implements net.ReadCloser.
 func (a *activeSession) Read(p []byte) (n int, err error) {
-	msg, ok := <-a.inbound
-	if !ok {
-		return 0, io.EOF
+	defer func() {
+		if n > 0 {
+			a.deadline.Touch()
+		}
+	}()
+
+	if a.dedicated != nil {
+		n, err := a.dedicated.Read(p)
+		return n, a.failure(err)
+	}
+
+	if !a.datagram {
+		return a.stream.Read(p)
+	}
+
+	// datagrams are never split across reads
+	msg, err := tunnelnet.Next(a.datagrams, a.done)
+	if err != nil {
+		return 0, a.failure(err)
 	}
 	return copy(p, msg), nil
 }
 
-func (a *activeSession) openConn() error {
+// deliver hand a frame received from the agent to the session without
+// blocking the tunnel worker
+func (a *activeSession) deliver(df *tunnelnet.DataFrame) error {
+	if df.WindowUpdate != nil {
+		a.stream.Grant(df.WindowUpdate.Credit)
+		return nil
+	}
+	if df.Fin {
+		a.stream.Finish(df.Seq)
+		return nil
+	}
+
+	if !df.IsDatagram {
+		return a.stream.Deliver(df.Seq, df.Payload)
+	}
+
+	select {
+	case a.datagrams <- df.Payload:
+	case <-a.done:
+	default:
+		// udp tolerates loss, a slow reader must not stall the tunnel
+	}
+	return nil
+}
+
+// finish end the session locally, a non nil err is returned by pending and
+// later reads and writes. False when the session already ended.
+func (a *activeSession) finish(err error) bool {
+	finished := false
+	a.closeOnce.Do(func() {
+		a.err = err
+		close(a.done)
+		_ = a.stream.CloseWithError(err)
+		if a.dedicated != nil {
+			_ = a.dedicated.Close()
+		}
+		finished = true
+	})
+	return finished
+}
+
+// failure replace err with the abort cause once the session was aborted
+func (a *activeSession) failure(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	select {
+	case <-a.done:
+		if a.err != nil {
+			return a.err
+		}
+	default:
+	}
+	return err
+}
+
+func (a *activeSession) openConn(target Target) error {
+	a.datagram = target.Protocol == "udp"
 	_, err := a.outbound.Write(&tunnelnet.DataFrame{
 		SessionID:      a.sessionID,
 		IsControlFrame: true,
 		NewConn: &tunnelnet.NewConn{
-			Hostname: "whoami.dino.local",
+			Hostname: target.Hostname,
+			Protocol: target.Protocol,
+			RouteID:  target.RouteID,
+			Label:    target.Label,
+			Stream:   a.dedicated != nil,
+			Timeouts: target.Timeouts,
 		},
 	})
 	return err
@@ -63,3 +213,4 @@ func (a *activeSession) openConn() error {
 
 var _ tunnelnet.ReadCloser = (*activeSession)(nil)
 var _ tunnelnet.WriteCloser = (*activeSession)(nil)
+var _ tunnelnet.CloseWriter = (*activeSession)(nil)
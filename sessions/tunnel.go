@@ -1,91 +1,267 @@
 package sessions
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"sync"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/structx/teapot"
 	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
 type activeTunnel struct {
+	log *teapot.Logger
+
 	streamID string
 	mtx      sync.Mutex
 	sessions map[string]*activeSession
 	stream   tunnelnet.Conn
+
+	// heartbeat pings awaiting a pong
+	heartbeat *heartbeat
+	// rtts round trips of answered pings, only the latest is kept
+	rtts chan time.Duration
+
+	// keyID fingerprint of the signing key the agent authenticated with
+	keyID string
+	// revokeAt time the credentials of the connection stop being valid,
+	// zero while they are current
+	revokeAt time.Time
+
+	// done closed once the agent disconnected or the tunnel was replaced
+	done      chan struct{}
+	closed    bool
+	closeOnce sync.Once
+}
+
+func newActiveTunnel(logger *teapot.Logger, tunnelUID, keyID string, conn tunnelnet.Conn) *activeTunnel {
+	return &activeTunnel{
+		log:       logger,
+		streamID:  tunnelUID,
+		mtx:       sync.Mutex{},
+		sessions:  make(map[string]*activeSession),
+		stream:    conn,
+		heartbeat: newHeartbeat(),
+		rtts:      make(chan time.Duration, 1),
+		keyID:     keyID,
+		done:      make(chan struct{}),
+	}
 }
 
 func (a *activeTunnel) worker() {
 	for {
 		df, err := a.stream.Read()
 		if err != nil {
-			if err == io.EOF {
-				return
-			}
-			fmt.Println(err)
+			a.log.Debug("tunnel stream read", teapot.String("tunnel", a.streamID), teapot.Error(err))
+			a.close(ErrTunnelClosed)
 			return
 		}
 
+		if df.Pong != nil {
+			if rtt, ok := a.heartbeat.pong(df.Pong.Nonce); ok {
+				select {
+				case a.rtts <- rtt:
+				default:
+					// previous round trip not recorded yet
+				}
+			}
+			continue
+		}
+
 		a.mtx.Lock()
 		session, ok := a.sessions[df.SessionID]
+		if ok && df.CloseConn != nil {
+			delete(a.sessions, df.SessionID)
+		}
 		a.mtx.Unlock()
 
 		if !ok {
-			fmt.Println("no session")
+			// frames still in flight for a session closed by the server
+			continue
+		}
+
+		if df.CloseConn != nil {
+			// abnormal reasons surface to the proxy through reads and writes
+			session.finish(df.CloseConn.Err())
+			continue
+		}
+
+		if err := session.deliver(df); err != nil {
+			a.log.Error("deliver session frame", teapot.String("session", df.SessionID), teapot.Error(err))
+			if err := a.deregisterSession(df.SessionID); err != nil {
+				a.log.Error("deregister session", teapot.Error(err))
+			}
+		}
+	}
+}
+
+// close abort every session with err, sessions opened afterwards are refused
+func (a *activeTunnel) close(err error) {
+	a.closeOnce.Do(func() {
+		a.mtx.Lock()
+		a.closed = true
+		sessions := a.sessions
+		a.sessions = make(map[string]*activeSession)
+		a.mtx.Unlock()
+
+		close(a.done)
+
+		for _, session := range sessions {
+			session.finish(err)
+		}
+	})
+}
+
+// reaper close sessions past their limits every interval until the tunnel closes
+func (a *activeTunnel) reaper(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.done:
 			return
+		case now := <-ticker.C:
+			if a.revoked(now) {
+				a.log.Info("revoke tunnel connection", teapot.String("tunnel", a.streamID))
+				a.close(ErrCredentialsRevoked)
+				return
+			}
+			a.reap(now)
 		}
+	}
+}
+
+// revoke close the connection once at has passed, an earlier revocation is kept
+func (a *activeTunnel) revoke(at time.Time) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
 
-		session.inbound <- df.Payload
+	if a.revokeAt.IsZero() || at.Before(a.revokeAt) {
+		a.revokeAt = at
 	}
 }
 
-func (a *activeTunnel) registerSession() *activeSession {
-	sessionID := uuid.New().String()
-	ch := make(chan []byte)
-	session := &activeSession{
-		streamID:  a.streamID,
-		sessionID: sessionID,
-		inbound:   ch,
-		outbound:  a.stream,
+// revoked true once the credentials of the connection are no longer valid at now
+func (a *activeTunnel) revoked(now time.Time) bool {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return !a.revokeAt.IsZero() && !now.Before(a.revokeAt)
+}
+
+// reap close every session expired at now, the agent is sent the reason
+func (a *activeTunnel) reap(now time.Time) {
+	type expiry struct {
+		session *activeSession
+		reason  tunnelnet.CloseReason
 	}
 
 	a.mtx.Lock()
-	a.sessions[sessionID] = session
+	var expired []expiry
+	for sessionID, session := range a.sessions {
+		if reason, ok := session.deadline.Expired(now); ok {
+			expired = append(expired, expiry{session, reason})
+			delete(a.sessions, sessionID)
+		}
+	}
 	a.mtx.Unlock()
 
-	return session
+	for _, e := range expired {
+		a.log.Info("reap session",
+			teapot.String("tunnel", a.streamID),
+			teapot.String("session", e.session.sessionID),
+			teapot.String("reason", e.reason.String()))
+		if err := e.session.closeWithReason(e.reason); err != nil {
+			a.log.Error("close expired session", teapot.String("session", e.session.sessionID), teapot.Error(err))
+		}
+	}
 }
 
-func (a *activeTunnel) deregisterSession(sessionID string) error {
+// load sessions currently open on the connection
+func (a *activeTunnel) load() int {
 	a.mtx.Lock()
+	defer a.mtx.Unlock()
+	return len(a.sessions)
+}
 
-	session, ok := a.sessions[sessionID]
-	if !ok {
-		// session is not active
-		return nil
+// isClosed true once the tunnel stopped serving sessions
+func (a *activeTunnel) isClosed() bool {
+	select {
+	case <-a.done:
+		return true
+	default:
+		return false
 	}
-	a.mtx.Unlock()
+}
 
-	// close inbound channel
-	defer close(session.inbound)
+func (a *activeTunnel) registerSession(sessionID string, dedicated io.ReadWriteCloser, timeouts tunnelnet.Timeouts) (*activeSession, error) {
+	session := newActiveSession(a.streamID, sessionID, a.stream, dedicated, timeouts)
 
-	// remove session from map
-	defer delete(a.sessions, sessionID)
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
 
-	// send close connection signal
-	if _, err := a.stream.Write(&tunnelnet.DataFrame{
-		SessionID:      sessionID,
-		IsControlFrame: true,
-		CloseConn: &tunnelnet.CloseConn{
-			Status: 1,
-		},
-		NewConn:     nil,
-		Payload:     nil,
-		RouteUpdate: nil,
-	}); err != nil {
-		return fmt.Errorf("stream.Write: %w", err)
+	if a.closed {
+		return nil, ErrTunnelClosed
+	}
+	a.sessions[sessionID] = session
+
+	return session, nil
+}
+
+// openSession register a session for target and ask the agent to dial it
+func (a *activeTunnel) openSession(ctx context.Context, target Target) (*activeSession, error) {
+	// opening a stream waits on the peer stream limit, other sessions must not
+	sessionID := uuid.New().String()
+	dedicated, err := a.openStream(ctx, sessionID, target.Protocol)
+	if err != nil {
+		return nil, fmt.Errorf("open session stream: %w", err)
 	}
 
-	return nil
+	session, err := a.registerSession(sessionID, dedicated, target.Timeouts)
+	if err != nil {
+		if dedicated != nil {
+			_ = dedicated.Close()
+		}
+		return nil, fmt.Errorf("register session: %w", err)
+	}
+
+	if err := session.openConn(target); err != nil {
+		_ = a.deregisterSession(sessionID)
+		return nil, fmt.Errorf("open session connection: %w", err)
+	}
+
+	return session, nil
+}
+
+// openStream dedicated transport stream of a session, nil when payloads use data frames
+func (a *activeTunnel) openStream(ctx context.Context, sessionID, protocol string) (io.ReadWriteCloser, error) {
+	sc, ok := a.stream.(tunnelnet.StreamConn)
+	if !ok || protocol == "udp" {
+		// datagrams stay on the tunnel stream, quic streams are ordered and reliable
+		return nil, nil
+	}
+
+	rwc, err := sc.OpenStream(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("sc.OpenStream: %w", err)
+	}
+	return rwc, nil
+}
+
+func (a *activeTunnel) deregisterSession(sessionID string) error {
+	a.mtx.Lock()
+	session, ok := a.sessions[sessionID]
+	delete(a.sessions, sessionID)
+	a.mtx.Unlock()
+
+	if !ok {
+		// session was closed by the agent
+		return nil
+	}
+
+	// send close connection signal
+	return session.Close()
 }
@@ -1 +1,143 @@
 package sessions
+
+import (
+	"context"
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// idleConn agent connection that never sends a frame
+type idleConn struct {
+	closed chan struct{}
+
+	mtx     sync.Mutex
+	written []*tunnelnet.DataFrame
+}
+
+// interface compliance
+var _ tunnelnet.Conn = (*idleConn)(nil)
+
+// Read implements tunnelnet.Conn.
+func (c *idleConn) Read() (*tunnelnet.DataFrame, error) {
+	<-c.closed
+	return nil, io.EOF
+}
+
+// Write implements tunnelnet.Conn.
+func (c *idleConn) Write(df *tunnelnet.DataFrame) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.written = append(c.written, df)
+	return len(df.Payload), nil
+}
+
+// Close implements tunnelnet.Conn.
+func (c *idleConn) Close(string) error { return nil }
+
+type TunnelSuite struct {
+	suite.Suite
+
+	conn   *idleConn
+	tunnel *activeTunnel
+}
+
+func (suite *TunnelSuite) SetupTest() {
+	suite.conn = &idleConn{closed: make(chan struct{})}
+	suite.tunnel = newActiveTunnel(teapot.New(teapot.WithWriter(io.Discard)), "tunnel", "key", suite.conn)
+}
+
+func (suite *TunnelSuite) TestCloseAbortsSessions() {
+	session, err := suite.tunnel.registerSession("session", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	suite.tunnel.close(ErrTunnelClosed)
+
+	_, err = session.Read(make([]byte, 8))
+	suite.ErrorIs(err, ErrTunnelClosed)
+	_, err = session.Write([]byte("late"))
+	suite.ErrorIs(err, ErrTunnelClosed)
+
+	_, err = suite.tunnel.registerSession("next", nil, tunnelnet.Timeouts{})
+	suite.ErrorIs(err, ErrTunnelClosed)
+}
+
+func (suite *TunnelSuite) TestOpenSessionTimeouts() {
+	timeouts := tunnelnet.Timeouts{Idle: time.Minute, MaxLifetime: time.Hour}
+	session, err := suite.tunnel.openSession(context.Background(), Target{
+		TunnelUID: "tunnel",
+		Hostname:  "web.dino.local",
+		Protocol:  "http",
+		Timeouts:  timeouts,
+	})
+	suite.Require().NoError(err)
+
+	suite.conn.mtx.Lock()
+	defer suite.conn.mtx.Unlock()
+	suite.Require().Len(suite.conn.written, 1)
+
+	df := suite.conn.written[0]
+	suite.Equal(session.sessionID, df.SessionID)
+	suite.Require().NotNil(df.NewConn)
+	suite.Equal(timeouts, df.NewConn.Timeouts)
+}
+
+func (suite *TunnelSuite) TestDisconnectClosesTunnel() {
+	go suite.tunnel.worker()
+	close(suite.conn.closed)
+
+	<-suite.tunnel.done
+	suite.True(suite.tunnel.isClosed())
+}
+
+func (suite *TunnelSuite) TestReapExpiredSessions() {
+	idle, err := suite.tunnel.registerSession("idle", nil, tunnelnet.Timeouts{Idle: time.Minute})
+	suite.Require().NoError(err)
+	aged, err := suite.tunnel.registerSession("aged", nil, tunnelnet.Timeouts{MaxLifetime: time.Hour})
+	suite.Require().NoError(err)
+	_, err = suite.tunnel.registerSession("open", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	suite.tunnel.reap(time.Now().Add(2 * time.Minute))
+	suite.Equal(2, suite.tunnel.load())
+
+	suite.tunnel.reap(time.Now().Add(2 * time.Hour))
+	suite.Equal(1, suite.tunnel.load())
+
+	var closeErr *tunnelnet.CloseError
+	_, err = idle.Read(make([]byte, 8))
+	suite.Require().ErrorAs(err, &closeErr)
+	suite.Equal(tunnelnet.CloseIdleTimeout, closeErr.Reason)
+
+	_, err = aged.Write([]byte("late"))
+	suite.Require().ErrorAs(err, &closeErr)
+	suite.Equal(tunnelnet.CloseMaxLifetime, closeErr.Reason)
+}
+
+func (suite *TunnelSuite) TestRevokeCredentials() {
+	now := time.Now()
+	suite.tunnel.revoke(now.Add(time.Hour))
+	suite.tunnel.revoke(now.Add(2 * time.Hour))
+	suite.False(suite.tunnel.revoked(now))
+	suite.True(suite.tunnel.revoked(now.Add(time.Hour)))
+
+	session, err := suite.tunnel.registerSession("session", nil, tunnelnet.Timeouts{})
+	suite.Require().NoError(err)
+
+	suite.tunnel.revoke(now)
+	go suite.tunnel.reaper(time.Millisecond)
+
+	<-suite.tunnel.done
+	_, err = session.Read(make([]byte, 8))
+	suite.ErrorIs(err, ErrCredentialsRevoked)
+}
+
+func TestTunnelSuite(t *testing.T) {
+	suite.Run(t, new(TunnelSuite))
+}
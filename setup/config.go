@@ -51,6 +51,34 @@ type Proxy struct {
 	ReadHeaderTimeout time.Duration `env:"READ_HEADER_TIMEOUT, default=15s"`
 	WriteTimeout      time.Duration `env:"WRITE_TIMEOUT, default=15s"`
 	IdleTimeout       time.Duration `env:"IDLE_TIMEOUT, default=30s"`
+
+	// ResponseHeaderTimeout max wait for tunneled response headers
+	ResponseHeaderTimeout time.Duration `env:"RESPONSE_HEADER_TIMEOUT, default=15s"`
+
+	// UDPIdleTimeout udp source address session expiry, must be positive
+	UDPIdleTimeout time.Duration `env:"UDP_IDLE_TIMEOUT, default=60s"`
+
+	// SessionIdleTimeout tunnel session without traffic, routes may override
+	SessionIdleTimeout time.Duration `env:"SESSION_IDLE_TIMEOUT, default=10m"`
+	// SessionMaxLifetime tunnel session max duration, 0 is unlimited
+	SessionMaxLifetime time.Duration `env:"SESSION_MAX_LIFETIME, default=0s"`
+
+	TLSEnabled bool   `env:"TLS_ENABLED, default=false"`
+	TLSPort    string `env:"TLS_PORT, default=8443"`
+	// TLSCertPath default certificate when no route certificate matches
+	TLSCertPath string `env:"TLS_CERT_PATH"`
+	TLSKeyPath  string `env:"TLS_KEY_PATH"`
+
+	// PassthroughPort tls passthrough listener, disabled when empty
+	PassthroughPort string `env:"PASSTHROUGH_PORT"`
+
+	ACMEEnabled      bool   `env:"ACME_ENABLED, default=false"`
+	ACMEEmail        string `env:"ACME_EMAIL"`
+	ACMEDirectoryURL string `env:"ACME_DIRECTORY_URL, default=https://acme-v02.api.letsencrypt.org/directory"`
+	// ACMECAPath trusted roots for a test directory like pebble
+	ACMECAPath string `env:"ACME_CA_PATH"`
+	// ACMEHosts subdomains of wildcard routes certificates are issued for, exact routes are always issued
+	ACMEHosts []string `env:"ACME_HOSTS"`
 }
 
 // Server
@@ -61,8 +89,27 @@ type Server struct {
 	QuicHost string `env:"QUIC_HOST, default=127.0.0.1"`
 	QuicPort string `env:"QUIC_PORT, default=4242"`
 
-	CertPath string `env:"SSL_CERT_PATH"`
-	KeyPath  string `env:"SSL_KEY_PATH"`
+	// EnrollPort tls port on QuicHost agents enroll on before they hold a client certificate
+	EnrollPort string `env:"ENROLL_PORT, default=4243"`
+
+	// CertLifetime of the tunnel gateway and agent certificates issued by the internal ca
+	CertLifetime time.Duration `env:"CERT_LIFETIME, default=24h"`
+	// CertHostnames names and addresses agents dial the tunnel gateway with
+	CertHostnames []string `env:"CERT_HOSTNAMES, default=tunnel.dino.local,localhost,127.0.0.1"`
+
+	// DuplicateTunnel second connection of a connected tunnel, balance, replace or reject
+	DuplicateTunnel string `env:"DUPLICATE_TUNNEL, default=balance"`
+
+	// HeartbeatInterval between pings sent to every agent, 0 disables heartbeats
+	HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL, default=15s"`
+	// HeartbeatMisses unanswered pings before an agent connection is closed
+	HeartbeatMisses int `env:"HEARTBEAT_MISSES, default=3"`
+
+	// DrainTimeout max wait for open sessions to finish on shutdown
+	DrainTimeout time.Duration `env:"DRAIN_TIMEOUT, default=10s"`
+
+	// Compression data frame compression agents may negotiate, empty disables it
+	Compression []string `env:"COMPRESSION, default=zstd,gzip"`
 }
 
 // JWT
@@ -72,9 +119,20 @@ type JWT struct {
 	Audience []string `env:"AUD,default=dino"`
 }
 
+// Lifetime token duration, zero when tokens do not expire
+func (j *JWT) Lifetime() time.Duration {
+	if j.Duration <= 0 {
+		return 0
+	}
+	return time.Duration(j.Duration) * time.Second
+}
+
 // Authenticator
 type Authenticator struct {
 	JWT *JWT `env:",prefix=JWT_"`
+
+	// MasterKey base64 encoded 32 byte key sealing tunnel signing keys at rest
+	MasterKey string `env:"MASTER_KEY"`
 }
 
 // Tunnel
@@ -82,6 +140,36 @@ type Tunnel struct {
 	ID       string `env:"ID"`
 	Token    string `env:"TOKEN"`
 	Endpoint string `env:"ENDPOINT, default=tunnel.dino.local:4242"`
+
+	// TokenFile persists refreshed tokens, a stored token newer than TOKEN is used instead
+	TokenFile string `env:"TOKEN_FILE"`
+
+	// EnrollEndpoint tunnel gateway port the agent requests its client certificate from
+	EnrollEndpoint string `env:"ENROLL_ENDPOINT, default=tunnel.dino.local:4243"`
+	// CertDir persists the agent key, certificate and pinned ca, kept in memory when empty
+	CertDir string `env:"CERT_DIR"`
+	// CAFingerprint sha256 of the internal ca certificate, pins the ca when CertDir holds none
+	CAFingerprint string `env:"CA_FINGERPRINT"`
+
+	// WildcardPorts local port of each wildcard route label, feature-1:3001,feature-2:3002
+	WildcardPorts map[string]string `env:"WILDCARD_PORTS"`
+
+	// SessionStreams carry each session on its own quic stream instead of the tunnel stream
+	SessionStreams bool `env:"SESSION_STREAMS, default=false"`
+
+	// DialTimeout max wait for a local backend to accept a session, 0 waits for the os connect timeout
+	DialTimeout time.Duration `env:"DIAL_TIMEOUT, default=10s"`
+
+	// ReconnectBackoff first delay before re-dialing a dropped tunnel, doubled per failed attempt
+	ReconnectBackoff time.Duration `env:"RECONNECT_BACKOFF, default=500ms"`
+	// ReconnectBackoffMax upper bound of the reconnect delay
+	ReconnectBackoffMax time.Duration `env:"RECONNECT_BACKOFF_MAX, default=30s"`
+
+	// MetricsAddr serves session counters at /debug/vars, disabled when empty
+	MetricsAddr string `env:"METRICS_ADDR"`
+
+	// Compression data frame compression offered to the server in order of preference, zstd or gzip
+	Compression []string `env:"COMPRESSION"`
 }
 
 // Configs
@@ -5,6 +5,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"net"
+	"time"
 
 	"github.com/quic-go/quic-go"
 	"github.com/quic-go/quic-go/qlog"
@@ -12,6 +13,8 @@ import (
 	"go.uber.org/fx"
 	"go.uber.org/multierr"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"soft.structx.io/dino/pki"
 	"soft.structx.io/dino/setup"
 	"soft.structx.io/dino/tunnel/transport"
 )
@@ -23,6 +26,16 @@ type StreamServerInterceptor = grpc.StreamServerInterceptor
 type TunnelTransport struct {
 	Service     any
 	ServiceDesc *grpc.ServiceDesc
+
+	// Drain ends the open tunnels gracefully before the server stops, optional
+	Drain func(context.Context) error
+}
+
+// EnrollTransport served over tls without a client certificate, agents
+// enroll on it before they hold one
+type EnrollTransport struct {
+	Service     any
+	ServiceDesc *grpc.ServiceDesc
 }
 
 // Params
@@ -35,7 +48,10 @@ type Params struct {
 
 	Cfg *setup.Server
 
+	Authority pki.Authority
+
 	Transport   *TunnelTransport
+	Enroll      *EnrollTransport
 	Interceptor StreamServerInterceptor
 }
 
@@ -43,17 +59,15 @@ type Params struct {
 var Module = fx.Module("tunnel_gateway", fx.Invoke(invokeModule))
 
 func invokeModule(p Params) error {
-	tlsConfig, err := newTlsConfig(p.Cfg.CertPath, p.Cfg.KeyPath)
-	if err != nil {
-		return fmt.Errorf("newTlsConfig: %w", err)
-	}
+	tlsConfig := newTlsConfig(p.Authority)
 
 	// lis, err := net.Listen("tcp", ":4242")
 	// if err != nil {
 	// 	return fmt.Errorf("net.Listen: %w", err)
 	// }
 
-	s := grpc.NewServer()
+	// quic credentials expose the connection of each tunnel for session streams
+	s := grpc.NewServer(grpc.Creds(transport.NewCredentials(tlsConfig)))
 	s.RegisterService(p.Transport.ServiceDesc, p.Transport.Service)
 
 	// grpclog.SetLoggerV2(zapgrpc.NewLogger(p.Logger))
@@ -67,6 +81,15 @@ func invokeModule(p Params) error {
 	}
 	grpcQuicListener := transport.New(quicListener)
 
+	es := grpc.NewServer(grpc.Creds(credentials.NewTLS(newEnrollTlsConfig(p.Authority))))
+	es.RegisterService(p.Enroll.ServiceDesc, p.Enroll.Service)
+
+	enrollListener, err := net.Listen("tcp", net.JoinHostPort(p.Cfg.QuicHost, p.Cfg.EnrollPort))
+	if err != nil {
+		_ = quicListener.Close()
+		return fmt.Errorf("net.Listen: %w", err)
+	}
+
 	p.Lc.Append(fx.Hook{
 		OnStart: func(ctx context.Context) error {
 			p.Logger.Info("start gRPC-QUIC server", teapot.Any("server_addr", grpcQuicListener.Addr()))
@@ -75,13 +98,40 @@ func invokeModule(p Params) error {
 					p.Logger.Fatal("unable to start gRPC-QUIC server", teapot.Error(err))
 				}
 			}()
+
+			p.Logger.Info("start enrollment server", teapot.Any("server_addr", enrollListener.Addr()))
+			go func() {
+				if err := es.Serve(enrollListener); err != nil {
+					p.Logger.Fatal("unable to start enrollment server", teapot.Error(err))
+				}
+			}()
 			return nil
 		},
 		OnStop: func(ctx context.Context) error {
 			var multiErr error
+
+			if p.Transport.Drain != nil {
+				p.Logger.Info("drain tunnels", teapot.String("timeout", p.Cfg.DrainTimeout.String()))
+				drainCtx, cancel := context.WithTimeout(ctx, p.Cfg.DrainTimeout)
+				if err := p.Transport.Drain(drainCtx); err != nil {
+					multiErr = multierr.Append(multiErr, fmt.Errorf("drain: %w", err))
+				}
+				cancel()
+			}
+
+			p.Logger.Info("shutdown enrollment server")
+			es.Stop()
+
+			p.Logger.Info("shutdown gRPC-QUIC server")
+			timer := time.AfterFunc(time.Second*10, func() {
+				s.Stop()
+			})
+			defer timer.Stop()
+			s.GracefulStop()
+
 			p.Logger.Info("close quic listener")
-			if err := grpcQuicListener.Close(); err != nil {
-				multiErr = multierr.Append(err, fmt.Errorf("lis.Close: %w", err))
+			if err := quicListener.Close(); err != nil {
+				multiErr = multierr.Append(multiErr, fmt.Errorf("lis.Close: %w", err))
 			}
 			return multiErr
 		},
@@ -90,14 +140,22 @@ func invokeModule(p Params) error {
 	return nil
 }
 
-func newTlsConfig(certFile, keyFile string) (*tls.Config, error) {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
-	if err != nil {
-		return nil, fmt.Errorf("tls.LoadX509KeyPair: %w", err)
+// newTlsConfig mutual tls, agents present a client certificate issued by the internal ca
+func newTlsConfig(authority pki.Authority) *tls.Config {
+	return &tls.Config{
+		GetCertificate: authority.ServerCertificate,
+		ClientAuth:     tls.RequireAndVerifyClientCert,
+		ClientCAs:      authority.Pool(),
+		NextProtos:     []string{"h3"},
+		MinVersion:     tls.VersionTLS13,
 	}
+}
 
+// newEnrollTlsConfig server tls only, agents verify the gateway against the
+// pinned ca and authenticate with their tunnel token
+func newEnrollTlsConfig(authority pki.Authority) *tls.Config {
 	return &tls.Config{
-		Certificates: []tls.Certificate{cert},
-		NextProtos:   []string{"h3"},
-	}, nil
+		GetCertificate: authority.ServerCertificate,
+		MinVersion:     tls.VersionTLS13,
+	}
 }
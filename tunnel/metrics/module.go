@@ -0,0 +1,62 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/structx/teapot"
+	"go.uber.org/fx"
+	"soft.structx.io/dino/setup"
+)
+
+// Params
+type Params struct {
+	fx.In
+
+	Lc fx.Lifecycle
+
+	Logger *teapot.Logger
+
+	Cfg *setup.Tunnel
+}
+
+// Module serves agent counters at /debug/vars when a metrics address is set
+var Module = fx.Module("tunnel_metrics", fx.Invoke(newModule))
+
+func newModule(p Params) {
+	if p.Cfg.MetricsAddr == "" {
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+
+	srv := &http.Server{
+		Addr:              p.Cfg.MetricsAddr,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	p.Lc.Append(fx.Hook{
+		OnStart: func(ctx context.Context) error {
+			ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", srv.Addr)
+			if err != nil {
+				return fmt.Errorf("failed to listen on metrics address: %w", err)
+			}
+
+			go func() {
+				if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+					p.Logger.Error("serve metrics", teapot.Error(err))
+				}
+			}()
+			p.Logger.Info("serving metrics", teapot.String("addr", ln.Addr().String()))
+			return nil
+		},
+		OnStop: srv.Shutdown,
+	})
+}
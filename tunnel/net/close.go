@@ -0,0 +1,102 @@
+package net
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// CloseReason why a session ended, values match the wire enum
+type CloseReason int32
+
+const (
+	// CloseUnspecified peer gave no reason, handled like a normal close
+	CloseUnspecified CloseReason = iota
+	// CloseNormal either side finished the session
+	CloseNormal
+	// CloseRouteNotFound agent has no route for the session
+	CloseRouteNotFound
+	// CloseDialRefused backend refused or could not be reached
+	CloseDialRefused
+	// CloseDialTimeout backend did not accept the connection in time
+	CloseDialTimeout
+	// CloseBackendReset backend reset the connection mid session
+	CloseBackendReset
+	// CloseIdleTimeout session carried no traffic for too long
+	CloseIdleTimeout
+	// ClosePolicyDenied session was refused by policy
+	ClosePolicyDenied
+	// CloseMaxLifetime session outlived its max duration
+	CloseMaxLifetime
+	// CloseInternal session failed on the agent before reaching the backend
+	CloseInternal
+)
+
+// String implements fmt.Stringer.
+func (r CloseReason) String() string {
+	switch r {
+	case CloseUnspecified, CloseNormal:
+		return "normal"
+	case CloseRouteNotFound:
+		return "route not found"
+	case CloseDialRefused:
+		return "dial refused"
+	case CloseDialTimeout:
+		return "dial timeout"
+	case CloseBackendReset:
+		return "backend reset"
+	case CloseIdleTimeout:
+		return "idle timeout"
+	case ClosePolicyDenied:
+		return "policy denied"
+	case CloseMaxLifetime:
+		return "max lifetime"
+	case CloseInternal:
+		return "internal"
+	default:
+		return "unknown"
+	}
+}
+
+// CloseError session ended by the peer for a reason other than a normal close
+type CloseError struct {
+	Reason  CloseReason
+	Message string
+}
+
+// Error implements error.
+func (e *CloseError) Error() string {
+	if e.Message == "" {
+		return "session closed: " + e.Reason.String()
+	}
+	return "session closed: " + e.Reason.String() + ": " + e.Message
+}
+
+// Err error of a close frame, nil for a normal close
+func (c *CloseConn) Err() error {
+	if c.Reason == CloseUnspecified || c.Reason == CloseNormal {
+		return nil
+	}
+	return &CloseError{Reason: c.Reason, Message: c.Message}
+}
+
+// DialReason close reason of a failed session start, only dial errors
+// are blamed on the backend
+func DialReason(err error) CloseReason {
+	var opErr *net.OpError
+	if !errors.As(err, &opErr) || opErr.Op != "dial" {
+		return CloseInternal
+	}
+	if opErr.Timeout() {
+		return CloseDialTimeout
+	}
+	return CloseDialRefused
+}
+
+// CopyReason close reason of a session whose backend side ended with err
+func CopyReason(err error) CloseReason {
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return CloseBackendReset
+	}
+	return CloseNormal
+}
@@ -0,0 +1,229 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// CompressionKey metadata key agents offer compression modes with, the
+// server answers with the negotiated mode under the same key
+const CompressionKey = "compression"
+
+// minCompressSize payloads smaller than this are sent as is
+const minCompressSize = 256
+
+// maxDecodedSize largest payload a compressed frame may expand to
+const maxDecodedSize = 4 * maxFrameSize
+
+// ErrDecodedSize compressed frame expands past maxDecodedSize
+var ErrDecodedSize = errors.New("decompressed payload too large")
+
+// ErrCompressionMismatch frame is compressed with a mode other than the
+// one negotiated for the stream
+var ErrCompressionMismatch = errors.New("compression mode not negotiated")
+
+// Compression encoding of data frame payloads, values match the wire enum
+type Compression int32
+
+const (
+	// CompressionNone payloads are sent as is
+	CompressionNone Compression = iota
+	// CompressionGzip payloads are gzip compressed
+	CompressionGzip
+	// CompressionZstd payloads are zstd compressed
+	CompressionZstd
+)
+
+// String implements fmt.Stringer.
+func (c Compression) String() string {
+	switch c {
+	case CompressionGzip:
+		return "gzip"
+	case CompressionZstd:
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// ParseCompression mode named s, empty is none
+func ParseCompression(s string) (Compression, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "none":
+		return CompressionNone, nil
+	case "gzip":
+		return CompressionGzip, nil
+	case "zstd":
+		return CompressionZstd, nil
+	default:
+		return CompressionNone, fmt.Errorf("unsupported compression %q", s)
+	}
+}
+
+// Negotiate first mode offered by the agent that the server allows, none
+// when they share no mode
+func Negotiate(offered []string, allowed []Compression) Compression {
+	for _, o := range offered {
+		mode, err := ParseCompression(o)
+		if err != nil || mode == CompressionNone {
+			continue
+		}
+		for _, a := range allowed {
+			if a == mode {
+				return mode
+			}
+		}
+	}
+	return CompressionNone
+}
+
+// Codec compresses data frame payloads of a tunnel stream, a nil codec
+// sends payloads as is and only accepts uncompressed frames
+type Codec struct {
+	mode Compression
+
+	zenc *zstd.Encoder
+	gzw  sync.Pool
+}
+
+// NewCodec codec compressing with mode, nil for none
+func NewCodec(mode Compression) (*Codec, error) {
+	switch mode {
+	case CompressionNone:
+		return nil, nil
+	case CompressionGzip:
+		return &Codec{mode: mode}, nil
+	case CompressionZstd:
+		enc, err := zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedFastest), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, fmt.Errorf("zstd.NewWriter: %w", err)
+		}
+		return &Codec{mode: mode, zenc: enc}, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", mode)
+	}
+}
+
+// Mode compression of sent payloads
+func (c *Codec) Mode() Compression {
+	if c == nil {
+		return CompressionNone
+	}
+	return c.mode
+}
+
+// Compress encoded p and its mode, p is returned as is when it is small,
+// already compressed or does not shrink
+func (c *Codec) Compress(p []byte) ([]byte, Compression) {
+	if c == nil || len(p) < minCompressSize || isCompressed(p) {
+		return p, CompressionNone
+	}
+
+	var out []byte
+	switch c.mode {
+	case CompressionZstd:
+		out = c.zenc.EncodeAll(p, make([]byte, 0, len(p)))
+	case CompressionGzip:
+		var buf bytes.Buffer
+		buf.Grow(len(p))
+
+		w, ok := c.gzw.Get().(*gzip.Writer)
+		if !ok {
+			w, _ = gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+		} else {
+			w.Reset(&buf)
+		}
+		_, werr := w.Write(p)
+		cerr := w.Close()
+		c.gzw.Put(w)
+		if werr != nil || cerr != nil {
+			return p, CompressionNone
+		}
+		out = buf.Bytes()
+	default:
+		return p, CompressionNone
+	}
+
+	// encrypted and media payloads barely shrink, the peer skips decoding them
+	if len(out) >= len(p)-len(p)/8 {
+		return p, CompressionNone
+	}
+	return out, c.mode
+}
+
+// zdec shared by every stream, DecodeAll is safe for concurrent use
+var zdec, _ = zstd.NewReader(nil, zstd.WithDecoderConcurrency(0), zstd.WithDecoderMaxMemory(maxDecodedSize))
+
+// Decompress payload p encoded with mode, only the negotiated mode and
+// none are accepted
+func (c *Codec) Decompress(mode Compression, p []byte) ([]byte, error) {
+	if mode != CompressionNone && mode != c.Mode() {
+		return nil, fmt.Errorf("%w: %s", ErrCompressionMismatch, mode)
+	}
+	return decompress(mode, p)
+}
+
+// decompress payload p encoded with mode
+func decompress(mode Compression, p []byte) ([]byte, error) {
+	switch mode {
+	case CompressionNone:
+		return p, nil
+	case CompressionZstd:
+		out, err := zdec.DecodeAll(p, nil)
+		if err != nil {
+			return nil, fmt.Errorf("zstd.DecodeAll: %w", err)
+		}
+		if len(out) > maxDecodedSize {
+			return nil, ErrDecodedSize
+		}
+		return out, nil
+	case CompressionGzip:
+		r, err := gzip.NewReader(bytes.NewReader(p))
+		if err != nil {
+			return nil, fmt.Errorf("gzip.NewReader: %w", err)
+		}
+		defer func() { _ = r.Close() }()
+
+		out, err := io.ReadAll(io.LimitReader(r, maxDecodedSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("gzip read: %w", err)
+		}
+		if len(out) > maxDecodedSize {
+			return nil, ErrDecodedSize
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported compression %d", mode)
+	}
+}
+
+// compressedMagic leading bytes of formats that do not compress further
+var compressedMagic = [][]byte{
+	{0x1f, 0x8b},                  // gzip
+	{0x28, 0xb5, 0x2f, 0xfd},      // zstd
+	{0x50, 0x4b, 0x03, 0x04},      // zip
+	{0x89, 'P', 'N', 'G'},         // png
+	{0xff, 0xd8, 0xff},            // jpeg
+	{'G', 'I', 'F', '8'},          // gif
+	{'R', 'I', 'F', 'F'},          // webp, wav
+	{'w', 'O', 'F', '2'},          // woff2
+	{0x42, 0x5a, 0x68},            // bzip2
+	{0xfd, '7', 'z', 'X', 'Z', 0}, // xz
+}
+
+// isCompressed p starts with the magic of an already compressed format
+func isCompressed(p []byte) bool {
+	for _, magic := range compressedMagic {
+		if bytes.HasPrefix(p, magic) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package net
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CompressSuite struct {
+	suite.Suite
+}
+
+func (suite *CompressSuite) TestRoundTrip() {
+	payload := jsonPayload(maxFrameSize)
+
+	for _, mode := range []Compression{CompressionGzip, CompressionZstd} {
+		codec, err := NewCodec(mode)
+		suite.Require().NoError(err)
+
+		out, used := codec.Compress(payload)
+		suite.Equal(mode, used, mode.String())
+		suite.Less(len(out), len(payload), mode.String())
+
+		decoded, err := codec.Decompress(used, out)
+		suite.Require().NoError(err)
+		suite.Equal(payload, decoded, mode.String())
+	}
+}
+
+func (suite *CompressSuite) TestSkipCompressed() {
+	codec, err := NewCodec(CompressionZstd)
+	suite.Require().NoError(err)
+
+	// already compressed content keeps its bytes
+	zipped, _ := codec.Compress(jsonPayload(maxFrameSize))
+	out, mode := codec.Compress(zipped)
+	suite.Equal(CompressionNone, mode)
+	suite.Equal(zipped, out)
+
+	// encrypted content does not shrink
+	random := make([]byte, maxFrameSize)
+	_, _ = rand.Read(random)
+	out, mode = codec.Compress(random)
+	suite.Equal(CompressionNone, mode)
+	suite.Equal(random, out)
+
+	small := []byte(`{"ok":true}`)
+	out, mode = codec.Compress(small)
+	suite.Equal(CompressionNone, mode)
+	suite.Equal(small, out)
+}
+
+func (suite *CompressSuite) TestDecodedSizeLimit() {
+	codec, err := NewCodec(CompressionGzip)
+	suite.Require().NoError(err)
+
+	bomb, mode := codec.Compress(bytes.Repeat([]byte{'a'}, maxDecodedSize+1))
+	suite.Require().Equal(CompressionGzip, mode)
+
+	_, err = codec.Decompress(mode, bomb)
+	suite.ErrorIs(err, ErrDecodedSize)
+}
+
+func (suite *CompressSuite) TestDecompressNegotiated() {
+	gz, err := NewCodec(CompressionGzip)
+	suite.Require().NoError(err)
+	zst, err := NewCodec(CompressionZstd)
+	suite.Require().NoError(err)
+
+	payload := jsonPayload(maxFrameSize)
+	out, mode := gz.Compress(payload)
+	suite.Require().Equal(CompressionGzip, mode)
+
+	// a stream without compression only takes plain frames
+	var none *Codec
+	_, err = none.Decompress(mode, out)
+	suite.ErrorIs(err, ErrCompressionMismatch)
+	plain, err := none.Decompress(CompressionNone, payload)
+	suite.Require().NoError(err)
+	suite.Equal(payload, plain)
+
+	_, err = zst.Decompress(mode, out)
+	suite.ErrorIs(err, ErrCompressionMismatch)
+
+	// small payloads of a compressing stream are sent as is
+	plain, err = gz.Decompress(CompressionNone, payload)
+	suite.Require().NoError(err)
+	suite.Equal(payload, plain)
+}
+
+func (suite *CompressSuite) TestNegotiate() {
+	allowed := []Compression{CompressionZstd, CompressionGzip}
+
+	suite.Equal(CompressionGzip, Negotiate([]string{"gzip", "zstd"}, allowed))
+	suite.Equal(CompressionZstd, Negotiate([]string{"brotli", "zstd"}, allowed))
+	suite.Equal(CompressionNone, Negotiate([]string{"zstd"}, []Compression{CompressionGzip}))
+	suite.Equal(CompressionNone, Negotiate(nil, allowed))
+}
+
+func TestCompressSuite(t *testing.T) {
+	suite.Run(t, new(CompressSuite))
+}
+
+// BenchmarkCompress bandwidth saved on typical api and page payloads,
+// reported as the share of payload bytes not sent
+func BenchmarkCompress(b *testing.B) {
+	payloads := map[string][]byte{
+		"json": jsonPayload(maxFrameSize),
+		"html": htmlPayload(maxFrameSize),
+	}
+
+	for _, mode := range []Compression{CompressionGzip, CompressionZstd} {
+		codec, err := NewCodec(mode)
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		for name, payload := range payloads {
+			b.Run(mode.String()+"/"+name, func(b *testing.B) {
+				b.SetBytes(int64(len(payload)))
+				b.ReportAllocs()
+
+				var sent int
+				for b.Loop() {
+					out, _ := codec.Compress(payload)
+					sent += len(out)
+				}
+				b.ReportMetric(100*(1-float64(sent)/float64(b.N*len(payload))), "saved%")
+			})
+		}
+	}
+}
+
+// jsonPayload api response of about size bytes
+func jsonPayload(size int) []byte {
+	type item struct {
+		ID        int      `json:"id"`
+		Name      string   `json:"name"`
+		Email     string   `json:"email"`
+		Active    bool     `json:"active"`
+		Tags      []string `json:"tags"`
+		CreatedAt string   `json:"created_at"`
+	}
+
+	var items []item
+	for i := 0; ; i++ {
+		items = append(items, item{
+			ID:        i,
+			Name:      fmt.Sprintf("user %d", i),
+			Email:     fmt.Sprintf("user%d@dino.local", i),
+			Active:    i%3 != 0,
+			Tags:      []string{"preview", "beta"},
+			CreatedAt: fmt.Sprintf("2026-01-%02dT10:00:00Z", i%28+1),
+		})
+		b, _ := json.Marshal(items)
+		if len(b) >= size {
+			return b[:size]
+		}
+	}
+}
+
+// htmlPayload rendered page of size bytes
+func htmlPayload(size int) []byte {
+	var sb strings.Builder
+	sb.WriteString("<!doctype html><html><head><title>dino</title></head><body><table>")
+	for i := 0; sb.Len() < size; i++ {
+		fmt.Fprintf(&sb, `<tr class="row"><td><a href="/routes/%d">route %d</a></td><td>enabled</td></tr>`, i, i)
+	}
+	return []byte(sb.String()[:size])
+}
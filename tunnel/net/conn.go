@@ -1,5 +1,10 @@
 package net
 
+import (
+	"context"
+	"io"
+)
+
 // Conn
 type Conn interface {
 	Read() (*DataFrame, error)
@@ -7,3 +12,12 @@ type Conn interface {
 
 	Close(string) error
 }
+
+// StreamConn tunnel conn carrying each session on a dedicated transport stream,
+// only control frames are sent over the conn itself
+type StreamConn interface {
+	Conn
+
+	// OpenStream open the transport stream of sessionID
+	OpenStream(context.Context, string) (io.ReadWriteCloser, error)
+}
@@ -8,16 +8,64 @@ type DataFrame struct {
 	Payload        []byte
 	RouteUpdate    *RouteUpdate
 	IsControlFrame bool
+
+	// IsDatagram payload is a single udp packet and must not be split or merged
+	IsDatagram bool
+
+	// Seq order of a stream payload within its session
+	Seq uint64
+	// Fin sender finished writing the session stream, ordered by Seq
+	Fin bool
+	// WindowUpdate credit returned to the sender of a session stream
+	WindowUpdate *WindowUpdate
+
+	// Ping heartbeat the receiver answers with a Pong of the same nonce
+	Ping *Heartbeat
+	// Pong answer to a Ping
+	Pong *Heartbeat
+
+	// GoAway server is shutting down, the agent should connect elsewhere
+	GoAway *GoAway
+}
+
+// GoAway
+type GoAway struct {
+	Reason string
+}
+
+// Heartbeat
+type Heartbeat struct {
+	Nonce uint64
+}
+
+// WindowUpdate
+type WindowUpdate struct {
+	Credit uint32
 }
 
 // NewConn
 type NewConn struct {
 	Hostname string
+	Protocol string
+
+	// RouteID route matched by the server, empty when only the hostname is known
+	RouteID string
+	// Label subdomain matched by a wildcard route, empty on exact matches
+	Label string
+	// Stream payloads use a dedicated transport stream instead of data frames
+	Stream bool
+	// Timeouts limits both peers enforce on the session
+	Timeouts Timeouts
 }
 
 // CloseConn
 type CloseConn struct {
 	Status int
+
+	// Reason why the sender ended the session
+	Reason CloseReason
+	// Message detail for logs, never required to act on the reason
+	Message string
 }
 
 type RouteUpdate struct {
@@ -26,4 +74,8 @@ type RouteUpdate struct {
 	DestIP       string
 	DestPort     uint32
 	IsDelete     bool
+
+	RouteID    string
+	PathPrefix string
+	Headers    map[string]string
 }
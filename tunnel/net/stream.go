@@ -0,0 +1,266 @@
+package net
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+)
+
+// DefaultWindow bytes a session may have in flight before the sender waits for credit
+const DefaultWindow = 256 * 1024
+
+// maxFrameSize largest payload of a single data frame
+const maxFrameSize = 32 * 1024
+
+// ErrWindowExceeded peer sent more than the credit it was granted
+var ErrWindowExceeded = errors.New("flow control window exceeded")
+
+// Stream ordered, flow controlled byte stream of a session.
+//
+// Payloads are delivered by sequence number and buffered until read, the
+// receive buffer never grows past the window since the peer may only send
+// the credit granted back as bytes are consumed.
+type Stream struct {
+	sessionID string
+	conn      Conn
+	window    uint32
+
+	// writeMtx keeps sequence numbers in send order
+	writeMtx sync.Mutex
+
+	mtx  sync.Mutex
+	cond *sync.Cond
+
+	buf      bytes.Buffer
+	reorder  map[uint64][]byte
+	buffered int
+	nextSeq  uint64
+	consumed uint32
+
+	seq    uint64
+	credit uint32
+
+	// fin sequence of the end of stream sent by the peer, reads return io.EOF
+	// once every payload before it was read
+	fin    uint64
+	hasFin bool
+	eof    bool
+	// writeClosed end of stream was sent, later writes fail
+	writeClosed bool
+
+	closed bool
+	// err returned once the stream is drained, io.EOF unless closed with an error
+	err error
+}
+
+// interface compliance
+var _ io.ReadWriteCloser = (*Stream)(nil)
+
+// NewStream session stream writing frames to conn, both peers start with window credit
+func NewStream(conn Conn, sessionID string, window uint32) *Stream {
+	s := &Stream{
+		sessionID: sessionID,
+		conn:      conn,
+		window:    window,
+		reorder:   map[uint64][]byte{},
+		credit:    window,
+	}
+	s.cond = sync.NewCond(&s.mtx)
+	return s
+}
+
+// Deliver queue payload received with sequence seq, never blocks the caller
+func (s *Stream) Deliver(seq uint64, payload []byte) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed || seq < s.nextSeq {
+		return nil
+	}
+
+	// every frame carries at least one byte, a larger gap cannot be within the window
+	if s.buffered+len(payload) > int(s.window) || seq-s.nextSeq > uint64(s.window) {
+		return ErrWindowExceeded
+	}
+	s.buffered += len(payload)
+
+	if seq != s.nextSeq {
+		s.reorder[seq] = payload
+		return nil
+	}
+
+	s.buf.Write(payload)
+	s.nextSeq++
+	for {
+		next, ok := s.reorder[s.nextSeq]
+		if !ok {
+			break
+		}
+		delete(s.reorder, s.nextSeq)
+		s.buf.Write(next)
+		s.nextSeq++
+	}
+	s.eof = s.hasFin && s.nextSeq == s.fin
+
+	s.cond.Broadcast()
+	return nil
+}
+
+// Finish mark the end of the peer stream at seq, reads return io.EOF once
+// every payload sent before it was read
+func (s *Stream) Finish(seq uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed || s.hasFin || seq < s.nextSeq {
+		return
+	}
+
+	s.fin, s.hasFin = seq, true
+	s.eof = s.nextSeq == seq
+	s.cond.Broadcast()
+}
+
+// Grant credit returned by the peer after consuming bytes
+func (s *Stream) Grant(credit uint32) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.credit += credit
+	s.cond.Broadcast()
+}
+
+// Read implements io.Reader.
+func (s *Stream) Read(p []byte) (int, error) {
+	s.mtx.Lock()
+	for s.buf.Len() == 0 && !s.closed && !s.eof {
+		s.cond.Wait()
+	}
+
+	if s.buf.Len() == 0 {
+		err := s.err
+		s.mtx.Unlock()
+		if err == nil {
+			err = io.EOF
+		}
+		return 0, err
+	}
+
+	n, _ := s.buf.Read(p)
+	s.buffered -= n
+	s.consumed += uint32(n)
+
+	// batch credit so small reads do not each cost a frame
+	var grant uint32
+	if s.consumed >= s.window/2 && !s.closed {
+		grant = s.consumed
+		s.consumed = 0
+	}
+	s.mtx.Unlock()
+
+	if grant > 0 {
+		if _, err := s.conn.Write(&DataFrame{
+			SessionID:      s.sessionID,
+			IsControlFrame: true,
+			WindowUpdate:   &WindowUpdate{Credit: grant},
+		}); err != nil {
+			return n, fmt.Errorf("conn.Write: %w", err)
+		}
+	}
+
+	return n, nil
+}
+
+// Write implements io.Writer.
+func (s *Stream) Write(p []byte) (int, error) {
+	s.writeMtx.Lock()
+	defer s.writeMtx.Unlock()
+
+	written := 0
+	for len(p) > 0 {
+		s.mtx.Lock()
+		for s.credit == 0 && !s.closed {
+			s.cond.Wait()
+		}
+
+		if s.closed || s.writeClosed {
+			err := s.err
+			s.mtx.Unlock()
+			if err == nil {
+				err = net.ErrClosed
+			}
+			return written, err
+		}
+
+		n := min(len(p), int(s.credit), maxFrameSize)
+		s.credit -= uint32(n)
+		seq := s.seq
+		s.seq++
+		s.mtx.Unlock()
+
+		if _, err := s.conn.Write(&DataFrame{
+			SessionID: s.sessionID,
+			Seq:       seq,
+			Payload:   p[:n],
+		}); err != nil {
+			return written, fmt.Errorf("conn.Write: %w", err)
+		}
+
+		written += n
+		p = p[n:]
+	}
+
+	return written, nil
+}
+
+// CloseWrite send the end of stream after every written payload, the peer
+// reads io.EOF while this side keeps reading
+func (s *Stream) CloseWrite() error {
+	s.writeMtx.Lock()
+	defer s.writeMtx.Unlock()
+
+	s.mtx.Lock()
+	if s.closed || s.writeClosed {
+		s.mtx.Unlock()
+		return nil
+	}
+	s.writeClosed = true
+	seq := s.seq
+	s.seq++
+	s.mtx.Unlock()
+
+	if _, err := s.conn.Write(&DataFrame{
+		SessionID: s.sessionID,
+		Seq:       seq,
+		Fin:       true,
+	}); err != nil {
+		return fmt.Errorf("conn.Write: %w", err)
+	}
+	return nil
+}
+
+// Close end the stream, buffered bytes are still read before io.EOF and
+// pending writes fail. The peer is not notified.
+func (s *Stream) Close() error {
+	return s.CloseWithError(nil)
+}
+
+// CloseWithError end the stream, reads return err once buffered bytes are
+// consumed and writes return err right away
+func (s *Stream) CloseWithError(err error) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if s.closed {
+		return nil
+	}
+
+	s.closed = true
+	s.err = err
+	s.reorder = map[uint64][]byte{}
+	s.cond.Broadcast()
+	return nil
+}
@@ -0,0 +1,210 @@
+package net
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+// recordConn keeps every frame written by a stream
+type recordConn struct {
+	mtx    sync.Mutex
+	frames []*DataFrame
+}
+
+// interface compliance
+var _ Conn = (*recordConn)(nil)
+
+// Read implements Conn.
+func (r *recordConn) Read() (*DataFrame, error) { return nil, io.EOF }
+
+// Write implements Conn.
+func (r *recordConn) Write(df *DataFrame) (int, error) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	cp := *df
+	cp.Payload = append([]byte(nil), df.Payload...)
+	r.frames = append(r.frames, &cp)
+	return len(df.Payload), nil
+}
+
+// Close implements Conn.
+func (r *recordConn) Close(string) error { return nil }
+
+func (r *recordConn) written() []*DataFrame {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	return append([]*DataFrame(nil), r.frames...)
+}
+
+type StreamSuite struct {
+	suite.Suite
+}
+
+func (suite *StreamSuite) TestDeliverInOrder() {
+	s := NewStream(&recordConn{}, "session", 64)
+
+	suite.Require().NoError(s.Deliver(1, []byte("world")))
+	suite.Require().NoError(s.Deliver(0, []byte("hello ")))
+	suite.Require().NoError(s.Close())
+
+	b, err := io.ReadAll(s)
+	suite.Require().NoError(err)
+	suite.Equal("hello world", string(b))
+}
+
+func (suite *StreamSuite) TestPartialRead() {
+	s := NewStream(&recordConn{}, "session", 64)
+	suite.Require().NoError(s.Deliver(0, []byte("abcdef")))
+
+	p := make([]byte, 4)
+	n, err := s.Read(p)
+	suite.Require().NoError(err)
+	suite.Equal("abcd", string(p[:n]))
+
+	n, err = s.Read(p)
+	suite.Require().NoError(err)
+	suite.Equal("ef", string(p[:n]))
+}
+
+func (suite *StreamSuite) TestWindowExceeded() {
+	s := NewStream(&recordConn{}, "session", 8)
+
+	suite.Require().NoError(s.Deliver(0, []byte("12345678")))
+	suite.ErrorIs(s.Deliver(1, []byte("9")), ErrWindowExceeded)
+}
+
+func (suite *StreamSuite) TestReadGrantsCredit() {
+	conn := &recordConn{}
+	s := NewStream(conn, "session", 8)
+	suite.Require().NoError(s.Deliver(0, []byte("12345678")))
+
+	_, err := s.Read(make([]byte, 8))
+	suite.Require().NoError(err)
+
+	frames := conn.written()
+	suite.Require().Len(frames, 1)
+	suite.Require().NotNil(frames[0].WindowUpdate)
+	suite.Equal(uint32(8), frames[0].WindowUpdate.Credit)
+}
+
+func (suite *StreamSuite) TestWriteWaitsForCredit() {
+	conn := &recordConn{}
+	s := NewStream(conn, "session", 4)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Write([]byte("12345678"))
+		done <- err
+	}()
+
+	select {
+	case <-done:
+		suite.FailNow("write finished without credit")
+	case <-time.After(50 * time.Millisecond):
+	}
+	suite.Len(conn.written(), 1)
+
+	s.Grant(4)
+	suite.Require().NoError(<-done)
+
+	frames := conn.written()
+	suite.Require().Len(frames, 2)
+	suite.Equal(uint64(0), frames[0].Seq)
+	suite.Equal(uint64(1), frames[1].Seq)
+	suite.Equal("5678", string(frames[1].Payload))
+}
+
+func (suite *StreamSuite) TestCloseUnblocksWrite() {
+	s := NewStream(&recordConn{}, "session", 0)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.Write([]byte("blocked"))
+		done <- err
+	}()
+
+	suite.Require().NoError(s.Close())
+	suite.Error(<-done)
+}
+
+func (suite *StreamSuite) TestCloseWithError() {
+	s := NewStream(&recordConn{}, "session", 64)
+	errAbort := errors.New("abort")
+
+	suite.Require().NoError(s.Deliver(0, []byte("buffered")))
+	suite.Require().NoError(s.CloseWithError(errAbort))
+
+	b := make([]byte, 16)
+	n, err := s.Read(b)
+	suite.Require().NoError(err)
+	suite.Equal("buffered", string(b[:n]))
+
+	_, err = s.Read(b)
+	suite.ErrorIs(err, errAbort)
+	_, err = s.Write([]byte("late"))
+	suite.ErrorIs(err, errAbort)
+}
+
+func (suite *StreamSuite) TestCloseWrite() {
+	conn := &recordConn{}
+	s := NewStream(conn, "session", 64)
+
+	_, err := s.Write([]byte("request"))
+	suite.Require().NoError(err)
+	suite.Require().NoError(s.CloseWrite())
+	suite.Require().NoError(s.CloseWrite())
+
+	_, err = s.Write([]byte("late"))
+	suite.ErrorIs(err, net.ErrClosed)
+
+	frames := conn.written()
+	suite.Require().Len(frames, 2)
+	suite.True(frames[1].Fin)
+	suite.Equal(uint64(1), frames[1].Seq)
+
+	// the end of stream overtakes the payload before it
+	peer := NewStream(&recordConn{}, "session", 64)
+	peer.Finish(frames[1].Seq)
+	suite.Require().NoError(peer.Deliver(frames[0].Seq, frames[0].Payload))
+
+	b, err := io.ReadAll(peer)
+	suite.Require().NoError(err)
+	suite.Equal("request", string(b))
+
+	// the half closed side keeps reading
+	suite.Require().NoError(s.Deliver(0, []byte("response")))
+	s.Finish(1)
+	b, err = io.ReadAll(s)
+	suite.Require().NoError(err)
+	suite.Equal("response", string(b))
+}
+
+func (suite *StreamSuite) TestCloseReason() {
+	suite.NoError((&CloseConn{}).Err())
+	suite.NoError((&CloseConn{Reason: CloseNormal}).Err())
+
+	var closeErr *CloseError
+	suite.Require().ErrorAs((&CloseConn{Reason: CloseDialRefused, Message: "connection refused"}).Err(), &closeErr)
+	suite.Equal(CloseDialRefused, closeErr.Reason)
+
+	suite.Equal(CloseDialTimeout, DialReason(&net.OpError{Op: "dial", Err: os.ErrDeadlineExceeded}))
+	suite.Equal(CloseDialRefused, DialReason(&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}))
+	suite.Equal(CloseInternal, DialReason(fmt.Errorf("open session: %w", errors.New("session exists"))))
+	suite.Equal(CloseInternal, DialReason(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	suite.Equal(CloseBackendReset, CopyReason(&net.OpError{Op: "read", Err: syscall.ECONNRESET}))
+	suite.Equal(CloseNormal, CopyReason(io.ErrUnexpectedEOF))
+}
+
+func TestStreamSuite(t *testing.T) {
+	suite.Run(t, new(StreamSuite))
+}
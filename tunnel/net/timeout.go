@@ -0,0 +1,56 @@
+package net
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Timeouts idle and lifetime limits of a session, zero disables a limit
+type Timeouts struct {
+	// Idle max time without payload in either direction
+	Idle time.Duration
+	// MaxLifetime max time since the session opened
+	MaxLifetime time.Duration
+}
+
+// Or fill the limits left at zero from defaults
+func (t Timeouts) Or(defaults Timeouts) Timeouts {
+	if t.Idle == 0 {
+		t.Idle = defaults.Idle
+	}
+	if t.MaxLifetime == 0 {
+		t.MaxLifetime = defaults.MaxLifetime
+	}
+	return t
+}
+
+// Deadline tracks the activity of a session against its timeouts
+type Deadline struct {
+	timeouts Timeouts
+	opened   time.Time
+	// last unix nano of the latest activity
+	last atomic.Int64
+}
+
+// NewDeadline deadline of a session opened at now
+func NewDeadline(timeouts Timeouts, now time.Time) *Deadline {
+	d := &Deadline{timeouts: timeouts, opened: now}
+	d.last.Store(now.UnixNano())
+	return d
+}
+
+// Touch record activity, safe for concurrent use
+func (d *Deadline) Touch() {
+	d.last.Store(time.Now().UnixNano())
+}
+
+// Expired close reason once a limit passed at now
+func (d *Deadline) Expired(now time.Time) (CloseReason, bool) {
+	if d.timeouts.MaxLifetime > 0 && now.Sub(d.opened) >= d.timeouts.MaxLifetime {
+		return CloseMaxLifetime, true
+	}
+	if d.timeouts.Idle > 0 && now.Sub(time.Unix(0, d.last.Load())) >= d.timeouts.Idle {
+		return CloseIdleTimeout, true
+	}
+	return CloseUnspecified, false
+}
@@ -0,0 +1,49 @@
+package net
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DeadlineSuite struct {
+	suite.Suite
+}
+
+func (suite *DeadlineSuite) TestExpired() {
+	opened := time.Now()
+	d := NewDeadline(Timeouts{Idle: time.Minute, MaxLifetime: time.Hour}, opened)
+
+	_, ok := d.Expired(opened.Add(30 * time.Second))
+	suite.False(ok)
+
+	reason, ok := d.Expired(opened.Add(2 * time.Minute))
+	suite.True(ok)
+	suite.Equal(CloseIdleTimeout, reason)
+
+	// max lifetime wins over recent activity
+	d.Touch()
+	reason, ok = d.Expired(opened.Add(2 * time.Hour))
+	suite.True(ok)
+	suite.Equal(CloseMaxLifetime, reason)
+}
+
+func (suite *DeadlineSuite) TestUnlimited() {
+	opened := time.Now()
+	d := NewDeadline(Timeouts{}, opened)
+
+	_, ok := d.Expired(opened.Add(24 * time.Hour))
+	suite.False(ok)
+}
+
+func (suite *DeadlineSuite) TestOr() {
+	defaults := Timeouts{Idle: 10 * time.Minute, MaxLifetime: time.Hour}
+
+	suite.Equal(defaults, Timeouts{}.Or(defaults))
+	suite.Equal(Timeouts{Idle: time.Minute, MaxLifetime: time.Hour}, Timeouts{Idle: time.Minute}.Or(defaults))
+}
+
+func TestDeadlineSuite(t *testing.T) {
+	suite.Run(t, new(DeadlineSuite))
+}
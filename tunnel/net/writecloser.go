@@ -5,3 +5,16 @@ import "io"
 type WriteCloser interface {
 	io.WriteCloser
 }
+
+// CloseWriter ends the send side of a connection while it keeps reading
+type CloseWriter interface {
+	CloseWrite() error
+}
+
+// CloseWrite half close w when it supports it, a no-op otherwise
+func CloseWrite(w io.Writer) error {
+	if cw, ok := w.(CloseWriter); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
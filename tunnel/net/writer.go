@@ -29,16 +29,6 @@ func NewWriter(conn Conn, sessionID string) WriteCloser {
 	}
 }
 
-// NewClientWriter
-func NewClientWriter(conn Conn, sessionID string) WriteCloser {
-	return &tunnelWriter{
-		conn:      conn,
-		sessionID: sessionID,
-		isClosed:  false,
-		close:     sync.Once{},
-	}
-}
-
 // Close implements WriteCloser.
 func (tw *tunnelWriter) Close() error {
 	err := tw.conn.Close(tw.sessionID)
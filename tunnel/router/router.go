@@ -1,22 +1,41 @@
 package router
 
-import "sync"
+import (
+	"net/http"
+	"sync"
+
+	"soft.structx.io/dino/routing"
+)
 
 type Route struct {
+	ID       string
+	Hostname string
 	Protocol string
 	IP       string
 	Port     string
+
+	// Rule path and header conditions, zero value matches every request
+	Rule routing.Rule
 }
 
 type Mux interface {
-	Add(string, string, string, string)
+	// Add insert or replace route by id
+	Add(Route)
+	// Del remove route by id
 	Del(string)
-	Match(string) (Route, bool)
+	// Get route by id
+	Get(string) (Route, bool)
+	// Match most specific route of hostname for request path and header
+	Match(string, string, http.Header) (Route, bool)
+	// Reset remove every route before a full route sync
+	Reset()
 }
 
 type tunnelRouter struct {
 	mtx    sync.RWMutex
 	routes map[string]Route
+	// hosts route ids by hostname
+	hosts map[string][]string
 }
 
 // interface compliance
@@ -26,30 +45,95 @@ func newMux() Mux {
 	return &tunnelRouter{
 		mtx:    sync.RWMutex{},
 		routes: map[string]Route{},
+		hosts:  map[string][]string{},
 	}
 }
 
 // Add implements Router.
-func (t *tunnelRouter) Add(hostname, protocol, ip string, port string) {
+func (t *tunnelRouter) Add(route Route) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	route.Rule.ID = route.ID
+
+	t.del(route.ID)
+	t.routes[route.ID] = route
+	t.hosts[route.Hostname] = append(t.hosts[route.Hostname], route.ID)
+}
+
+// Reset implements Router.
+func (t *tunnelRouter) Reset() {
 	t.mtx.Lock()
 	defer t.mtx.Unlock()
-	t.routes[hostname] = Route{Protocol: protocol, IP: ip, Port: port}
+
+	t.routes = map[string]Route{}
+	t.hosts = map[string][]string{}
 }
 
 // Del implements Router.
-func (t *tunnelRouter) Del(hostname string) {
+func (t *tunnelRouter) Del(id string) {
 	t.mtx.Lock()
 	defer t.mtx.Unlock()
-	delete(t.routes, hostname)
+	t.del(id)
+}
+
+func (t *tunnelRouter) del(id string) {
+	route, ok := t.routes[id]
+	if !ok {
+		return
+	}
+	delete(t.routes, id)
+
+	ids := t.hosts[route.Hostname]
+	for i, rid := range ids {
+		if rid == id {
+			ids = append(ids[:i], ids[i+1:]...)
+			break
+		}
+	}
+
+	if len(ids) == 0 {
+		delete(t.hosts, route.Hostname)
+		return
+	}
+	t.hosts[route.Hostname] = ids
+}
+
+// Get implements Router.
+func (t *tunnelRouter) Get(id string) (Route, bool) {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	route, ok := t.routes[id]
+	return route, ok
 }
 
 // Match implements Router.
-func (t *tunnelRouter) Match(hostname string) (Route, bool) {
+func (t *tunnelRouter) Match(hostname, path string, header http.Header) (Route, bool) {
 	t.mtx.RLock()
 	defer t.mtx.RUnlock()
-	route, ok := t.routes[hostname]
+
+	if r, ok := t.match(hostname, path, header); ok {
+		return r, true
+	}
+
+	// exact hostname routes take precedence over wildcard routes
+	pattern, _, ok := routing.Wildcard(hostname)
+	if !ok {
+		return Route{}, false
+	}
+	return t.match(pattern, path, header)
+}
+
+func (t *tunnelRouter) match(hostname, path string, header http.Header) (Route, bool) {
+	ids := t.hosts[hostname]
+	rules := make([]routing.Rule, 0, len(ids))
+	for _, id := range ids {
+		rules = append(rules, t.routes[id].Rule)
+	}
+
+	rule, ok := routing.MostSpecific(rules, path, header)
 	if !ok {
 		return Route{}, false
 	}
-	return route, true
+	return t.routes[rule.ID], true
 }
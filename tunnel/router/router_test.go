@@ -0,0 +1,65 @@
+package router
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"soft.structx.io/dino/routing"
+)
+
+type RouterSuite struct {
+	suite.Suite
+
+	mux Mux
+}
+
+func (suite *RouterSuite) SetupTest() {
+	suite.mux = newMux()
+	suite.mux.Add(Route{ID: "exact", Hostname: "app.dino.local"})
+	suite.mux.Add(Route{ID: "wildcard", Hostname: "*.dino.local"})
+	suite.mux.Add(Route{ID: "wildcard-v2", Hostname: "*.dino.local", Rule: routing.Rule{PathPrefix: "/v2"}})
+	suite.mux.Add(Route{ID: "api-v2", Hostname: "api.dino.local", Rule: routing.Rule{PathPrefix: "/v2"}})
+}
+
+func (suite *RouterSuite) TestMatch() {
+	tt := []struct {
+		name     string
+		hostname string
+		path     string
+		id       string
+		ok       bool
+	}{
+		{"exact over wildcard", "app.dino.local", "/", "exact", true},
+		{"exact without rule over wildcard rule", "app.dino.local", "/v2", "exact", true},
+		{"wildcard label", "feature-1.dino.local", "/", "wildcard", true},
+		{"wildcard rule", "feature-1.dino.local", "/v2/users", "wildcard-v2", true},
+		{"exact rule", "api.dino.local", "/v2", "api-v2", true},
+		{"wildcard when exact rules miss", "api.dino.local", "/v1", "wildcard", true},
+		{"single label only", "a.feature-1.dino.local", "/", "", false},
+		{"parent not covered", "dino.local", "/", "", false},
+		{"other domain", "app.example.com", "/", "", false},
+	}
+
+	for _, tc := range tt {
+		r, ok := suite.mux.Match(tc.hostname, tc.path, http.Header{})
+		suite.Equal(tc.ok, ok, tc.name)
+		suite.Equal(tc.id, r.ID, tc.name)
+	}
+}
+
+func (suite *RouterSuite) TestDel() {
+	suite.mux.Del("exact")
+
+	r, ok := suite.mux.Match("app.dino.local", "/", http.Header{})
+	suite.True(ok)
+	suite.Equal("wildcard", r.ID)
+
+	suite.mux.Reset()
+	_, ok = suite.mux.Match("app.dino.local", "/", http.Header{})
+	suite.False(ok)
+}
+
+func TestRouterSuite(t *testing.T) {
+	suite.Run(t, new(RouterSuite))
+}
@@ -0,0 +1,347 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/structx/teapot"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	pb "soft.structx.io/dino/pb/rtunnel/v1"
+)
+
+const (
+	keyFile  = "agent.key"
+	certFile = "agent.crt"
+	caFile   = "ca.crt"
+)
+
+var (
+	// errCAMismatch enrollment answered with another ca than the pinned one
+	errCAMismatch = errors.New("enrollment ca does not match the pinned ca")
+	// errNotEnrolled agent holds no client certificate yet
+	errNotEnrolled = errors.New("agent is not enrolled")
+	// errNoPinnedCA agent cannot verify the gateway it enrolls with
+	errNoPinnedCA = errors.New("no pinned ca, place ca.crt in the cert dir or set the ca fingerprint")
+)
+
+// certSource client certificate of the agent and the pinned ca of the
+// server, replaced by every enrollment
+type certSource struct {
+	mtx  sync.Mutex
+	cert *tls.Certificate
+	ca   *x509.Certificate
+
+	// fingerprint sha256 of the ca pinned before the first enrollment, nil
+	// when a placed ca.crt pins it
+	fingerprint []byte
+
+	// dir the key, certificate and ca are persisted to, empty keeps them in memory
+	dir string
+}
+
+// newCertSource certificate and ca persisted in dir, a ca placed there or
+// the ca fingerprint must be pinned before the first enrollment
+func newCertSource(dir, fingerprint string) (*certSource, error) {
+	cs := &certSource{dir: dir}
+	if fingerprint != "" {
+		fp, err := parseFingerprint(fingerprint)
+		if err != nil {
+			return nil, err
+		}
+		cs.fingerprint = fp
+	}
+
+	if dir != "" {
+		if err := cs.load(); err != nil {
+			return nil, err
+		}
+	}
+
+	if cs.ca == nil && cs.fingerprint == nil {
+		return nil, errNoPinnedCA
+	}
+	return cs, nil
+}
+
+// load ca, certificate and key persisted in dir
+func (cs *certSource) load() error {
+	caPEM, err := os.ReadFile(filepath.Join(cs.dir, caFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	ca, err := parseCertificate(caPEM)
+	if err != nil {
+		return fmt.Errorf("parse pinned ca: %w", err)
+	}
+	if !cs.pinned(ca) {
+		return fmt.Errorf("%s: %w", caFile, errCAMismatch)
+	}
+	cs.ca = ca
+
+	cert, err := tls.LoadX509KeyPair(filepath.Join(cs.dir, certFile), filepath.Join(cs.dir, keyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("tls.LoadX509KeyPair: %w", err)
+	}
+	cs.cert = &cert
+
+	return nil
+}
+
+// pinned ca is the pinned ca, or matches the pinned fingerprint
+func (cs *certSource) pinned(ca *x509.Certificate) bool {
+	if cs.ca != nil {
+		return bytes.Equal(cs.ca.Raw, ca.Raw)
+	}
+	sum := sha256.Sum256(ca.Raw)
+	return cs.fingerprint == nil || bytes.Equal(cs.fingerprint, sum[:])
+}
+
+// tlsConfig mutual tls with the tunnel gateway, its certificate must be issued by the pinned ca
+func (cs *certSource) tlsConfig(serverName string) *tls.Config {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	pool := x509.NewCertPool()
+	if cs.ca != nil {
+		pool.AddCert(cs.ca)
+	}
+
+	return &tls.Config{
+		ServerName:           serverName,
+		RootCAs:              pool,
+		GetClientCertificate: cs.clientCertificate,
+		NextProtos:           []string{"h3"},
+		MinVersion:           tls.VersionTLS13,
+	}
+}
+
+// enrollTlsConfig server tls with the enrollment port, its certificate must
+// chain to the pinned ca
+func (cs *certSource) enrollTlsConfig(serverName string) *tls.Config {
+	return &tls.Config{
+		ServerName: serverName,
+		MinVersion: tls.VersionTLS13,
+		// a ca pinned by fingerprint is only known once the gateway sent its
+		// chain, verifyGateway replaces the default verification
+		InsecureSkipVerify: true,
+		VerifyConnection: func(state tls.ConnectionState) error {
+			return cs.verifyGateway(serverName, state.PeerCertificates)
+		},
+	}
+}
+
+// verifyGateway chain is issued for serverName by the pinned ca
+func (cs *certSource) verifyGateway(serverName string, chain []*x509.Certificate) error {
+	if len(chain) == 0 {
+		return errors.New("gateway sent no certificate")
+	}
+
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	roots := x509.NewCertPool()
+	if cs.ca != nil {
+		roots.AddCert(cs.ca)
+	} else {
+		for _, cert := range chain[1:] {
+			if cert.IsCA && cs.pinned(cert) {
+				roots.AddCert(cert)
+			}
+		}
+	}
+
+	if _, err := chain[0].Verify(x509.VerifyOptions{
+		DNSName:   serverName,
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}); err != nil {
+		return fmt.Errorf("verify gateway certificate: %w", err)
+	}
+	return nil
+}
+
+// clientCertificate current certificate, renewed ones are used by the next handshake
+func (cs *certSource) clientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	if cs.cert == nil {
+		return nil, errNotEnrolled
+	}
+	return cs.cert, nil
+}
+
+// renewAt time a third of the certificate lifetime is left, zero when not enrolled
+func (cs *certSource) renewAt() time.Time {
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	if cs.cert == nil || cs.cert.Leaf == nil {
+		return time.Time{}
+	}
+
+	leaf := cs.cert.Leaf
+	return leaf.NotAfter.Add(-leaf.NotAfter.Sub(leaf.NotBefore) / 3)
+}
+
+// enroll request a certificate for a new key, every enrollment rotates the key
+func (cs *certSource) enroll(ctx context.Context, cli pb.EnrollmentServiceClient, md metadata.MD, tunnelID string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("ecdsa.GenerateKey: %w", err)
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: tunnelID},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("x509.CreateCertificateRequest: %w", err)
+	}
+
+	resp, err := cli.Enroll(metadata.NewOutgoingContext(ctx, md), &pb.EnrollRequest{
+		Csr: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr}),
+	})
+	if err != nil {
+		return fmt.Errorf("cli.Enroll: %w", err)
+	}
+
+	ca, err := parseCertificate(resp.GetCa())
+	if err != nil {
+		return fmt.Errorf("parse enrollment ca: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("x509.MarshalPKCS8PrivateKey: %w", err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+
+	cert, err := tls.X509KeyPair(resp.GetCertificate(), keyPEM)
+	if err != nil {
+		return fmt.Errorf("tls.X509KeyPair: %w", err)
+	}
+
+	cs.mtx.Lock()
+	defer cs.mtx.Unlock()
+
+	// the first enrollment pins the ca matching the fingerprint
+	if !cs.pinned(ca) {
+		return errCAMismatch
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{
+		Roots:     pool,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		return fmt.Errorf("verify client certificate: %w", err)
+	}
+
+	cs.ca = ca
+	cs.cert = &cert
+
+	if cs.dir == "" {
+		return nil
+	}
+
+	if err := writeFile(filepath.Join(cs.dir, caFile), resp.GetCa()); err != nil {
+		return fmt.Errorf("persist ca: %w", err)
+	}
+	// the key is written last, a partial write leaves a pair that fails to load
+	if err := writeFile(filepath.Join(cs.dir, certFile), resp.GetCertificate()); err != nil {
+		return fmt.Errorf("persist certificate: %w", err)
+	}
+	if err := writeFile(filepath.Join(cs.dir, keyFile), keyPEM); err != nil {
+		return fmt.Errorf("persist key: %w", err)
+	}
+	return nil
+}
+
+// parseCertificate first certificate of a pem block
+func parseCertificate(b []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(b)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("missing certificate pem block")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// parseFingerprint hex encoded sha256 fingerprint, colons as printed by openssl are allowed
+func parseFingerprint(s string) ([]byte, error) {
+	fp, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(s), ":", ""))
+	if err != nil || len(fp) != sha256.Size {
+		return nil, fmt.Errorf("invalid ca fingerprint %q", s)
+	}
+	return fp, nil
+}
+
+// enroll request a client certificate from the tunnel gateway, authenticated by the tunnel token
+func (t *tunnelClient) enroll(ctx context.Context) error {
+	// the enrollment port is reachable without a client certificate
+	creds := credentials.NewTLS(t.certs.enrollTlsConfig(t.enrollName))
+	cc, err := grpc.NewClient(t.enrollTarget, grpc.WithTransportCredentials(creds))
+	if err != nil {
+		return fmt.Errorf("grpc.NewClient: %w", err)
+	}
+	defer cc.Close()
+
+	timeout, cancel := context.WithTimeout(ctx, time.Second*15)
+	defer cancel()
+
+	md := metadata.New(map[string]string{
+		"tunnel-id":     t.tunnelID,
+		"authorization": t.tokens.get(),
+	})
+
+	if err := t.certs.enroll(timeout, pb.NewEnrollmentServiceClient(cc), md, t.tunnelID); err != nil {
+		return err
+	}
+
+	t.log.Info("enrolled tunnel agent", teapot.String("renew_at", t.certs.renewAt().String()))
+	return nil
+}
+
+// renewer enroll again before the client certificate expires until ctx is done
+func (t *tunnelClient) renewer(ctx context.Context) {
+	for {
+		if err := sleep(ctx, time.Until(t.certs.renewAt())); err != nil {
+			return
+		}
+
+		for {
+			err := t.enroll(ctx)
+			if err == nil {
+				break
+			}
+			t.log.Error("renew client certificate", teapot.String("retry_in", refreshRetry.String()), teapot.Error(err))
+
+			if err := sleep(ctx, refreshRetry); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	pb "soft.structx.io/dino/pb/rtunnel/v1"
+)
+
+// fakeEnrollment signs every request with a throwaway ca
+type fakeEnrollment struct {
+	ca    *x509.Certificate
+	caPEM []byte
+	key   *ecdsa.PrivateKey
+}
+
+// interface compliance
+var _ pb.EnrollmentServiceClient = (*fakeEnrollment)(nil)
+
+func newFakeEnrollment() (*fakeEnrollment, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	ca, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fakeEnrollment{
+		ca:    ca,
+		caPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:   key,
+	}, nil
+}
+
+// Enroll implements pb.EnrollmentServiceClient.
+func (f *fakeEnrollment) Enroll(_ context.Context, in *pb.EnrollRequest, _ ...grpc.CallOption) (*pb.EnrollResponse, error) {
+	block, _ := pem.Decode(in.GetCsr())
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      csr.Subject,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(2 * time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, f.ca, csr.PublicKey, f.key)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.EnrollResponse{
+		Certificate: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		Ca:          f.caPEM,
+	}, nil
+}
+
+// serverChain gateway certificate for name followed by the ca
+func (f *fakeEnrollment) serverChain(name string) ([]*x509.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(now.UnixNano()),
+		Subject:      pkix.Name{CommonName: "test gateway"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		DNSNames:     []string{name},
+	}, f.ca, &key.PublicKey, f.key)
+	if err != nil {
+		return nil, err
+	}
+
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return []*x509.Certificate{leaf, f.ca}, nil
+}
+
+// fingerprint of the ca as printed by openssl
+func (f *fakeEnrollment) fingerprint() string {
+	sum := sha256.Sum256(f.ca.Raw)
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+type CertSuite struct {
+	suite.Suite
+
+	enrollment *fakeEnrollment
+}
+
+func (suite *CertSuite) SetupTest() {
+	var err error
+	suite.enrollment, err = newFakeEnrollment()
+	suite.Require().NoError(err)
+}
+
+func (suite *CertSuite) TestNoPinnedCA() {
+	_, err := newCertSource("", "")
+	suite.ErrorIs(err, errNoPinnedCA)
+
+	_, err = newCertSource(suite.T().TempDir(), "")
+	suite.ErrorIs(err, errNoPinnedCA)
+
+	_, err = newCertSource("", "not a fingerprint")
+	suite.Error(err)
+}
+
+func (suite *CertSuite) TestEnroll() {
+	cs, err := newCertSource("", suite.enrollment.fingerprint())
+	suite.Require().NoError(err)
+	suite.True(cs.renewAt().IsZero())
+
+	_, err = cs.clientCertificate(nil)
+	suite.ErrorIs(err, errNotEnrolled)
+
+	suite.Require().NoError(cs.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"))
+
+	cert, err := cs.clientCertificate(nil)
+	suite.Require().NoError(err)
+	suite.Equal("tunnel-id", cert.Leaf.Subject.CommonName)
+
+	// issued an hour ago for three hours, renewed with an hour left
+	suite.WithinDuration(time.Now().Add(time.Hour), cs.renewAt(), time.Minute)
+	suite.True(cs.tlsConfig("tunnel.dino.local").RootCAs.Equal(suite.pool(suite.enrollment.ca)))
+}
+
+func (suite *CertSuite) TestPinnedCA() {
+	other, err := newFakeEnrollment()
+	suite.Require().NoError(err)
+
+	// the fingerprint pins the first enrollment
+	cs, err := newCertSource("", other.fingerprint())
+	suite.Require().NoError(err)
+	suite.ErrorIs(cs.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"), errCAMismatch)
+
+	cs, err = newCertSource("", suite.enrollment.fingerprint())
+	suite.Require().NoError(err)
+	suite.Require().NoError(cs.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"))
+	suite.ErrorIs(cs.enroll(context.Background(), other, metadata.MD{}, "tunnel-id"), errCAMismatch)
+}
+
+func (suite *CertSuite) TestVerifyGateway() {
+	chain, err := suite.enrollment.serverChain("tunnel.dino.local")
+	suite.Require().NoError(err)
+
+	cs, err := newCertSource("", suite.enrollment.fingerprint())
+	suite.Require().NoError(err)
+	suite.NoError(cs.verifyGateway("tunnel.dino.local", chain))
+	suite.Error(cs.verifyGateway("other.dino.local", chain))
+
+	// the ca must come with the chain until it is pinned
+	suite.Error(cs.verifyGateway("tunnel.dino.local", chain[:1]))
+
+	other, err := newFakeEnrollment()
+	suite.Require().NoError(err)
+	otherChain, err := other.serverChain("tunnel.dino.local")
+	suite.Require().NoError(err)
+	suite.Error(cs.verifyGateway("tunnel.dino.local", otherChain))
+
+	// once pinned the ca verifies chains without it
+	suite.Require().NoError(cs.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"))
+	suite.NoError(cs.verifyGateway("tunnel.dino.local", chain[:1]))
+}
+
+func (suite *CertSuite) TestPersist() {
+	dir := suite.T().TempDir()
+
+	cs, err := newCertSource(dir, suite.enrollment.fingerprint())
+	suite.Require().NoError(err)
+	suite.Require().NoError(cs.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"))
+
+	// the persisted ca stays pinned
+	loaded, err := newCertSource(dir, "")
+	suite.Require().NoError(err)
+	suite.Equal(cs.renewAt(), loaded.renewAt())
+	suite.True(loaded.ca.Equal(suite.enrollment.ca))
+
+	// a ca placed before the first enrollment is pinned
+	other, err := newFakeEnrollment()
+	suite.Require().NoError(err)
+	pinnedDir := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(pinnedDir, caFile), other.caPEM, 0o600))
+
+	pinned, err := newCertSource(pinnedDir, "")
+	suite.Require().NoError(err)
+	suite.ErrorIs(pinned.enroll(context.Background(), suite.enrollment, metadata.MD{}, "tunnel-id"), errCAMismatch)
+
+	// a placed ca must match the fingerprint
+	_, err = newCertSource(pinnedDir, suite.enrollment.fingerprint())
+	suite.ErrorIs(err, errCAMismatch)
+}
+
+func (suite *CertSuite) pool(certs ...*x509.Certificate) *x509.CertPool {
+	pool := x509.NewCertPool()
+	for _, c := range certs {
+		pool.AddCert(c)
+	}
+	return pool
+}
+
+func TestCertSuite(t *testing.T) {
+	suite.Run(t, new(CertSuite))
+}
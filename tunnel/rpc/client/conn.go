@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sync"
 
 	"google.golang.org/grpc"
 	pb "soft.structx.io/dino/pb/rtunnel/v1"
@@ -12,17 +13,30 @@ import (
 
 type clientConn struct {
 	str grpc.BidiStreamingClient[pb.TunnelMessage, pb.TunnelMessage]
+
+	// sendMtx session actors write to the stream concurrently
+	sendMtx sync.Mutex
+
+	// codec compresses data payloads, nil when the server negotiated none
+	codec *tunnelnet.Codec
+}
+
+func (c *clientConn) send(msg *pb.TunnelMessage) error {
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+	return c.str.Send(msg)
 }
 
 var _ tunnelnet.Conn = (*clientConn)(nil)
 
 // Close implements net.Conn.
 func (c *clientConn) Close(sessionID string) error {
-	return c.str.Send(&pb.TunnelMessage{
+	return c.send(&pb.TunnelMessage{
 		SessionId: sessionID,
 		Payload: &pb.TunnelMessage_CloseConnection{
 			CloseConnection: &pb.CloseConnection{
 				StatusCode: 1,
+				Reason:     pb.CLOSEREASON_CLOSEREASON_NORMAL,
 			},
 		},
 	})
@@ -44,14 +58,60 @@ func (c *clientConn) Read() (*tunnelnet.DataFrame, error) {
 			SessionID:      msg.GetSessionId(),
 			IsControlFrame: true,
 			CloseConn: &tunnelnet.CloseConn{
-				Status: int(msg.GetCloseConnection().GetStatusCode()),
+				Status:  int(msg.GetCloseConnection().GetStatusCode()),
+				Reason:  tunnelnet.CloseReason(msg.GetCloseConnection().GetReason()),
+				Message: msg.GetCloseConnection().GetMessage(),
 			},
 		}, nil
+	} else if msg.GetEndOfStream() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID: msg.GetSessionId(),
+			Seq:       msg.GetSeq(),
+			Fin:       true,
+		}, nil
+	} else if msg.GetWindowUpdate() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			WindowUpdate: &tunnelnet.WindowUpdate{
+				Credit: msg.GetWindowUpdate().GetCredit(),
+			},
+		}, nil
+	} else if msg.GetGoAway() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			GoAway:         &tunnelnet.GoAway{Reason: msg.GetGoAway().GetReason()},
+		}, nil
+	} else if msg.GetPing() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			Ping:           &tunnelnet.Heartbeat{Nonce: msg.GetPing().GetNonce()},
+		}, nil
+	} else if msg.GetPong() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			Pong:           &tunnelnet.Heartbeat{Nonce: msg.GetPong().GetNonce()},
+		}, nil
 	} else if msg.GetData() != nil {
+		payload, err := c.codec.Decompress(tunnelnet.Compression(msg.GetCompression()), msg.GetData())
+		if err != nil {
+			return nil, fmt.Errorf("decompress data: %w", err)
+		}
+		return &tunnelnet.DataFrame{
+			IsControlFrame: false,
+			SessionID:      msg.GetSessionId(),
+			Payload:        payload,
+			Seq:            msg.GetSeq(),
+		}, nil
+	} else if msg.GetDatagram() != nil {
 		return &tunnelnet.DataFrame{
 			IsControlFrame: false,
+			IsDatagram:     true,
 			SessionID:      msg.GetSessionId(),
-			Payload:        msg.GetData(),
+			Payload:        msg.GetDatagram(),
 		}, nil
 	} else if msg.GetNewConnection() != nil {
 		return &tunnelnet.DataFrame{
@@ -59,6 +119,14 @@ func (c *clientConn) Read() (*tunnelnet.DataFrame, error) {
 			IsControlFrame: true,
 			NewConn: &tunnelnet.NewConn{
 				Hostname: msg.GetNewConnection().GetDestination(),
+				Protocol: protocolString(msg.GetNewConnection().GetProtocol()),
+				RouteID:  msg.GetNewConnection().GetRouteId(),
+				Label:    msg.GetNewConnection().GetWildcardLabel(),
+				Stream:   msg.GetNewConnection().GetDedicatedStream(),
+				Timeouts: tunnelnet.Timeouts{
+					Idle:        msg.GetNewConnection().GetIdleTimeout().AsDuration(),
+					MaxLifetime: msg.GetNewConnection().GetMaxLifetime().AsDuration(),
+				},
 			},
 		}, nil
 	} else if msg.GetRouteUpdates() != nil {
@@ -72,6 +140,9 @@ func (c *clientConn) Read() (*tunnelnet.DataFrame, error) {
 				DestIP:       rcfg.DestinationIp,
 				DestPort:     rcfg.DestinationPort,
 				IsDelete:     rcfg.IsDeleted,
+				RouteID:      rcfg.Id,
+				PathPrefix:   rcfg.PathPrefix,
+				Headers:      rcfg.MatchHeaders,
 			},
 			NewConn:   nil,
 			CloseConn: nil,
@@ -87,24 +158,93 @@ func (c *clientConn) Write(df *tunnelnet.DataFrame) (int, error) {
 
 	if df.IsControlFrame {
 		if df.CloseConn != nil {
-			return 0, c.str.Send(&pb.TunnelMessage{
+			return 0, c.send(&pb.TunnelMessage{
 				SessionId: df.SessionID,
 				Payload: &pb.TunnelMessage_CloseConnection{
 					CloseConnection: &pb.CloseConnection{
 						StatusCode: uint32(df.CloseConn.Status),
+						Reason:     pb.CLOSEREASON(df.CloseConn.Reason),
+						Message:    df.CloseConn.Message,
+					},
+				},
+			})
+		}
+		if df.Pong != nil {
+			return 0, c.send(&pb.TunnelMessage{
+				SessionId: df.SessionID,
+				Payload: &pb.TunnelMessage_Pong{
+					Pong: &pb.Heartbeat{Nonce: df.Pong.Nonce},
+				},
+			})
+		}
+		if df.Ping != nil {
+			return 0, c.send(&pb.TunnelMessage{
+				SessionId: df.SessionID,
+				Payload: &pb.TunnelMessage_Ping{
+					Ping: &pb.Heartbeat{Nonce: df.Ping.Nonce},
+				},
+			})
+		}
+		if df.WindowUpdate != nil {
+			return 0, c.send(&pb.TunnelMessage{
+				SessionId: df.SessionID,
+				Payload: &pb.TunnelMessage_WindowUpdate{
+					WindowUpdate: &pb.WindowUpdate{
+						Credit: df.WindowUpdate.Credit,
 					},
 				},
 			})
 		}
 	}
 
-	if err := c.str.Send(&pb.TunnelMessage{
-		SessionId: df.SessionID,
+	if df.Fin {
+		if err := c.send(&pb.TunnelMessage{
+			SessionId: df.SessionID,
+			Seq:       df.Seq,
+			Payload:   &pb.TunnelMessage_EndOfStream{EndOfStream: &pb.EndOfStream{}},
+		}); err != nil {
+			return 0, fmt.Errorf("str.Send end of stream: %w", err)
+		}
+		return 0, nil
+	}
+
+	if df.IsDatagram {
+		if err := c.send(&pb.TunnelMessage{
+			SessionId: df.SessionID,
+			Payload: &pb.TunnelMessage_Datagram{
+				Datagram: df.Payload,
+			},
+		}); err != nil {
+			return 0, fmt.Errorf("str.Send: %w", err)
+		}
+		return len(df.Payload), nil
+	}
+
+	payload, mode := c.codec.Compress(df.Payload)
+	if err := c.send(&pb.TunnelMessage{
+		SessionId:   df.SessionID,
+		Seq:         df.Seq,
+		Compression: pb.COMPRESSION(mode),
 		Payload: &pb.TunnelMessage_Data{
-			Data: df.Payload,
+			Data: payload,
 		},
 	}); err != nil {
 		return 0, fmt.Errorf("str.Send: %w", err)
 	}
 	return len(df.Payload), nil
 }
+
+func protocolString(protocol pb.REVERSETUNNELPROTOCOL) string {
+	switch protocol {
+	case pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_TCP:
+		return "tcp"
+	case pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_UDP:
+		return "udp"
+	case pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_HTTP:
+		return "http"
+	case pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_HTTPS:
+		return "https"
+	default:
+		return ""
+	}
+}
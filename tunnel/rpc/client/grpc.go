@@ -2,15 +2,20 @@ package client
 
 import (
 	"context"
-	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
+	"sync/atomic"
+	"time"
 
+	"github.com/quic-go/quic-go"
+	"github.com/structx/teapot"
 	"go.uber.org/fx"
-	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	pb "soft.structx.io/dino/pb/rtunnel/v1"
+	"soft.structx.io/dino/routing"
 	"soft.structx.io/dino/setup"
 	tunnelnet "soft.structx.io/dino/tunnel/net"
 	"soft.structx.io/dino/tunnel/router"
@@ -24,7 +29,7 @@ type Params struct {
 
 	Lc fx.Lifecycle
 
-	Logger *zap.Logger
+	Logger *teapot.Logger
 
 	Cfg *setup.Tunnel
 
@@ -32,52 +37,86 @@ type Params struct {
 	Mux             router.Mux
 }
 
+// Result
 type Result struct {
 	fx.Out
+
+	Tunneler Tunneler
 }
 
 // Tunneler
 type Tunneler interface {
-	establishTunnel(context.Context) error
+	// State current connection state of the tunnel
+	State() State
 }
 
 type tunnelClient struct {
-	log *zap.Logger
+	log *teapot.Logger
 
 	target   string
 	tunnelID string
-	token    string
+	tokens   *tokenSource
+
+	// serverName the tunnel gateway certificate is verified for
+	serverName string
+	// enrollTarget tunnel gateway port issuing client certificates
+	enrollTarget string
+	// enrollName the enrollment port certificate is verified for
+	enrollName string
+	certs      *certSource
+
+	// wildcardPorts local port of each wildcard route label
+	wildcardPorts map[string]string
+	// sessionStreams ask the server for a quic stream per session
+	sessionStreams bool
+	// compression modes offered to the server in order of preference
+	compression []string
 
-	conn     *grpc.ClientConn
 	sessions sessions.Mux
 	mux      router.Mux
+
+	state   atomic.Int32
+	backoff backoff
+
+	cancelFn context.CancelFunc
+	stopped  chan struct{}
 }
 
 // interface compliance
 var _ Tunneler = (*tunnelClient)(nil)
 
 // Module
-var Module = fx.Module("tunnel_client", fx.Invoke(newModule))
+var Module = fx.Module("tunnel_client",
+	fx.Provide(newModule),
+	// the tunnel connects on start whether or not anything asks for its state
+	fx.Invoke(func(Tunneler) {}),
+)
 
-func newModule(p Params) error {
+func newModule(p Params) (Result, error) {
+	for _, name := range p.Cfg.Compression {
+		if _, err := tunnelnet.ParseCompression(name); err != nil {
+			return Result{}, fmt.Errorf("invalid tunnel compression: %w", err)
+		}
+	}
 
-	tlsConfig := &tls.Config{
-		InsecureSkipVerify: true,
-		NextProtos:         []string{"h3"},
+	tokens, err := newTokenSource(p.Cfg.Token, p.Cfg.TokenFile)
+	if err != nil {
+		return Result{}, fmt.Errorf("read tunnel token: %w", err)
 	}
 
-	dial := transport.NewQuicDialer(tlsConfig)
-	creds := transport.NewCredentials(tlsConfig)
+	certs, err := newCertSource(p.Cfg.CertDir, p.Cfg.CAFingerprint)
+	if err != nil {
+		return Result{}, fmt.Errorf("read tunnel certificate: %w", err)
+	}
 
-	opts := []grpc.DialOption{
-		// grpc.WithTransportCredentials(insecure.NewCredentials()),
-		grpc.WithTransportCredentials(creds),
-		grpc.WithContextDialer(dial),
+	serverName, _, err := net.SplitHostPort(p.Cfg.Endpoint)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid tunnel endpoint: %w", err)
 	}
 
-	conn, err := grpc.NewClient("tunnel.dino.local:4242", opts...)
+	enrollName, _, err := net.SplitHostPort(p.Cfg.EnrollEndpoint)
 	if err != nil {
-		return fmt.Errorf("grpc.NewClient: %w", err)
+		return Result{}, fmt.Errorf("invalid enroll endpoint: %w", err)
 	}
 
 	tc := &tunnelClient{
@@ -86,94 +125,380 @@ func newModule(p Params) error {
 		mux:      p.Mux,
 		target:   p.Cfg.Endpoint,
 		tunnelID: p.Cfg.ID,
-		token:    p.Cfg.Token,
-		conn:     conn,
+		tokens:   tokens,
+
+		serverName:   serverName,
+		enrollTarget: p.Cfg.EnrollEndpoint,
+		enrollName:   enrollName,
+		certs:        certs,
+
+		wildcardPorts:  p.Cfg.WildcardPorts,
+		sessionStreams: p.Cfg.SessionStreams,
+		compression:    p.Cfg.Compression,
+
+		backoff: backoff{
+			min: p.Cfg.ReconnectBackoff,
+			max: p.Cfg.ReconnectBackoffMax,
+		},
+		stopped: make(chan struct{}),
 	}
 
 	p.Lc.Append(fx.Hook{
-		OnStart: func(ctx context.Context) error {
-			return tc.establishTunnel(ctx)
-		},
-		OnStop: func(ctx context.Context) error {
-			return nil
-		},
+		OnStart: tc.start,
+		OnStop:  tc.stop,
 	})
 
+	return Result{Tunneler: tc}, nil
+}
+
+// errGoAway server asked the agent to reconnect before shutting down
+var errGoAway = errors.New("server going away")
+
+// State implements Tunneler.
+func (t *tunnelClient) State() State {
+	return State(t.state.Load())
+}
+
+func (t *tunnelClient) setState(state State) {
+	if State(t.state.Swap(int32(state))) != state {
+		t.log.Info("tunnel state", teapot.String("state", state.String()))
+	}
+}
+
+func (t *tunnelClient) start(_ context.Context) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancelFn = cancel
+
+	go t.supervise(ctx)
 	return nil
 }
 
-// EstablishTunnel implements Tunneler.
-func (t *tunnelClient) establishTunnel(ctx context.Context) error {
-	cli := pb.NewReverseTunnelServiceClient(t.conn)
+func (t *tunnelClient) stop(ctx context.Context) error {
+	t.cancelFn()
+
+	select {
+	case <-t.stopped:
+	case <-ctx.Done():
+		return fmt.Errorf("wait for tunnel supervisor: %w", ctx.Err())
+	}
+	return nil
+}
+
+// supervise keep the tunnel connected, every ended stream is re-dialed after a backoff
+func (t *tunnelClient) supervise(ctx context.Context) {
+	defer close(t.stopped)
+	defer t.setState(StateStopped)
+
+	for {
+		t.setState(StateConnecting)
+		err := t.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if errors.Is(err, errGoAway) {
+			// the old stream drains in the background, reconnect right away
+			t.log.Info("tunnel go away", teapot.String("endpoint", t.target))
+			continue
+		}
+
+		delay := t.backoff.next()
+		t.setState(StateBackoff)
+		t.log.Error("tunnel disconnected",
+			teapot.String("endpoint", t.target),
+			teapot.String("retry_in", delay.String()),
+			teapot.Error(err))
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// connect establish one tunnel stream and serve it until it ends or the
+// server sends a go away
+func (t *tunnelClient) connect(ctx context.Context) error {
+	if time.Now().After(t.certs.renewAt()) {
+		if err := t.enroll(ctx); err != nil {
+			return fmt.Errorf("enroll: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	cc, err := grpc.NewClient(t.target, t.dialOptions()...)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("grpc.NewClient: %w", err)
+	}
+
+	draining := false
+	defer func() {
+		if !draining {
+			cancel()
+			_ = cc.Close()
+		}
+	}()
+
+	cli := pb.NewReverseTunnelServiceClient(cc)
 
 	md := metadata.New(map[string]string{
 		"tunnel-id":     t.tunnelID,
-		"authorization": t.token,
+		"authorization": t.tokens.get(),
 	})
-	ctx = metadata.NewOutgoingContext(ctx, md)
+	if t.sessionStreams {
+		md.Set(transport.SessionStreamsKey, transport.SessionStreamsMode)
+	}
+	if len(t.compression) > 0 {
+		md.Set(tunnelnet.CompressionKey, t.compression...)
+	}
 
-	ctx = context.WithoutCancel(ctx)
-	stream, err := cli.EstablishTunnel(ctx)
+	stream, err := cli.EstablishTunnel(metadata.NewOutgoingContext(ctx, md))
 	if err != nil {
 		return fmt.Errorf("cli.EstablishTunnel: %w", err)
 	}
-	cc := &clientConn{str: stream}
-	go t.worker(cc)
 
-	return nil
+	// the server answers with headers once the tunnel is authenticated and registered
+	header, err := stream.Header()
+	if err != nil {
+		return fmt.Errorf("stream.Header: %w", err)
+	}
+	if header == nil {
+		_, err := stream.Recv()
+		return fmt.Errorf("tunnel rejected: %w", err)
+	}
+
+	// routes removed while disconnected are missing from the upcoming sync
+	t.mux.Reset()
+	t.backoff.reset()
+	t.setState(StateConnected)
+	go t.refresher(ctx, cli)
+	go t.renewer(ctx)
+
+	if t.sessionStreams {
+		p, ok := peer.FromContext(stream.Context())
+		if !ok {
+			return errors.New("missing tunnel stream peer")
+		}
+
+		qc, ok := transport.Connection(p.AuthInfo)
+		if !ok {
+			return errors.New("tunnel stream is not carried by quic")
+		}
+		go t.acceptStreams(ctx, qc)
+	}
+
+	codec, err := t.codec(header)
+	if err != nil {
+		return err
+	}
+
+	conn := &clientConn{str: stream, codec: codec}
+	goAway := make(chan struct{})
+	served := make(chan error, 1)
+	go func() { served <- t.worker(conn, goAway) }()
+
+	select {
+	case err = <-served:
+		t.resetSessions(conn)
+		return err
+	case <-goAway:
+	}
+
+	// open sessions finish on the old stream until the server closes it
+	draining = true
+	go func() {
+		err := <-served
+		t.log.Debug("drained tunnel stream", teapot.Error(err))
+		t.resetSessions(conn)
+		cancel()
+		_ = cc.Close()
+	}()
+	return errGoAway
+}
+
+// dialOptions every connection attempt dials a fresh client so a go away
+// can reach another server replica and a renewed certificate is presented
+func (t *tunnelClient) dialOptions() []grpc.DialOption {
+	tlsConfig := t.certs.tlsConfig(t.serverName)
+	return []grpc.DialOption{
+		grpc.WithTransportCredentials(transport.NewCredentials(tlsConfig)),
+		grpc.WithContextDialer(transport.NewQuicDialer(tlsConfig)),
+	}
+}
+
+// resetSessions close sessions of an ended stream. Sessions are not resumed
+// on the next stream: payloads in flight when it ended are lost, neither side
+// keeps them to replay, so clients retry over the new tunnel instead.
+func (t *tunnelClient) resetSessions(conn tunnelnet.Conn) {
+	if err := t.sessions.Reset(conn); err != nil {
+		t.log.Error("reset sessions", teapot.Error(err))
+	}
 }
 
-func (t *tunnelClient) worker(conn tunnelnet.Conn) {
+// acceptStreams hand every session stream opened by the server to its session
+func (t *tunnelClient) acceptStreams(ctx context.Context, qc *quic.Conn) {
+	for {
+		sessionID, rwc, err := transport.AcceptSessionStream(ctx, qc)
+		if errors.Is(err, transport.ErrSessionHeader) {
+			t.log.Error("accept session stream", teapot.Error(err))
+			continue
+		} else if err != nil {
+			t.log.Debug("stop accepting session streams", teapot.Error(err))
+			return
+		}
+
+		if err := t.sessions.AttachStream(sessionID, rwc); err != nil {
+			t.log.Error("attach session stream", teapot.String("session", sessionID), teapot.Error(err))
+		}
+	}
+}
+
+// codec compression the server picked from the offered modes
+func (t *tunnelClient) codec(header metadata.MD) (*tunnelnet.Codec, error) {
+	var mode tunnelnet.Compression
+	if modes := header.Get(tunnelnet.CompressionKey); len(modes) > 0 {
+		var err error
+		mode, err = tunnelnet.ParseCompression(modes[0])
+		if err != nil {
+			return nil, fmt.Errorf("server compression: %w", err)
+		}
+	}
+
+	codec, err := tunnelnet.NewCodec(mode)
+	if err != nil {
+		return nil, fmt.Errorf("tunnelnet.NewCodec: %w", err)
+	}
+	if mode != tunnelnet.CompressionNone {
+		t.log.Info("tunnel compression", teapot.String("mode", mode.String()))
+	}
+	return codec, nil
+}
+
+// worker dispatch tunnel frames until the stream ends, goAway is closed
+// when the server asks for a new stream
+func (t *tunnelClient) worker(conn tunnelnet.Conn, goAway chan struct{}) error {
+	announced := false
 	for {
 		df, err := conn.Read()
 		if err != nil {
-			t.log.Error("read from conn", zap.Error(err))
-			continue
+			return fmt.Errorf("conn.Read: %w", err)
 		}
 
-		t.log.Debug("received msg", zap.Any("dataframe", df))
+		t.log.Debug("received msg", teapot.Any("dataframe", df))
 
 		if df.NewConn != nil {
-			r, ok := t.mux.Match(df.NewConn.Hostname)
+			r, ok := t.matchRoute(df.NewConn)
 			if !ok {
-				// send back missing route error
-				t.log.Error("no matched route", zap.String("hostname", df.NewConn.Hostname))
+				err := fmt.Errorf("no route for %s %s", df.NewConn.Protocol, df.NewConn.Hostname)
+				if err := t.sessions.Reject(conn, df.SessionID, tunnelnet.CloseRouteNotFound, err); err != nil {
+					t.log.Error("reject session", teapot.Error(err))
+				}
 				continue
 			}
 
-			hostAndPort := net.JoinHostPort(r.IP, r.Port)
-			if err := t.sessions.InitSession(conn, df.SessionID, r.Protocol, hostAndPort); err != nil {
-				t.log.Error("init session", zap.Error(err))
-				continue
+			hostAndPort := net.JoinHostPort(r.IP, t.destinationPort(r, df.NewConn))
+			if err := t.sessions.InitSession(conn, df.SessionID, r.Protocol, hostAndPort, df.NewConn.Stream, df.NewConn.Timeouts); err != nil {
+				t.log.Error("init session", teapot.Error(err))
 			}
+			continue
+		}
+		if df.GoAway != nil {
+			if !announced {
+				announced = true
+				t.log.Info("server going away", teapot.String("reason", df.GoAway.Reason))
+				close(goAway)
+			}
+			continue
+		}
+		if df.Ping != nil {
+			// answered right away so the server measures the round trip
+			if _, err := conn.Write(&tunnelnet.DataFrame{
+				SessionID:      df.SessionID,
+				IsControlFrame: true,
+				Pong:           &tunnelnet.Heartbeat{Nonce: df.Ping.Nonce},
+			}); err != nil {
+				return fmt.Errorf("conn.Write pong: %w", err)
+			}
+			continue
 		}
 		if df.CloseConn != nil {
-			if err := t.sessions.CloseSession(df.SessionID); err != nil {
-				t.log.Error("close session", zap.Error(err))
-				continue
+			if err := t.sessions.CloseSession(df.SessionID, df.CloseConn.Reason); err != nil {
+				t.log.Error("close session", teapot.Error(err))
 			}
+			continue
 		}
 		if df.RouteUpdate != nil {
 
 			if df.RouteUpdate.IsDelete {
-				t.mux.Del(df.RouteUpdate.Hostname)
+				t.mux.Del(routeKey(df.RouteUpdate))
 				continue
 			}
 
 			port, err := validateAndParsePort(df.RouteUpdate.DestPort)
 			if err != nil {
-				t.log.Error("invalid port", zap.Error(err))
+				t.log.Error("invalid port", teapot.Error(err))
 				continue
 			}
 
-			t.log.Info("config update", zap.Any("route", df.RouteUpdate))
-			t.mux.Add(df.RouteUpdate.Hostname, df.RouteUpdate.DestProtocol, df.RouteUpdate.DestIP, port)
+			t.log.Info("config update", teapot.Any("route", df.RouteUpdate))
+			t.mux.Add(router.Route{
+				ID:       routeKey(df.RouteUpdate),
+				Hostname: df.RouteUpdate.Hostname,
+				Protocol: df.RouteUpdate.DestProtocol,
+				IP:       df.RouteUpdate.DestIP,
+				Port:     port,
+				Rule: routing.Rule{
+					PathPrefix: df.RouteUpdate.PathPrefix,
+					Headers:    df.RouteUpdate.Headers,
+				},
+			})
 			continue
 		}
 
-		err = t.sessions.RouteMsg(df.SessionID, df.Payload)
+		err = t.sessions.RouteMsg(df)
 		if err != nil {
-			t.log.Error("route message", zap.Error(err))
+			t.log.Error("route message", teapot.Error(err))
 		}
 	}
 }
+
+// matchRoute route chosen by the server, hostname match for sessions without one
+func (t *tunnelClient) matchRoute(nc *tunnelnet.NewConn) (router.Route, bool) {
+	if nc.RouteID != "" {
+		if r, ok := t.mux.Get(nc.RouteID); ok {
+			return r, true
+		}
+	}
+	return t.mux.Match(nc.Hostname, "/", nil)
+}
+
+// destinationPort local port of a wildcard route label, route port otherwise
+func (t *tunnelClient) destinationPort(r router.Route, nc *tunnelnet.NewConn) string {
+	if !routing.IsWildcard(r.Hostname) {
+		return r.Port
+	}
+
+	// passthrough sessions carry no label, derive it from the hostname
+	label := nc.Label
+	if label == "" {
+		_, label, _ = routing.Wildcard(nc.Hostname)
+	}
+
+	if port, ok := t.wildcardPorts[label]; ok {
+		return port
+	}
+	return r.Port
+}
+
+// routeKey route id, servers without route ids identify routes by hostname
+func routeKey(ru *tunnelnet.RouteUpdate) string {
+	if ru.RouteID != "" {
+		return ru.RouteID
+	}
+	return ru.Hostname
+}
@@ -0,0 +1,62 @@
+package client
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// State tunnel connection state
+type State int32
+
+const (
+	// StateConnecting dialing and authenticating the tunnel stream
+	StateConnecting State = iota
+	// StateConnected tunnel stream registered and routes synced
+	StateConnected
+	// StateBackoff waiting before the next connection attempt
+	StateBackoff
+	// StateStopped tunnel client shut down
+	StateStopped
+)
+
+// String implements fmt.Stringer.
+func (s State) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateBackoff:
+		return "backoff"
+	case StateStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+// backoff exponential reconnect delay with jitter
+type backoff struct {
+	min, max time.Duration
+	attempt  uint
+}
+
+// next delay of the following attempt, picked within the upper half of the
+// exponential step so agents restarted together do not reconnect in lockstep
+func (b *backoff) next() time.Duration {
+	d := b.max
+	if b.attempt < 32 {
+		if step := b.min << b.attempt; step > 0 && step < b.max {
+			d = step
+		}
+	}
+	b.attempt++
+
+	half := d / 2
+	return half + rand.N(d-half+1)
+}
+
+// reset start over from the minimum delay once a connection succeeded
+func (b *backoff) reset() {
+	b.attempt = 0
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type BackoffSuite struct {
+	suite.Suite
+}
+
+func (suite *BackoffSuite) TestGrowsWithinBounds() {
+	b := backoff{min: 100 * time.Millisecond, max: time.Second}
+
+	for _, step := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		step *= time.Millisecond
+		d := b.next()
+		suite.GreaterOrEqual(d, step/2)
+		suite.LessOrEqual(d, step)
+	}
+}
+
+func (suite *BackoffSuite) TestReset() {
+	b := backoff{min: 100 * time.Millisecond, max: time.Second}
+	for range 10 {
+		b.next()
+	}
+
+	b.reset()
+	suite.LessOrEqual(b.next(), 100*time.Millisecond)
+}
+
+func TestBackoffSuite(t *testing.T) {
+	suite.Run(t, new(BackoffSuite))
+}
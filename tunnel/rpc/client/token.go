@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/structx/teapot"
+	"google.golang.org/grpc/metadata"
+	pb "soft.structx.io/dino/pb/rtunnel/v1"
+)
+
+// refreshRetry delay before retrying a failed token refresh
+const refreshRetry = 30 * time.Second
+
+// tokenSource token the agent authenticates with, replaced by every refresh
+type tokenSource struct {
+	mtx   sync.Mutex
+	token string
+
+	// path refreshed tokens are persisted to, empty keeps them in memory
+	path string
+}
+
+// newTokenSource token of the agent, a persisted token issued after the
+// configured one takes precedence
+func newTokenSource(token, path string) (*tokenSource, error) {
+	ts := &tokenSource{token: token, path: path}
+	if path == "" {
+		return ts, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ts, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("os.ReadFile: %w", err)
+	}
+
+	persisted := strings.TrimSpace(string(b))
+	if issuedAt(persisted).After(issuedAt(token)) {
+		ts.token = persisted
+	}
+	return ts, nil
+}
+
+// get current token
+func (ts *tokenSource) get() string {
+	ts.mtx.Lock()
+	defer ts.mtx.Unlock()
+	return ts.token
+}
+
+// set replace the token and persist it when a path is configured
+func (ts *tokenSource) set(token string) error {
+	ts.mtx.Lock()
+	ts.token = token
+	ts.mtx.Unlock()
+
+	if ts.path == "" {
+		return nil
+	}
+	return writeFile(ts.path, []byte(token))
+}
+
+// writeFile replace the file at path, rename keeps the previous content
+// readable until the new one is complete
+func writeFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("os.CreateTemp: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close %s: %w", filepath.Base(path), err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("os.Rename: %w", err)
+	}
+	return nil
+}
+
+// issuedAt issue time of token, zero when it cannot be read
+func issuedAt(token string) time.Time {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil || claims.IssuedAt == nil {
+		return time.Time{}
+	}
+	return claims.IssuedAt.Time
+}
+
+// refreshAt time a third of the lifetime of token is left, zero when it never expires
+func refreshAt(token string) (time.Time, error) {
+	var claims jwt.RegisteredClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(token, &claims); err != nil {
+		return time.Time{}, fmt.Errorf("jwt.ParseUnverified: %w", err)
+	}
+
+	if claims.ExpiresAt == nil {
+		return time.Time{}, nil
+	}
+
+	expiresAt := claims.ExpiresAt.Time
+	if claims.IssuedAt == nil {
+		return expiresAt, nil
+	}
+	return expiresAt.Add(-expiresAt.Sub(claims.IssuedAt.Time) / 3), nil
+}
+
+// refresher renew the token before it expires until ctx is done
+func (t *tunnelClient) refresher(ctx context.Context, cli pb.ReverseTunnelServiceClient) {
+	for {
+		at, err := refreshAt(t.tokens.get())
+		if err != nil {
+			t.log.Error("read tunnel token expiry", teapot.Error(err))
+			return
+		} else if at.IsZero() {
+			return
+		}
+
+		if err := sleep(ctx, time.Until(at)); err != nil {
+			return
+		}
+
+		for {
+			err := t.refresh(ctx, cli)
+			if err == nil {
+				break
+			}
+			t.log.Error("refresh tunnel token", teapot.String("retry_in", refreshRetry.String()), teapot.Error(err))
+
+			if err := sleep(ctx, refreshRetry); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// refresh exchange the current token for a new one
+func (t *tunnelClient) refresh(ctx context.Context, cli pb.ReverseTunnelServiceClient) error {
+	md := metadata.New(map[string]string{
+		"tunnel-id":     t.tunnelID,
+		"authorization": t.tokens.get(),
+	})
+
+	resp, err := cli.RefreshToken(metadata.NewOutgoingContext(ctx, md), &pb.RefreshTokenRequest{})
+	if err != nil {
+		return fmt.Errorf("cli.RefreshToken: %w", err)
+	}
+
+	if err := t.tokens.set(resp.GetToken()); err != nil {
+		return fmt.Errorf("store refreshed token: %w", err)
+	}
+
+	t.log.Info("refreshed tunnel token")
+	return nil
+}
+
+// sleep wait for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,72 @@
+package client
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+)
+
+type TokenSuite struct {
+	suite.Suite
+}
+
+func (suite *TokenSuite) token(issuedAt time.Time, lifetime time.Duration) string {
+	claims := jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issuedAt)}
+	if lifetime > 0 {
+		claims.ExpiresAt = jwt.NewNumericDate(issuedAt.Add(lifetime))
+	}
+
+	ss, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte("key"))
+	suite.Require().NoError(err)
+	return ss
+}
+
+func (suite *TokenSuite) TestRefreshAt() {
+	issuedAt := time.Now().Truncate(time.Second)
+
+	at, err := refreshAt(suite.token(issuedAt, 3*time.Hour))
+	suite.Require().NoError(err)
+	suite.Equal(issuedAt.Add(2*time.Hour), at)
+
+	at, err = refreshAt(suite.token(issuedAt, 0))
+	suite.Require().NoError(err)
+	suite.True(at.IsZero())
+
+	_, err = refreshAt("not a token")
+	suite.Error(err)
+}
+
+func (suite *TokenSuite) TestPersistRefreshedToken() {
+	path := filepath.Join(suite.T().TempDir(), "token")
+	configured := suite.token(time.Now().Add(-time.Hour), time.Hour)
+
+	ts, err := newTokenSource(configured, path)
+	suite.Require().NoError(err)
+	suite.Equal(configured, ts.get())
+
+	refreshed := suite.token(time.Now(), time.Hour)
+	suite.Require().NoError(ts.set(refreshed))
+
+	b, err := os.ReadFile(path)
+	suite.Require().NoError(err)
+	suite.Equal(refreshed, string(b))
+
+	// a restart picks up the refreshed token
+	ts, err = newTokenSource(configured, path)
+	suite.Require().NoError(err)
+	suite.Equal(refreshed, ts.get())
+
+	// a token deployed after the last refresh wins
+	redeployed := suite.token(time.Now().Add(time.Hour), time.Hour)
+	ts, err = newTokenSource(redeployed, path)
+	suite.Require().NoError(err)
+	suite.Equal(redeployed, ts.get())
+}
+
+func TestTokenSuite(t *testing.T) {
+	suite.Run(t, new(TokenSuite))
+}
@@ -0,0 +1,60 @@
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/structx/teapot"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	pb "soft.structx.io/dino/pb/rtunnel/v1"
+	"soft.structx.io/dino/pki"
+	"soft.structx.io/dino/tunnel/verifier"
+)
+
+type enrollmentServer struct {
+	pb.UnimplementedEnrollmentServiceServer
+
+	log *teapot.Logger
+
+	verifier  verifier.Verifier
+	authority pki.Authority
+}
+
+// interface compliance
+var _ pb.EnrollmentServiceServer = (*enrollmentServer)(nil)
+
+func newEnrollmentServer(logger *teapot.Logger, verifier verifier.Verifier, authority pki.Authority) pb.EnrollmentServiceServer {
+	return &enrollmentServer{
+		log:       logger,
+		verifier:  verifier,
+		authority: authority,
+	}
+}
+
+// Enroll
+func (es *enrollmentServer) Enroll(ctx context.Context, req *pb.EnrollRequest) (*pb.EnrollResponse, error) {
+	_, tunnelID, token, err := tunnelCredentials(ctx, es.log)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := es.verifier.VerifyToken(ctx, tunnelID, token); err != nil {
+		es.log.Error("failed to verify token", teapot.Error(err))
+		return nil, status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
+	}
+
+	cert, err := es.authority.IssueClient(tunnelID, req.GetCsr())
+	if errors.Is(err, pki.ErrInvalidRequest) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	} else if err != nil {
+		es.log.Error("failed to issue client certificate", teapot.Error(err))
+		return nil, status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	es.log.Info("enrolled tunnel agent", teapot.String("tunnel", tunnelID))
+	return &pb.EnrollResponse{
+		Certificate: cert,
+		Ca:          es.authority.CertificatePEM(),
+	}, nil
+}
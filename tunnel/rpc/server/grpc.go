@@ -1,27 +1,65 @@
 package server
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/quic-go/quic-go"
 	"github.com/structx/teapot"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 	pb "soft.structx.io/dino/pb/rtunnel/v1"
 	"soft.structx.io/dino/sessions"
 	tunnelnet "soft.structx.io/dino/tunnel/net"
+	"soft.structx.io/dino/tunnel/transport"
 	"soft.structx.io/dino/tunnel/verifier"
 )
 
 type tunnelConn struct {
 	str grpc.BidiStreamingServer[pb.TunnelMessage, pb.TunnelMessage]
+
+	// sendMtx sessions write to the stream concurrently
+	sendMtx sync.Mutex
+
+	// codec compresses data payloads, nil when the agent negotiated none
+	codec *tunnelnet.Codec
+}
+
+// streamConn tunnel conn opening a quic stream per session
+type streamConn struct {
+	*tunnelConn
+
+	quic *quic.Conn
+}
+
+// OpenStream implements net.StreamConn.
+func (s *streamConn) OpenStream(ctx context.Context, sessionID string) (io.ReadWriteCloser, error) {
+	return transport.OpenSessionStream(ctx, s.quic, sessionID)
+}
+
+func (t *tunnelConn) send(msg *pb.TunnelMessage) error {
+	t.sendMtx.Lock()
+	defer t.sendMtx.Unlock()
+	return t.str.Send(msg)
+}
+
+func (t *tunnelConn) sendHeader(md metadata.MD) error {
+	t.sendMtx.Lock()
+	defer t.sendMtx.Unlock()
+	return t.str.SendHeader(md)
 }
 
 // Read implements net.Conn.
-func (t tunnelConn) Read() (*tunnelnet.DataFrame, error) {
+func (t *tunnelConn) Read() (*tunnelnet.DataFrame, error) {
 	msg, err := t.str.Recv()
 	if err != nil {
 		if err == io.EOF {
@@ -30,23 +68,60 @@ func (t tunnelConn) Read() (*tunnelnet.DataFrame, error) {
 		return nil, fmt.Errorf("str.Recv: %w", err)
 	}
 
-	fmt.Println("tunnel conn received message")
-
 	if msg.GetCloseConnection() != nil {
-		return nil, io.EOF
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			CloseConn: &tunnelnet.CloseConn{
+				Status:  int(msg.GetCloseConnection().GetStatusCode()),
+				Reason:  tunnelnet.CloseReason(msg.GetCloseConnection().GetReason()),
+				Message: msg.GetCloseConnection().GetMessage(),
+			},
+		}, nil
+	} else if msg.GetEndOfStream() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID: msg.GetSessionId(),
+			Seq:       msg.GetSeq(),
+			Fin:       true,
+		}, nil
+	} else if msg.GetWindowUpdate() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			WindowUpdate: &tunnelnet.WindowUpdate{
+				Credit: msg.GetWindowUpdate().GetCredit(),
+			},
+		}, nil
+	} else if msg.GetPong() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			Pong:           &tunnelnet.Heartbeat{Nonce: msg.GetPong().GetNonce()},
+		}, nil
+	} else if msg.GetPing() != nil {
+		return &tunnelnet.DataFrame{
+			SessionID:      msg.GetSessionId(),
+			IsControlFrame: true,
+			Ping:           &tunnelnet.Heartbeat{Nonce: msg.GetPing().GetNonce()},
+		}, nil
 	} else if msg.GetData() != nil {
-		return &tunnelnet.DataFrame{SessionID: msg.GetSessionId(), Payload: msg.GetData()}, nil
+		payload, err := t.codec.Decompress(tunnelnet.Compression(msg.GetCompression()), msg.GetData())
+		if err != nil {
+			return nil, fmt.Errorf("decompress data: %w", err)
+		}
+		return &tunnelnet.DataFrame{SessionID: msg.GetSessionId(), Payload: payload, Seq: msg.GetSeq()}, nil
+	} else if msg.GetDatagram() != nil {
+		return &tunnelnet.DataFrame{SessionID: msg.GetSessionId(), Payload: msg.GetDatagram(), IsDatagram: true}, nil
 	}
 
 	return nil, errors.New("unsupported message")
 }
 
 // Write implements net.Conn.
-func (t tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
-	fmt.Println("tunnel conn write")
+func (t *tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
 	if df.RouteUpdate != nil {
 		ru := df.RouteUpdate
-		if err := t.str.Send(&pb.TunnelMessage{
+		if err := t.send(&pb.TunnelMessage{
 			SessionId: df.SessionID,
 			Payload: &pb.TunnelMessage_RouteUpdates{
 				RouteUpdates: &pb.Route{
@@ -55,6 +130,9 @@ func (t tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
 					DestinationIp:       ru.DestIP,
 					DestinationPort:     ru.DestPort,
 					IsDeleted:           ru.IsDelete,
+					Id:                  ru.RouteID,
+					PathPrefix:          ru.PathPrefix,
+					MatchHeaders:        ru.Headers,
 				},
 			},
 		}); err != nil {
@@ -66,24 +144,58 @@ func (t tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
 
 	if df.IsControlFrame {
 		if df.NewConn != nil {
-			if err := t.str.Send(&pb.TunnelMessage{
+			if err := t.send(&pb.TunnelMessage{
 				SessionId: df.SessionID,
 				Payload: &pb.TunnelMessage_NewConnection{
 					NewConnection: &pb.NewConnection{
-						Protocol:    pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_HTTP,
-						Destination: df.NewConn.Hostname,
+						Protocol:        pbProtocol(df.NewConn.Protocol),
+						Destination:     df.NewConn.Hostname,
+						RouteId:         df.NewConn.RouteID,
+						WildcardLabel:   df.NewConn.Label,
+						DedicatedStream: df.NewConn.Stream,
+						IdleTimeout:     durationpb.New(df.NewConn.Timeouts.Idle),
+						MaxLifetime:     durationpb.New(df.NewConn.Timeouts.MaxLifetime),
 					},
 				},
 			}); err != nil {
 				return 0, fmt.Errorf("str.Send: %w", err)
 			}
 			return 0, nil
+		} else if df.WindowUpdate != nil {
+			if err := t.send(&pb.TunnelMessage{
+				SessionId: df.SessionID,
+				Payload: &pb.TunnelMessage_WindowUpdate{
+					WindowUpdate: &pb.WindowUpdate{
+						Credit: df.WindowUpdate.Credit,
+					},
+				},
+			}); err != nil {
+				return 0, fmt.Errorf("str.Send window update: %w", err)
+			}
+			return 0, nil
+		} else if df.GoAway != nil {
+			if err := t.send(&pb.TunnelMessage{
+				SessionId: df.SessionID,
+				Payload: &pb.TunnelMessage_GoAway{
+					GoAway: &pb.GoAway{Reason: df.GoAway.Reason},
+				},
+			}); err != nil {
+				return 0, fmt.Errorf("str.Send go away: %w", err)
+			}
+			return 0, nil
+		} else if df.Ping != nil || df.Pong != nil {
+			if err := t.send(heartbeatMessage(df)); err != nil {
+				return 0, fmt.Errorf("str.Send heartbeat: %w", err)
+			}
+			return 0, nil
 		} else if df.CloseConn != nil {
-			if err := t.str.Send(&pb.TunnelMessage{
+			if err := t.send(&pb.TunnelMessage{
 				SessionId: df.SessionID,
 				Payload: &pb.TunnelMessage_CloseConnection{
 					CloseConnection: &pb.CloseConnection{
 						StatusCode: uint32(df.CloseConn.Status),
+						Reason:     pb.CLOSEREASON(df.CloseConn.Reason),
+						Message:    df.CloseConn.Message,
 					},
 				},
 			}); err != nil {
@@ -93,12 +205,37 @@ func (t tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
 		}
 	}
 
-	if df.Payload != nil {
-		fmt.Println("write data")
-		if err := t.str.Send(&pb.TunnelMessage{
+	if df.Fin {
+		if err := t.send(&pb.TunnelMessage{
 			SessionId: df.SessionID,
+			Seq:       df.Seq,
+			Payload:   &pb.TunnelMessage_EndOfStream{EndOfStream: &pb.EndOfStream{}},
+		}); err != nil {
+			return 0, fmt.Errorf("str.Send end of stream: %w", err)
+		}
+		return 0, nil
+	}
+
+	if df.IsDatagram {
+		if err := t.send(&pb.TunnelMessage{
+			SessionId: df.SessionID,
+			Payload: &pb.TunnelMessage_Datagram{
+				Datagram: df.Payload,
+			},
+		}); err != nil {
+			return 0, fmt.Errorf("str.Send: %w", err)
+		}
+		return len(df.Payload), nil
+	}
+
+	if df.Payload != nil {
+		payload, mode := t.codec.Compress(df.Payload)
+		if err := t.send(&pb.TunnelMessage{
+			SessionId:   df.SessionID,
+			Seq:         df.Seq,
+			Compression: pb.COMPRESSION(mode),
 			Payload: &pb.TunnelMessage_Data{
-				Data: df.Payload,
+				Data: payload,
 			},
 		}); err != nil {
 			return 0, fmt.Errorf("str.Send: %w", err)
@@ -110,17 +247,61 @@ func (t tunnelConn) Write(df *tunnelnet.DataFrame) (int, error) {
 }
 
 // Close implements net.Conn.
-func (t tunnelConn) Close(sessionID string) error {
-	return t.str.Send(&pb.TunnelMessage{
+func (t *tunnelConn) Close(sessionID string) error {
+	return t.send(&pb.TunnelMessage{
 		SessionId: sessionID,
 		Payload: &pb.TunnelMessage_CloseConnection{
 			CloseConnection: &pb.CloseConnection{
 				StatusCode: 1,
+				Reason:     pb.CLOSEREASON_CLOSEREASON_NORMAL,
 			},
 		},
 	})
 }
 
+// heartbeatMessage tunnel message of a ping or pong frame
+func heartbeatMessage(df *tunnelnet.DataFrame) *pb.TunnelMessage {
+	if df.Pong != nil {
+		return &pb.TunnelMessage{
+			SessionId: df.SessionID,
+			Payload:   &pb.TunnelMessage_Pong{Pong: &pb.Heartbeat{Nonce: df.Pong.Nonce}},
+		}
+	}
+	return &pb.TunnelMessage{
+		SessionId: df.SessionID,
+		Payload:   &pb.TunnelMessage_Ping{Ping: &pb.Heartbeat{Nonce: df.Ping.Nonce}},
+	}
+}
+
+// sessionStreams quic connection of agents that asked for a stream per session
+func sessionStreams(ctx context.Context, md metadata.MD) (*quic.Conn, bool) {
+	modes := md.Get(transport.SessionStreamsKey)
+	if len(modes) < 1 || modes[0] != transport.SessionStreamsMode {
+		return nil, false
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, false
+	}
+	return transport.Connection(p.AuthInfo)
+}
+
+func pbProtocol(protocol string) pb.REVERSETUNNELPROTOCOL {
+	switch strings.ToLower(protocol) {
+	case "tcp":
+		return pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_TCP
+	case "udp":
+		return pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_UDP
+	case "http":
+		return pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_HTTP
+	case "https":
+		return pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_HTTPS
+	default:
+		return pb.REVERSETUNNELPROTOCOL_REVERSETUNNELPROTOCOL_UNSPECIFIED
+	}
+}
+
 type reverseTunnelServer struct {
 	pb.UnimplementedReverseTunnelServiceServer
 
@@ -128,62 +309,171 @@ type reverseTunnelServer struct {
 
 	mux      sessions.Multiplexer
 	verifier verifier.Verifier
+
+	// compression modes agents may negotiate
+	compression []tunnelnet.Compression
 }
 
 // interface compliance
 var _ pb.ReverseTunnelServiceServer = (*reverseTunnelServer)(nil)
 var _ tunnelnet.Conn = (*tunnelConn)(nil)
+var _ tunnelnet.StreamConn = (*streamConn)(nil)
 
-func newReverseTunnelServer(logger *teapot.Logger, sessionMux sessions.Multiplexer, verifier verifier.Verifier) pb.ReverseTunnelServiceServer {
+func newReverseTunnelServer(
+	logger *teapot.Logger,
+	sessionMux sessions.Multiplexer,
+	verifier verifier.Verifier,
+	compression []tunnelnet.Compression,
+) pb.ReverseTunnelServiceServer {
 	return &reverseTunnelServer{
-		log:      logger,
-		mux:      sessionMux,
-		verifier: verifier,
+		log:         logger,
+		mux:         sessionMux,
+		verifier:    verifier,
+		compression: compression,
 	}
 }
 
-// EstablishTunnel
-func (rts *reverseTunnelServer) EstablishTunnel(stream grpc.BidiStreamingServer[pb.TunnelMessage, pb.TunnelMessage]) error {
-	rts.log.Info("establish tunnel")
-	ctx := stream.Context()
+// RefreshToken
+func (rts *reverseTunnelServer) RefreshToken(ctx context.Context, _ *pb.RefreshTokenRequest) (*pb.RefreshTokenResponse, error) {
+	_, tunnelID, token, err := rts.credentials(ctx)
+	if err != nil {
+		return nil, err
+	}
 
+	refreshed, err := rts.verifier.RefreshToken(ctx, tunnelID, token)
+	if errors.Is(err, verifier.ErrRotatedKey) {
+		return nil, status.Error(codes.PermissionDenied, err.Error())
+	} else if err != nil {
+		rts.log.Error("failed to refresh token", teapot.Error(err))
+		return nil, status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
+	}
+
+	return &pb.RefreshTokenResponse{Token: refreshed}, nil
+}
+
+// credentials tunnel id and token the agent sent in the request metadata,
+// the tunnel id must match the client certificate of the connection
+func (rts *reverseTunnelServer) credentials(ctx context.Context) (metadata.MD, string, string, error) {
+	md, tunnelID, token, err := tunnelCredentials(ctx, rts.log)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		rts.log.Debug("missing peer")
+		return nil, "", "", status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
+	}
+
+	cert, ok := transport.PeerCertificate(p.AuthInfo)
+	if !ok || cert.Subject.CommonName != tunnelID {
+		rts.log.Debug("client certificate does not match tunnel", teapot.String("tunnel", tunnelID))
+		return nil, "", "", status.Error(codes.PermissionDenied, "client certificate does not match tunnel")
+	}
+
+	return md, tunnelID, token, nil
+}
+
+// tunnelCredentials tunnel id and token sent in the request metadata
+func tunnelCredentials(ctx context.Context, logger *teapot.Logger) (metadata.MD, string, string, error) {
 	md, ok := metadata.FromIncomingContext(ctx)
 	if !ok {
-		rts.log.Debug("missing request metadata")
-		return status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
+		logger.Debug("missing request metadata")
+		return nil, "", "", status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
 	}
 
 	authorizations := md.Get("authorization")
 	if len(authorizations) < 1 {
-		rts.log.Debug("missing authorization metadata")
-		return status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
+		logger.Debug("missing authorization metadata")
+		return nil, "", "", status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
 	}
 
 	ids := md.Get("tunnel-id")
 	if len(ids) < 1 {
-		rts.log.Debug("missing tunnel-id metadata")
-		return status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
+		logger.Debug("missing tunnel-id metadata")
+		return nil, "", "", status.Error(codes.InvalidArgument, codes.InvalidArgument.String())
 	}
 
-	claims, err := rts.verifier.VerifyToken(ctx, ids[0], authorizations[0])
+	return md, ids[0], authorizations[0], nil
+}
+
+// EstablishTunnel
+func (rts *reverseTunnelServer) EstablishTunnel(stream grpc.BidiStreamingServer[pb.TunnelMessage, pb.TunnelMessage]) error {
+	rts.log.Info("establish tunnel")
+	ctx := stream.Context()
+
+	md, tunnelID, token, err := rts.credentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	verified, err := rts.verifier.VerifyToken(ctx, tunnelID, token)
 	if err != nil {
 		rts.log.Error("failed to verify token", teapot.Error(err))
 		return status.Error(codes.Unauthenticated, codes.Unauthenticated.String())
 	}
+	claims := verified.Claims
 
-	tc := tunnelConn{str: stream}
-	if err := rts.mux.RegisterTunnel(ctx, tc, claims.ID); err != nil {
+	mode := tunnelnet.Negotiate(md.Get(tunnelnet.CompressionKey), rts.compression)
+	codec, err := tunnelnet.NewCodec(mode)
+	if err != nil {
+		rts.log.Error("create tunnel codec", teapot.Error(err))
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	tc := &tunnelConn{str: stream, codec: codec}
+
+	var conn tunnelnet.Conn = tc
+	if qc, ok := sessionStreams(ctx, md); ok {
+		conn = &streamConn{tunnelConn: tc, quic: qc}
+	}
+
+	done, err := rts.mux.RegisterTunnel(ctx, conn, claims.ID, verified.KeyID)
+	if errors.Is(err, sessions.ErrTunnelConnected) {
+		rts.log.Debug("reject duplicate tunnel", teapot.String("tunnel", claims.ID))
+		return status.Error(codes.AlreadyExists, err.Error())
+	} else if errors.Is(err, sessions.ErrDraining) {
+		return status.Error(codes.Unavailable, err.Error())
+	} else if err != nil {
 		rts.log.Error("sessionManager.RegisterTunnel", teapot.Error(err))
 		return status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	if err := rts.mux.SyncRoutes(ctx, ids[0]); err != nil {
+	defer func() {
+		// stream context is already canceled once the agent is gone
+		if err := rts.mux.DeregisterTunnel(context.WithoutCancel(ctx), conn, claims.ID); err != nil {
+			rts.log.Error("sessionManager.DeregisterTunnel", teapot.Error(err))
+		}
+	}()
+
+	// headers tell the agent the tunnel is registered even when no route
+	// follows and which compression both sides use
+	if err := tc.sendHeader(metadata.Pairs(tunnelnet.CompressionKey, mode.String())); err != nil {
+		rts.log.Error("send tunnel header", teapot.Error(err))
+		return status.Error(codes.Internal, codes.Internal.String())
+	}
+
+	if err := rts.mux.SyncRoutes(ctx, conn, claims.ID); err != nil {
 		rts.log.Error("session manager sync routes", teapot.Error(err))
 		return status.Error(codes.Internal, codes.Internal.String())
 	}
 
-	// defer rts.sessionManager.
+	// tokens of rotated credentials are only valid until the grace period ends
+	var revoked <-chan time.Time
+	if !verified.RevokeAt.IsZero() {
+		timer := time.NewTimer(time.Until(verified.RevokeAt))
+		defer timer.Stop()
+		revoked = timer.C
+	}
 
-	<-ctx.Done()
-	return nil
+	select {
+	case <-ctx.Done():
+		return nil
+	case <-revoked:
+		rts.log.Info("revoke tunnel connection", teapot.String("tunnel", claims.ID))
+		return status.Error(codes.Unauthenticated, sessions.ErrCredentialsRevoked.Error())
+	case <-done:
+		// replaced by a newer connection of the same tunnel or shut down
+		return status.Error(codes.Aborted, sessions.ErrTunnelClosed.Error())
+	}
 }
@@ -1,11 +1,16 @@
 package server
 
 import (
+	"fmt"
+
 	"github.com/structx/teapot"
 	"go.uber.org/fx"
 	pb "soft.structx.io/dino/pb/rtunnel/v1"
+	"soft.structx.io/dino/pki"
 	"soft.structx.io/dino/sessions"
+	"soft.structx.io/dino/setup"
 	"soft.structx.io/dino/tunnel/gateway"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
 	"soft.structx.io/dino/tunnel/verifier"
 )
 
@@ -15,8 +20,11 @@ type Params struct {
 
 	Logger *teapot.Logger
 
-	Mux      sessions.Multiplexer
-	Verifier verifier.Verifier
+	Cfg *setup.Server
+
+	Mux       sessions.Multiplexer
+	Verifier  verifier.Verifier
+	Authority pki.Authority
 }
 
 // Result
@@ -24,17 +32,32 @@ type Result struct {
 	fx.Out
 
 	Transport *gateway.TunnelTransport
+	Enroll    *gateway.EnrollTransport
 }
 
 // Module
 var Module = fx.Module("rtunnel_rpc", fx.Provide(newModule))
 
-func newModule(p Params) Result {
-	rts := newReverseTunnelServer(p.Logger, p.Mux, p.Verifier)
+func newModule(p Params) (Result, error) {
+	compression := make([]tunnelnet.Compression, 0, len(p.Cfg.Compression))
+	for _, name := range p.Cfg.Compression {
+		mode, err := tunnelnet.ParseCompression(name)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid server compression: %w", err)
+		}
+		compression = append(compression, mode)
+	}
+
+	rts := newReverseTunnelServer(p.Logger, p.Mux, p.Verifier, compression)
 	return Result{
 		Transport: &gateway.TunnelTransport{
 			ServiceDesc: &pb.ReverseTunnelService_ServiceDesc,
 			Service:     rts,
+			Drain:       p.Mux.Drain,
 		},
-	}
+		Enroll: &gateway.EnrollTransport{
+			ServiceDesc: &pb.EnrollmentService_ServiceDesc,
+			Service:     newEnrollmentServer(p.Logger, p.Verifier, p.Authority),
+		},
+	}, nil
 }
@@ -0,0 +1,673 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	"go.uber.org/fx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"soft.structx.io/dino/auth"
+	pb "soft.structx.io/dino/pb/rtunnel/v1"
+	"soft.structx.io/dino/pki"
+	"soft.structx.io/dino/sessions"
+	"soft.structx.io/dino/setup"
+	"soft.structx.io/dino/tunnel/gateway"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+	"soft.structx.io/dino/tunnel/router"
+	"soft.structx.io/dino/tunnel/rpc/client"
+	tunnelsessions "soft.structx.io/dino/tunnel/sessions"
+	"soft.structx.io/dino/tunnel/transport"
+	"soft.structx.io/dino/tunnel/verifier"
+)
+
+// testAuthority throwaway ca issuing the gateway and agent certificates
+type testAuthority struct {
+	cert    *x509.Certificate
+	certPEM []byte
+	key     *ecdsa.PrivateKey
+}
+
+// interface compliance
+var _ pki.Authority = (*testAuthority)(nil)
+
+func newTestAuthority() (*testAuthority, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+
+	return &testAuthority{
+		cert:    cert,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		key:     key,
+	}, nil
+}
+
+// CertificatePEM implements pki.Authority.
+func (a *testAuthority) CertificatePEM() []byte {
+	return a.certPEM
+}
+
+// Pool implements pki.Authority.
+func (a *testAuthority) Pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(a.cert)
+	return pool
+}
+
+// IssueClient implements pki.Authority.
+func (a *testAuthority) IssueClient(tunnelID string, csrPEM []byte) ([]byte, error) {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil {
+		return nil, pki.ErrInvalidRequest
+	}
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := a.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: tunnelID},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, csr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), nil
+}
+
+// ServerCertificate implements pki.Authority.
+func (a *testAuthority) ServerCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := a.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "test gateway"},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, &key.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &tls.Certificate{Certificate: [][]byte{der, a.cert.Raw}, PrivateKey: key}, nil
+}
+
+// clientKeyPair pem encoded certificate and key of an agent of tunnelID
+func (a *testAuthority) clientKeyPair(tunnelID string) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	der, err := a.issue(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: tunnelID},
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, &key.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), nil
+}
+
+func (a *testAuthority) issue(template *x509.Certificate, pub any) ([]byte, error) {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 64))
+	if err != nil {
+		return nil, err
+	}
+
+	template.SerialNumber = serial
+	template.NotBefore = time.Now().Add(-time.Minute)
+	template.NotAfter = time.Now().Add(time.Hour)
+	return x509.CreateCertificate(rand.Reader, template, a.cert, pub, a.key)
+}
+
+// fakeMultiplexer hands every registered tunnel conn to the test
+type fakeMultiplexer struct {
+	sessions.Multiplexer
+
+	conns chan tunnelnet.Conn
+}
+
+// RegisterTunnel implements sessions.Multiplexer.
+func (f *fakeMultiplexer) RegisterTunnel(_ context.Context, conn tunnelnet.Conn, _, _ string) (<-chan struct{}, error) {
+	f.conns <- conn
+	return make(chan struct{}), nil
+}
+
+// DeregisterTunnel implements sessions.Multiplexer.
+func (f *fakeMultiplexer) DeregisterTunnel(context.Context, tunnelnet.Conn, string) error {
+	return nil
+}
+
+// SyncRoutes implements sessions.Multiplexer.
+func (f *fakeMultiplexer) SyncRoutes(context.Context, tunnelnet.Conn, string) error {
+	return nil
+}
+
+// Drain implements sessions.Multiplexer.
+func (f *fakeMultiplexer) Drain(context.Context) error {
+	return nil
+}
+
+// fakeVerifier accepts the token of every tunnel
+type fakeVerifier struct{}
+
+// interface compliance
+var _ verifier.Verifier = (*fakeVerifier)(nil)
+
+// VerifyToken implements verifier.Verifier.
+func (fakeVerifier) VerifyToken(_ context.Context, tunnelID, token string) (verifier.Token, error) {
+	if token != "token" {
+		return verifier.Token{}, errors.New("invalid token")
+	}
+	return verifier.Token{Claims: &auth.Claims{RegisteredClaims: jwt.RegisteredClaims{ID: tunnelID}}}, nil
+}
+
+// RefreshToken implements verifier.Verifier.
+func (fakeVerifier) RefreshToken(context.Context, string, string) (string, error) {
+	return "", errors.New("refresh not supported")
+}
+
+// TunnelSuite serves the tunnel gateway and connects a real agent to it
+type TunnelSuite struct {
+	suite.Suite
+
+	logger    *teapot.Logger
+	authority *testAuthority
+	mux       *fakeMultiplexer
+
+	serverCfg *setup.Server
+	server    *fx.App
+	agents    []*fx.App
+}
+
+func (suite *TunnelSuite) SetupTest() {
+	suite.logger = teapot.New(teapot.WithWriter(io.Discard))
+
+	var err error
+	suite.authority, err = newTestAuthority()
+	suite.Require().NoError(err)
+
+	suite.mux = &fakeMultiplexer{conns: make(chan tunnelnet.Conn, 1)}
+	suite.serverCfg = &setup.Server{
+		QuicHost:     "127.0.0.1",
+		QuicPort:     suite.freePort("udp"),
+		EnrollPort:   suite.freePort("tcp"),
+		DrainTimeout: time.Second,
+	}
+
+	suite.server = fx.New(
+		fx.NopLogger,
+		fx.Supply(suite.logger, suite.serverCfg),
+		fx.Supply(
+			fx.Annotate(suite.authority, fx.As(new(pki.Authority))),
+			fx.Annotate(suite.mux, fx.As(new(sessions.Multiplexer))),
+			fx.Annotate(fakeVerifier{}, fx.As(new(verifier.Verifier))),
+			gateway.StreamServerInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				return handler(srv, ss)
+			}),
+		),
+		Module,
+		gateway.Module,
+	)
+	suite.Require().NoError(suite.server.Start(context.Background()))
+}
+
+func (suite *TunnelSuite) TearDownTest() {
+	// agents disconnect first, open tunnel streams hold up the server shutdown
+	for _, app := range suite.agents {
+		suite.NoError(app.Stop(context.Background()))
+	}
+	suite.agents = nil
+
+	suite.NoError(suite.server.Stop(context.Background()))
+}
+
+// freePort unused local port of network
+func (suite *TunnelSuite) freePort(network string) string {
+	var addr net.Addr
+	if network == "udp" {
+		pc, err := net.ListenPacket(network, "127.0.0.1:0")
+		suite.Require().NoError(err)
+		addr = pc.LocalAddr()
+		suite.Require().NoError(pc.Close())
+	} else {
+		lis, err := net.Listen(network, "127.0.0.1:0")
+		suite.Require().NoError(err)
+		addr = lis.Addr()
+		suite.Require().NoError(lis.Close())
+	}
+
+	_, port, err := net.SplitHostPort(addr.String())
+	suite.Require().NoError(err)
+	return port
+}
+
+// unresponsiveBackend address whose accept queue is full, dials to it hang
+// until they time out
+func (suite *TunnelSuite) unresponsiveBackend() netip.AddrPort {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	suite.Require().NoError(err)
+	suite.Require().NoError(syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}))
+	suite.Require().NoError(syscall.Listen(fd, 0))
+
+	f := os.NewFile(uintptr(fd), "unresponsive")
+	defer func() { _ = f.Close() }()
+
+	lis, err := net.FileListener(f)
+	if err != nil {
+		suite.T().Skipf("unresponsive backend: %v", err)
+	}
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	// never accepted, fills the queue
+	queued, err := net.DialTimeout("tcp", lis.Addr().String(), time.Second)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = queued.Close() })
+
+	return lis.Addr().(*net.TCPAddr).AddrPort()
+}
+
+// echoBackend address of a tcp backend writing back everything it reads
+func (suite *TunnelSuite) echoBackend() netip.AddrPort {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = lis.Close() })
+
+	go func() {
+		for {
+			c, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = c.Close() }()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+	return lis.Addr().(*net.TCPAddr).AddrPort()
+}
+
+// openSession route tcp sessions of the agent behind conn to backend and
+// open session through it
+func (suite *TunnelSuite) openSession(conn tunnelnet.Conn, routes router.Mux, backend netip.AddrPort, timeouts tunnelnet.Timeouts) {
+	_, err := conn.Write(&tunnelnet.DataFrame{
+		RouteUpdate: &tunnelnet.RouteUpdate{
+			RouteID:      "backend",
+			Hostname:     "backend.dino.local",
+			DestProtocol: "tcp",
+			DestIP:       backend.Addr().String(),
+			DestPort:     uint32(backend.Port()),
+		},
+	})
+	suite.Require().NoError(err)
+
+	suite.Eventually(func() bool {
+		_, ok := routes.Get("backend")
+		return ok
+	}, time.Second*5, time.Millisecond*10)
+
+	_, err = conn.Write(&tunnelnet.DataFrame{
+		SessionID:      "session",
+		IsControlFrame: true,
+		NewConn: &tunnelnet.NewConn{
+			Hostname: "backend.dino.local",
+			Protocol: "tcp",
+			RouteID:  "backend",
+			Timeouts: timeouts,
+		},
+	})
+	suite.Require().NoError(err)
+}
+
+// frames every frame the agent behind conn sends
+func (suite *TunnelSuite) frames(conn tunnelnet.Conn) <-chan *tunnelnet.DataFrame {
+	frames := make(chan *tunnelnet.DataFrame, 16)
+	go func() {
+		for {
+			df, err := conn.Read()
+			if err != nil {
+				return
+			}
+			frames <- df
+		}
+	}()
+	return frames
+}
+
+// pinnedDir cert dir of an agent that holds the pinned ca but did not enroll yet
+func (suite *TunnelSuite) pinnedDir() string {
+	dir := suite.T().TempDir()
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "ca.crt"), suite.authority.certPEM, 0o600))
+	return dir
+}
+
+// enrolledDir cert dir of an agent of tunnelID holding an issued certificate
+func (suite *TunnelSuite) enrolledDir(tunnelID string) string {
+	dir := suite.T().TempDir()
+
+	certPEM, keyPEM, err := suite.authority.clientKeyPair(tunnelID)
+	suite.Require().NoError(err)
+
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "ca.crt"), suite.authority.certPEM, 0o600))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "agent.crt"), certPEM, 0o600))
+	suite.Require().NoError(os.WriteFile(filepath.Join(dir, "agent.key"), keyPEM, 0o600))
+	return dir
+}
+
+// agent start an agent with cfg, its router receives the routes of the server
+func (suite *TunnelSuite) agent(cfg *setup.Tunnel) (client.Tunneler, router.Mux) {
+	var (
+		tunneler client.Tunneler
+		routes   router.Mux
+	)
+
+	app := fx.New(
+		fx.NopLogger,
+		fx.Supply(suite.logger, cfg),
+		tunnelsessions.Module,
+		router.Module,
+		client.Module,
+		fx.Populate(&tunneler, &routes),
+	)
+	suite.Require().NoError(app.Start(context.Background()))
+	suite.agents = append(suite.agents, app)
+
+	return tunneler, routes
+}
+
+// tunnelCfg agent config of tunnel web dialing the test gateway
+func (suite *TunnelSuite) tunnelCfg(certDir string) *setup.Tunnel {
+	return &setup.Tunnel{
+		ID:                  "web",
+		Token:               "token",
+		Endpoint:            net.JoinHostPort("127.0.0.1", suite.serverCfg.QuicPort),
+		EnrollEndpoint:      net.JoinHostPort("127.0.0.1", suite.serverCfg.EnrollPort),
+		CertDir:             certDir,
+		DialTimeout:         time.Second * 10,
+		ReconnectBackoff:    time.Millisecond * 50,
+		ReconnectBackoffMax: time.Millisecond * 50,
+	}
+}
+
+// registered tunnel conn of the next agent connecting to the server
+func (suite *TunnelSuite) registered() tunnelnet.Conn {
+	select {
+	case conn := <-suite.mux.conns:
+		return conn
+	case <-time.After(time.Second * 5):
+		suite.FailNow("agent did not connect")
+		return nil
+	}
+}
+
+// tunnelClient grpc client of the tunnel port presenting cert, nil sends no certificate
+func (suite *TunnelSuite) tunnelClient(cert *tls.Certificate) pb.ReverseTunnelServiceClient {
+	tlsConfig := &tls.Config{
+		ServerName: "127.0.0.1",
+		RootCAs:    suite.authority.Pool(),
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert == nil {
+				return &tls.Certificate{}, nil
+			}
+			return cert, nil
+		},
+		NextProtos: []string{"h3"},
+		MinVersion: tls.VersionTLS13,
+	}
+
+	cc, err := grpc.NewClient(net.JoinHostPort("127.0.0.1", suite.serverCfg.QuicPort),
+		grpc.WithTransportCredentials(transport.NewCredentials(tlsConfig)),
+		grpc.WithContextDialer(transport.NewQuicDialer(tlsConfig)),
+	)
+	suite.Require().NoError(err)
+	suite.T().Cleanup(func() { _ = cc.Close() })
+
+	return pb.NewReverseTunnelServiceClient(cc)
+}
+
+// establish open a tunnel stream of web and wait for the first answer
+func (suite *TunnelSuite) establish(cli pb.ReverseTunnelServiceClient) error {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+
+	ctx = metadata.NewOutgoingContext(ctx, metadata.Pairs("tunnel-id", "web", "authorization", "token"))
+	stream, err := cli.EstablishTunnel(ctx)
+	if err != nil {
+		return err
+	}
+	_, err = stream.Recv()
+	return err
+}
+
+func (suite *TunnelSuite) TestEnroll() {
+	dir := suite.pinnedDir()
+	tunneler, _ := suite.agent(suite.tunnelCfg(dir))
+	suite.registered()
+
+	suite.Eventually(func() bool {
+		return tunneler.State() == client.StateConnected
+	}, time.Second*5, time.Millisecond*10)
+	suite.FileExists(filepath.Join(dir, "agent.crt"))
+	suite.FileExists(filepath.Join(dir, "agent.key"))
+}
+
+func (suite *TunnelSuite) TestEnrollFingerprint() {
+	cfg := suite.tunnelCfg("")
+	cfg.CAFingerprint = pki.Fingerprint(suite.authority.cert)
+	suite.agent(cfg)
+	suite.registered()
+}
+
+func (suite *TunnelSuite) TestEnrollOtherCA() {
+	other, err := newTestAuthority()
+	suite.Require().NoError(err)
+
+	// the gateway certificate does not chain to the pinned ca
+	cfg := suite.tunnelCfg("")
+	cfg.CAFingerprint = pki.Fingerprint(other.cert)
+	tunneler, _ := suite.agent(cfg)
+
+	suite.Eventually(func() bool {
+		return tunneler.State() == client.StateBackoff
+	}, time.Second*5, time.Millisecond*10)
+	suite.Empty(suite.mux.conns)
+}
+
+func (suite *TunnelSuite) TestNoClientCertificate() {
+	err := suite.establish(suite.tunnelClient(nil))
+	suite.Equal(codes.Unavailable, status.Code(err), err)
+	suite.Empty(suite.mux.conns)
+}
+
+func (suite *TunnelSuite) TestCertificateOfOtherTunnel() {
+	certPEM, keyPEM, err := suite.authority.clientKeyPair("db")
+	suite.Require().NoError(err)
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	suite.Require().NoError(err)
+
+	err = suite.establish(suite.tunnelClient(&cert))
+	suite.Equal(codes.PermissionDenied, status.Code(err), err)
+	suite.Empty(suite.mux.conns)
+}
+
+func (suite *TunnelSuite) TestFrames() {
+	_, routes := suite.agent(suite.tunnelCfg(suite.enrolledDir("web")))
+	conn := suite.registered()
+
+	const frames = 16
+
+	// sessions write to the tunnel concurrently
+	var wg sync.WaitGroup
+	for i := range frames {
+		wg.Go(func() {
+			_, err := conn.Write(&tunnelnet.DataFrame{
+				IsControlFrame: true,
+				Ping:           &tunnelnet.Heartbeat{Nonce: uint64(i)},
+			})
+			suite.NoError(err)
+		})
+	}
+
+	pongs := make(chan uint64, frames)
+	go func() {
+		for {
+			df, err := conn.Read()
+			if err != nil {
+				return
+			}
+			if df.Pong != nil {
+				pongs <- df.Pong.Nonce
+			}
+		}
+	}()
+
+	received := map[uint64]bool{}
+	for range frames {
+		select {
+		case nonce := <-pongs:
+			received[nonce] = true
+		case <-time.After(time.Second * 5):
+			suite.FailNow("agent did not answer every ping", "received %d", len(received))
+		}
+	}
+	suite.Len(received, frames)
+	wg.Wait()
+
+	_, err := conn.Write(&tunnelnet.DataFrame{
+		RouteUpdate: &tunnelnet.RouteUpdate{
+			RouteID:      "route-id",
+			Hostname:     "web.dino.local",
+			DestProtocol: "http",
+			DestIP:       "127.0.0.1",
+			DestPort:     8080,
+		},
+	})
+	suite.Require().NoError(err)
+
+	suite.Eventually(func() bool {
+		_, ok := routes.Get("route-id")
+		return ok
+	}, time.Second*5, time.Millisecond*10)
+}
+
+func (suite *TunnelSuite) TestPongsDuringDial() {
+	_, routes := suite.agent(suite.tunnelCfg(suite.enrolledDir("web")))
+	conn := suite.registered()
+
+	frames := suite.frames(conn)
+	suite.openSession(conn, routes, suite.unresponsiveBackend(), tunnelnet.Timeouts{})
+
+	// the agent answers every ping while the backend of the session is dialed
+	for i := range 5 {
+		_, err := conn.Write(&tunnelnet.DataFrame{
+			IsControlFrame: true,
+			Ping:           &tunnelnet.Heartbeat{Nonce: uint64(i)},
+		})
+		suite.Require().NoError(err)
+
+		select {
+		case df := <-frames:
+			suite.Require().NotNil(df.Pong, "session answered before its dial ended: %+v", df)
+			suite.Equal(uint64(i), df.Pong.Nonce)
+		case <-time.After(time.Second):
+			suite.FailNow("ping not answered while a session dial is pending")
+		}
+	}
+}
+
+func (suite *TunnelSuite) TestDialTimeout() {
+	cfg := suite.tunnelCfg(suite.enrolledDir("web"))
+	cfg.DialTimeout = time.Millisecond * 100
+	_, routes := suite.agent(cfg)
+	conn := suite.registered()
+
+	frames := suite.frames(conn)
+	suite.openSession(conn, routes, suite.unresponsiveBackend(), tunnelnet.Timeouts{})
+
+	select {
+	case df := <-frames:
+		suite.Equal("session", df.SessionID)
+		suite.Require().NotNil(df.CloseConn)
+		suite.Equal(tunnelnet.CloseDialTimeout, df.CloseConn.Reason)
+	case <-time.After(time.Second * 5):
+		suite.Fail("session dial did not time out")
+	}
+}
+
+func (suite *TunnelSuite) TestSessionTimeouts() {
+	_, routes := suite.agent(suite.tunnelCfg(suite.enrolledDir("web")))
+	conn := suite.registered()
+
+	// the agent enforces the limits the server sent with the session
+	frames := suite.frames(conn)
+	suite.openSession(conn, routes, suite.echoBackend(), tunnelnet.Timeouts{Idle: time.Millisecond * 100})
+
+	select {
+	case df := <-frames:
+		suite.Equal("session", df.SessionID)
+		suite.Require().NotNil(df.CloseConn)
+		suite.Equal(tunnelnet.CloseIdleTimeout, df.CloseConn.Reason)
+	case <-time.After(time.Second * 5):
+		suite.Fail("agent did not expire the idle session")
+	}
+}
+
+func TestTunnelSuite(t *testing.T) {
+	suite.Run(t, new(TunnelSuite))
+}
@@ -0,0 +1,111 @@
+package sessions
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"go.uber.org/multierr"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// maxDatagramSize largest udp payload
+const maxDatagramSize = 64 * 1024
+
+// datagramActor forwards tunnel datagrams to a local udp socket one packet at a time
+type datagramActor struct {
+	sessionID string
+	incoming  chan []byte
+	done      chan struct{}
+	errCh     chan error
+	outbound  tunnelnet.Conn
+	localConn *net.UDPConn
+	// cleanup deregister the session and tell the server why it ended
+	cleanup func(tunnelnet.CloseReason)
+	// deadline idle and lifetime limits sent by the server
+	deadline  *tunnelnet.Deadline
+	closeOnce sync.Once
+}
+
+// interface compliance
+var _ actor = (*datagramActor)(nil)
+
+// tunnel implements actor.
+func (d *datagramActor) tunnel() tunnelnet.Conn { return d.outbound }
+
+// close implements actor.
+func (d *datagramActor) close() error {
+	var result error
+	d.closeOnce.Do(func() {
+		close(d.done)
+		if err := d.localConn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			result = multierr.Append(result, fmt.Errorf("failed to close local conn: %w", err))
+		}
+	})
+	return result
+}
+
+// routeIncoming implements actor.
+func (d *datagramActor) routeIncoming(df *tunnelnet.DataFrame) error {
+	select {
+	case d.incoming <- df.Payload:
+	case <-d.done:
+	default:
+		// udp tolerates loss, a slow local socket must not stall the tunnel
+	}
+	return nil
+}
+
+// handleConn implements actor.
+func (d *datagramActor) handleConn() {
+	go func() {
+		buf := make([]byte, maxDatagramSize)
+		for {
+			n, err := d.localConn.Read(buf)
+			if err != nil {
+				if !errors.Is(err, net.ErrClosed) {
+					d.errCh <- fmt.Errorf("failed to read local datagram: %w", err)
+				}
+				return
+			}
+
+			pkt := make([]byte, n)
+			copy(pkt, buf[:n])
+			d.deadline.Touch()
+
+			if _, err := d.outbound.Write(&tunnelnet.DataFrame{
+				SessionID:  d.sessionID,
+				Payload:    pkt,
+				IsDatagram: true,
+			}); err != nil {
+				d.errCh <- fmt.Errorf("failed to write datagram to gRPC stream: %w", err)
+				return
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-d.done:
+			return
+		case pkt := <-d.incoming:
+			d.deadline.Touch()
+			if _, err := d.localConn.Write(pkt); err != nil && !errors.Is(err, net.ErrClosed) {
+				d.errCh <- fmt.Errorf("failed to write local datagram: %w", err)
+			}
+		case now := <-ticker.C:
+			if reason, ok := d.deadline.Expired(now); ok {
+				d.cleanup(reason)
+				if err := d.close(); err != nil {
+					d.errCh <- err
+				}
+				return
+			}
+		}
+	}
+}
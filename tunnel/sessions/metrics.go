@@ -0,0 +1,14 @@
+package sessions
+
+import (
+	"expvar"
+
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// closeReasons sessions ended by either side, keyed by close reason
+var closeReasons = expvar.NewMap("dino_session_close_reasons")
+
+func recordClose(reason tunnelnet.CloseReason) {
+	closeReasons.Add(reason.String(), 1)
+}
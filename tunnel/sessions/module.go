@@ -3,6 +3,7 @@ package sessions
 import (
 	"github.com/structx/teapot"
 	"go.uber.org/fx"
+	"soft.structx.io/dino/setup"
 )
 
 // Params
@@ -12,6 +13,7 @@ type Params struct {
 	Lc fx.Lifecycle
 
 	Logger *teapot.Logger
+	Cfg    *setup.Tunnel
 }
 
 // Result
@@ -25,7 +27,7 @@ type Result struct {
 var Module = fx.Module("tunnel_sessions", fx.Provide(newModule))
 
 func newModule(p Params) Result {
-	sessionMux := newMux(p.Logger)
+	sessionMux := newMux(p.Logger, p.Cfg.DialTimeout)
 	p.Lc.Append(fx.Hook{
 		OnStart: sessionMux.start,
 		OnStop:  sessionMux.stop,
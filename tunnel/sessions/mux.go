@@ -8,59 +8,111 @@ import (
 	"net"
 	"net/netip"
 	"sync"
+	"time"
 
 	"github.com/structx/teapot"
 	"go.uber.org/multierr"
 	tunnelnet "soft.structx.io/dino/tunnel/net"
 )
 
+// datagramBufferSize udp packets queued per session before newer ones are dropped
+const datagramBufferSize = 64
+
+// streamAttachTimeout max wait between a session opening and its dedicated stream arriving
+const streamAttachTimeout = 10 * time.Second
+
+// expiryInterval between checks of a session against its idle timeout and max lifetime
+const expiryInterval = time.Second
+
+// errDialAborted session was closed while its backend was dialed
+var errDialAborted = errors.New("session closed while dialing")
+
 type actor interface {
-	routeIncoming([]byte)
+	routeIncoming(*tunnelnet.DataFrame) error
 	handleConn()
 	close() error
+	// tunnel stream the session was opened on
+	tunnel() tunnelnet.Conn
 }
 
 type sessionActor struct {
 	sessionID string
-	incoming  chan []byte
+	conn      tunnelnet.Conn
+	stream    *tunnelnet.Stream
+	// attached dedicated transport stream, nil when payloads use data frames
+	attached  chan io.ReadWriteCloser
+	done      chan struct{}
 	errCh     chan error
-	outbound  io.WriteCloser
 	localConn net.Conn
-	cleanup   func()
+	// cleanup deregister the session and tell the server why it ended
+	cleanup func(tunnelnet.CloseReason)
+	// deadline idle and lifetime limits sent by the server
+	deadline  *tunnelnet.Deadline
+	closeOnce sync.Once
 }
 
 // close implements actor.
 func (s *sessionActor) close() error {
 	var result error
-	if s.localConn != nil {
-		if err := s.localConn.Close(); err != nil {
-			result = multierr.Append(result, fmt.Errorf("failed to close local conn: %w", err))
-		}
-	}
+	s.closeOnce.Do(func() {
+		close(s.done)
+		_ = s.stream.Close()
 
-	if s.outbound != nil {
-		if err := s.outbound.Close(); err != nil {
-			result = multierr.Append(result, fmt.Errorf("failed to close outbound conn: %w", err))
+		if s.localConn != nil {
+			if err := s.localConn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				result = multierr.Append(result, fmt.Errorf("failed to close local conn: %w", err))
+			}
 		}
-	}
-
-	close(s.incoming)
-
+	})
 	return result
 }
 
+// tunnel implements actor.
+func (s *sessionActor) tunnel() tunnelnet.Conn { return s.conn }
+
 // routeIncoming implements actor.
-func (s *sessionActor) routeIncoming(msg []byte) {
-	s.incoming <- msg
+func (s *sessionActor) routeIncoming(df *tunnelnet.DataFrame) error {
+	if df.WindowUpdate != nil {
+		s.stream.Grant(df.WindowUpdate.Credit)
+		return nil
+	}
+	if df.Fin {
+		s.stream.Finish(df.Seq)
+		return nil
+	}
+	return s.stream.Deliver(df.Seq, df.Payload)
 }
 
 // interface compliance
 var _ actor = (*sessionActor)(nil)
 
+// dialingSession session whose backend is dialed, frames arriving meanwhile
+// are buffered in its stream
+type dialingSession struct {
+	conn tunnelnet.Conn
+	// stream nil for udp sessions, their datagrams are dropped until connected
+	stream *tunnelnet.Stream
+	cancel context.CancelFunc
+}
+
+// abort stop the dial, the session is not answered
+func (d *dialingSession) abort() {
+	d.cancel()
+	if d.stream != nil {
+		_ = d.stream.Close()
+	}
+}
+
 type Mux interface {
-	InitSession(tunnelnet.Conn, string, string, string) error
-	RouteMsg(string, []byte) error
-	CloseSession(string) error
+	InitSession(tunnelnet.Conn, string, string, string, bool, tunnelnet.Timeouts) error
+	AttachStream(string, io.ReadWriteCloser) error
+	RouteMsg(*tunnelnet.DataFrame) error
+	// CloseSession end a session the server closed for reason
+	CloseSession(string, tunnelnet.CloseReason) error
+	// Reject refuse a session the agent cannot serve, the server is told why
+	Reject(tunnelnet.Conn, string, tunnelnet.CloseReason, error) error
+	// Reset close every session of a tunnel stream that ended
+	Reset(tunnelnet.Conn) error
 
 	start(context.Context) error
 	stop(context.Context) error
@@ -72,95 +124,334 @@ type sessionMultiplexer struct {
 	mtx    sync.RWMutex
 	actors map[string]actor
 	errCh  chan error
+
+	// pending dedicated streams that arrived before their session opened
+	pending map[string]io.ReadWriteCloser
+	// dialing sessions registered once their backend accepted them
+	dialing map[string]*dialingSession
+
+	// dial connects the backend of a session
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
 }
 
 // interface compliance
 var _ Mux = (*sessionMultiplexer)(nil)
 
-func newMux(logger *teapot.Logger) Mux {
+func newMux(logger *teapot.Logger, dialTimeout time.Duration) Mux {
 	return &sessionMultiplexer{
 		log:    logger,
 		mtx:    sync.RWMutex{},
 		errCh:  make(chan error),
 		actors: map[string]actor{},
+
+		pending: map[string]io.ReadWriteCloser{},
+		dialing: map[string]*dialingSession{},
+
+		dial: (&net.Dialer{Timeout: dialTimeout}).DialContext,
 	}
 }
 
 // CloseSession implements Manager.
-func (s *sessionMultiplexer) CloseSession(sessionID string) error {
+func (s *sessionMultiplexer) CloseSession(sessionID string, reason tunnelnet.CloseReason) error {
+	recordClose(reason)
+	if reason != tunnelnet.CloseNormal && reason != tunnelnet.CloseUnspecified {
+		s.log.Info("session closed by server", teapot.String("session", sessionID), teapot.String("reason", reason.String()))
+	}
+
 	s.mtx.Lock()
-	defer delete(s.actors, sessionID)
-	defer s.mtx.Unlock()
-	if _, ok := s.actors[sessionID]; ok {
-		return s.actors[sessionID].close()
+	a, ok := s.actors[sessionID]
+	delete(s.actors, sessionID)
+	ds, dialing := s.dialing[sessionID]
+	delete(s.dialing, sessionID)
+	s.mtx.Unlock()
+
+	if dialing {
+		ds.abort()
+	}
+	if ok {
+		return a.close()
+	}
+	return nil
+}
+
+// Reject implements Mux.
+func (s *sessionMultiplexer) Reject(conn tunnelnet.Conn, sessionID string, reason tunnelnet.CloseReason, cause error) error {
+	recordClose(reason)
+	s.log.Error("reject session",
+		teapot.String("session", sessionID),
+		teapot.String("reason", reason.String()),
+		teapot.Error(cause))
+
+	if _, err := conn.Write(&tunnelnet.DataFrame{
+		SessionID:      sessionID,
+		IsControlFrame: true,
+		CloseConn: &tunnelnet.CloseConn{
+			Status:  1,
+			Reason:  reason,
+			Message: cause.Error(),
+		},
+	}); err != nil {
+		return fmt.Errorf("conn.Write: %w", err)
 	}
 	return nil
 }
 
 // RouteMsg implements Manager.
-func (s *sessionMultiplexer) RouteMsg(sessionID string, payload []byte) error {
+func (s *sessionMultiplexer) RouteMsg(df *tunnelnet.DataFrame) error {
 	s.mtx.RLock()
-	actor, ok := s.actors[sessionID]
+	actor, ok := s.actors[df.SessionID]
+	ds, dialing := s.dialing[df.SessionID]
 	s.mtx.RUnlock()
 
+	if dialing {
+		return s.routeDialing(df, ds)
+	}
 	if !ok {
 		return errors.New("session not active")
 	}
 
-	go actor.routeIncoming(payload)
+	// delivery never blocks, a peer overrunning its window ends the session
+	if err := actor.routeIncoming(df); err != nil {
+		if cerr := actor.close(); cerr != nil {
+			err = multierr.Append(err, cerr)
+		}
+		return fmt.Errorf("route incoming: %w", err)
+	}
 
 	return nil
 }
 
-// initConn implements Manager.
-func (s *sessionMultiplexer) InitSession(conn tunnelnet.Conn, sessionID, protocol, addr string) error {
+// routeDialing buffer a frame of a session whose backend is not connected yet
+func (s *sessionMultiplexer) routeDialing(df *tunnelnet.DataFrame, ds *dialingSession) error {
+	if ds.stream == nil {
+		return nil
+	}
+	if df.WindowUpdate != nil {
+		ds.stream.Grant(df.WindowUpdate.Credit)
+		return nil
+	}
+	if df.Fin {
+		ds.stream.Finish(df.Seq)
+		return nil
+	}
+
+	if err := ds.stream.Deliver(df.Seq, df.Payload); err != nil {
+		s.mtx.Lock()
+		delete(s.dialing, df.SessionID)
+		s.mtx.Unlock()
+
+		ds.abort()
+		return fmt.Errorf("route incoming: %w", err)
+	}
+	return nil
+}
+
+// Reset implements Mux.
+func (s *sessionMultiplexer) Reset(conn tunnelnet.Conn) error {
+	// a draining stream ends while its replacement already serves sessions,
+	// pending streams expire on their own
 	s.mtx.Lock()
-	defer s.mtx.Unlock()
+	var actors []actor
+	for sessionID, a := range s.actors {
+		if a.tunnel() == conn {
+			actors = append(actors, a)
+			delete(s.actors, sessionID)
+		}
+	}
+	var dialing []*dialingSession
+	for sessionID, ds := range s.dialing {
+		if ds.conn == conn {
+			dialing = append(dialing, ds)
+			delete(s.dialing, sessionID)
+		}
+	}
+	s.mtx.Unlock()
+
+	for _, ds := range dialing {
+		ds.abort()
+	}
+
+	var result error
+	for _, a := range actors {
+		if err := a.close(); err != nil {
+			result = multierr.Append(result, fmt.Errorf("failed to close actor: %w", err))
+		}
+	}
+	return result
+}
+
+// AttachStream implements Mux.
+func (s *sessionMultiplexer) AttachStream(sessionID string, rwc io.ReadWriteCloser) error {
+	s.mtx.Lock()
+	a, ok := s.actors[sessionID]
+	if !ok {
+		// the open session control frame may still be in flight
+		s.pending[sessionID] = rwc
+		s.mtx.Unlock()
+
+		s.expirePending(sessionID, rwc)
+		return nil
+	}
+	s.mtx.Unlock()
+
+	sa, ok := a.(*sessionActor)
+	if !ok || sa.attached == nil {
+		_ = rwc.Close()
+		return errors.New("session does not use a dedicated stream")
+	}
+
+	select {
+	case sa.attached <- rwc:
+		return nil
+	default:
+		_ = rwc.Close()
+		return errors.New("session stream already attached")
+	}
+}
+
+// expirePending close a dedicated stream whose session did not open in time,
+// the wait is extended while the backend of the session is dialed
+func (s *sessionMultiplexer) expirePending(sessionID string, rwc io.ReadWriteCloser) {
+	time.AfterFunc(streamAttachTimeout, func() {
+		s.mtx.Lock()
+		if _, ok := s.dialing[sessionID]; ok {
+			s.mtx.Unlock()
+			s.expirePending(sessionID, rwc)
+			return
+		}
+		p, ok := s.pending[sessionID]
+		if ok && p == rwc {
+			delete(s.pending, sessionID)
+		}
+		s.mtx.Unlock()
 
-	ch := make(chan []byte)
+		if ok && p == rwc {
+			_ = rwc.Close()
+		}
+	})
+}
 
+// InitSession implements Mux.
+func (s *sessionMultiplexer) InitSession(conn tunnelnet.Conn, sessionID, protocol, addr string, dedicated bool, timeouts tunnelnet.Timeouts) error {
 	addrPort, err := netip.ParseAddrPort(addr)
 	if err != nil {
-		return fmt.Errorf("netip.ParseAddrPort: %w", err)
+		return s.reject(conn, sessionID, fmt.Errorf("netip.ParseAddrPort: %w", err))
 	}
 
-	var outboundConn io.WriteCloser
-	var localConn net.Conn
-
+	var network string
 	switch protocol {
-	// case "udp":
-	// 	dfn = net.Dialer{
-	// 		LocalAddr: net.UDPAddrFromAddrPort(addrPort),
-	// 	}
-	case "http", "https":
-		tcpAddr := net.TCPAddrFromAddrPort(addrPort)
-		localConn, err = net.DialTCP("tcp", nil, tcpAddr)
-		if err != nil {
-			return fmt.Errorf("net.DialTCP: %w", err)
-		}
-
-		outboundConn = tunnelnet.NewClientWriter(conn, sessionID)
+	case "udp":
+		network = "udp"
+	case "tcp", "http", "https":
+		network = "tcp"
 	default:
-		return fmt.Errorf("unsupported protocol %s", protocol)
+		return s.reject(conn, sessionID, fmt.Errorf("unsupported protocol %s", protocol))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ds := &dialingSession{conn: conn, cancel: cancel}
+	if network == "tcp" {
+		ds.stream = tunnelnet.NewStream(conn, sessionID, tunnelnet.DefaultWindow)
+	}
+
+	s.mtx.Lock()
+	s.dialing[sessionID] = ds
+	s.mtx.Unlock()
+
+	// a slow backend must not hold up the frames of every other session
+	go func() {
+		defer cancel()
+
+		err := s.open(ctx, ds, sessionID, network, addrPort.String(), dedicated, timeouts)
+		if err != nil && !errors.Is(err, errDialAborted) {
+			s.log.Error("init session", teapot.Error(s.reject(conn, sessionID, err)))
+		}
+	}()
+	return nil
+}
+
+// reject refuse a session that could not be opened, the proxy client gets
+// an answer instead of waiting for a timeout
+func (s *sessionMultiplexer) reject(conn tunnelnet.Conn, sessionID string, err error) error {
+	if rerr := s.Reject(conn, sessionID, tunnelnet.DialReason(err), err); rerr != nil {
+		err = multierr.Append(err, rerr)
+	}
+	return err
+}
+
+// open dial the backend of a dialing session and register the session once
+// it is connected
+func (s *sessionMultiplexer) open(ctx context.Context, ds *dialingSession, sessionID, network, addr string, dedicated bool, timeouts tunnelnet.Timeouts) error {
+	localConn, err := s.dial(ctx, network, addr)
+	if err != nil {
+		s.mtx.Lock()
+		if s.dialing[sessionID] == ds {
+			delete(s.dialing, sessionID)
+		}
+		s.mtx.Unlock()
+
+		if errors.Is(ctx.Err(), context.Canceled) {
+			return errDialAborted
+		}
+		ds.abort()
+		return fmt.Errorf("dial %s: %w", network, err)
+	}
+
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	// closed by the server or its tunnel stream ended during the dial
+	if s.dialing[sessionID] != ds {
+		_ = localConn.Close()
+		return errDialAborted
+	}
+	delete(s.dialing, sessionID)
+
+	done := make(chan struct{})
+	deadline := tunnelnet.NewDeadline(timeouts, time.Now())
+
+	if network == "udp" {
+		udpConn, ok := localConn.(*net.UDPConn)
+		if !ok {
+			_ = localConn.Close()
+			return fmt.Errorf("unexpected udp conn %T", localConn)
+		}
+
+		actor := &datagramActor{
+			sessionID: sessionID,
+			localConn: udpConn,
+			errCh:     s.errCh,
+			incoming:  make(chan []byte, datagramBufferSize),
+			done:      done,
+			outbound:  ds.conn,
+			deadline:  deadline,
+			cleanup:   s.cleanup(ds.conn, sessionID, done),
+		}
+
+		s.actors[sessionID] = actor
+		go actor.handleConn()
+		return nil
+	}
+
+	var attached chan io.ReadWriteCloser
+	if dedicated {
+		attached = make(chan io.ReadWriteCloser, 1)
+		if rwc, ok := s.pending[sessionID]; ok {
+			delete(s.pending, sessionID)
+			attached <- rwc
+		}
 	}
 
 	actor := &sessionActor{
 		sessionID: sessionID,
+		conn:      ds.conn,
+		attached:  attached,
 		localConn: localConn,
 		errCh:     s.errCh,
-		incoming:  ch,
-		outbound:  outboundConn,
-		cleanup: func() {
-			if _, err := conn.Write(&tunnelnet.DataFrame{
-				SessionID:      sessionID,
-				IsControlFrame: true,
-				CloseConn: &tunnelnet.CloseConn{
-					Status: 1,
-				},
-			}); err != nil {
-				s.log.Error("conn.Write", teapot.Error(err))
-			}
-		},
+		stream:    ds.stream,
+		done:      done,
+		deadline:  deadline,
+		cleanup:   s.cleanup(ds.conn, sessionID, done),
 	}
 
 	s.actors[sessionID] = actor
@@ -169,14 +460,58 @@ func (s *sessionMultiplexer) InitSession(conn tunnelnet.Conn, sessionID, protoco
 	return nil
 }
 
+// cleanup deregister a session ended by the agent and tell the server why,
+// nothing is sent when the server closed it first
+func (s *sessionMultiplexer) cleanup(conn tunnelnet.Conn, sessionID string, done chan struct{}) func(tunnelnet.CloseReason) {
+	return func(reason tunnelnet.CloseReason) {
+		s.mtx.Lock()
+		delete(s.actors, sessionID)
+		s.mtx.Unlock()
+
+		// server already closed the session when done was closed first
+		select {
+		case <-done:
+			return
+		default:
+		}
+
+		recordClose(reason)
+		if reason != tunnelnet.CloseNormal {
+			s.log.Info("session closed by agent", teapot.String("session", sessionID), teapot.String("reason", reason.String()))
+		}
+
+		if _, err := conn.Write(&tunnelnet.DataFrame{
+			SessionID:      sessionID,
+			IsControlFrame: true,
+			CloseConn: &tunnelnet.CloseConn{
+				Status: 1,
+				Reason: reason,
+			},
+		}); err != nil {
+			s.log.Error("conn.Write", teapot.Error(err))
+		}
+	}
+}
+
 func (s *sessionMultiplexer) start(_ context.Context) error {
 	go s.worker()
 	return nil
 }
 
 func (s *sessionMultiplexer) stop(_ context.Context) error {
-	var result error
+	s.mtx.Lock()
+	for sessionID, ds := range s.dialing {
+		ds.abort()
+		delete(s.dialing, sessionID)
+	}
+	actors := make([]actor, 0, len(s.actors))
 	for _, a := range s.actors {
+		actors = append(actors, a)
+	}
+	s.mtx.Unlock()
+
+	var result error
+	for _, a := range actors {
 		if err := a.close(); err != nil {
 			result = multierr.Append(result, fmt.Errorf("failed to close actor: %w", err))
 		}
@@ -192,30 +527,95 @@ func (s *sessionMultiplexer) worker() {
 }
 
 func (sa *sessionActor) handleConn() {
-	defer sa.cleanup()
+	reason := tunnelnet.CloseNormal
+	defer func() {
+		sa.cleanup(reason)
+		if err := sa.close(); err != nil {
+			sa.errCh <- err
+		}
+	}()
 
-	var (
-		wg sync.WaitGroup
+	var rw io.ReadWriteCloser = sa.stream
+	if sa.attached != nil {
+		timer := time.NewTimer(streamAttachTimeout)
+		defer timer.Stop()
+
+		select {
+		case rw = <-sa.attached:
+			defer func() { _ = rw.Close() }()
+		case <-sa.done:
+			return
+		case <-timer.C:
+			sa.errCh <- fmt.Errorf("session %s stream was not attached", sa.sessionID)
+			return
+		}
+	}
 
-		rc = tunnelnet.NewReader(sa.incoming)
-	)
+	errCh := make(chan error, 2)
 
-	wg.Add(2)
 	go func() {
-		defer wg.Done()
 		// read from gRPC stream and write to local conn
-		if _, err := io.Copy(sa.localConn, rc); err != nil && !errors.Is(err, net.ErrClosed) {
-			sa.errCh <- fmt.Errorf("failed to copy from gRPC stream to local conn: %w", err)
+		_, err := io.Copy(sa.localConn, activityReader{rw, sa.deadline})
+		if err == nil {
+			// the server finished writing, the backend may still answer
+			err = tunnelnet.CloseWrite(sa.localConn)
 		}
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			err = fmt.Errorf("failed to copy from gRPC stream to local conn: %w", err)
+		}
+		errCh <- err
 	}()
 
 	go func() {
-		defer wg.Done()
 		// read from local conn and write to gRPC stream
-		if _, err := io.Copy(sa.outbound, sa.localConn); err != nil && !errors.Is(err, net.ErrClosed) {
-			sa.errCh <- fmt.Errorf("failed to copy from local conn to gRPC stream: %w", err)
+		_, err := io.Copy(rw, activityReader{sa.localConn, sa.deadline})
+		if err == nil {
+			err = tunnelnet.CloseWrite(rw)
+		}
+		if err != nil && !errors.Is(err, net.ErrClosed) {
+			err = fmt.Errorf("failed to copy from local conn to gRPC stream: %w", err)
 		}
+		errCh <- err
 	}()
 
-	wg.Wait()
+	ticker := time.NewTicker(expiryInterval)
+	defer ticker.Stop()
+
+	// both sides finishing, a failed copy or the session expiring ends the
+	// session, a side that finished writing is half closed
+	for pending := 2; pending > 0; {
+		select {
+		case err := <-errCh:
+			pending--
+			if err == nil {
+				continue
+			}
+			if !errors.Is(err, net.ErrClosed) {
+				reason = tunnelnet.CopyReason(err)
+				sa.errCh <- err
+			}
+			return
+		case now := <-ticker.C:
+			if expired, ok := sa.deadline.Expired(now); ok {
+				// closing the attached stream and local conn unblocks both copies
+				reason = expired
+				return
+			}
+		}
+	}
+}
+
+// activityReader records every read payload as session activity
+type activityReader struct {
+	r        io.Reader
+	deadline *tunnelnet.Deadline
+}
+
+// Read implements io.Reader.
+func (a activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.deadline.Touch()
+	}
+	return n, err
 }
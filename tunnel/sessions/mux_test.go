@@ -0,0 +1,257 @@
+package sessions
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/structx/teapot"
+	tunnelnet "soft.structx.io/dino/tunnel/net"
+)
+
+// recordConn hands every frame written by the mux to the test
+type recordConn struct {
+	frames chan *tunnelnet.DataFrame
+}
+
+// interface compliance
+var _ tunnelnet.Conn = (*recordConn)(nil)
+
+// Read implements tunnelnet.Conn.
+func (r *recordConn) Read() (*tunnelnet.DataFrame, error) { return nil, io.EOF }
+
+// Write implements tunnelnet.Conn.
+func (r *recordConn) Write(df *tunnelnet.DataFrame) (int, error) {
+	cp := *df
+	cp.Payload = append([]byte(nil), df.Payload...)
+	r.frames <- &cp
+	return len(df.Payload), nil
+}
+
+// Close implements tunnelnet.Conn.
+func (r *recordConn) Close(string) error { return nil }
+
+// unresponsiveBackend listener whose accept queue is full, dials to it hang
+// until they time out
+func unresponsiveBackend() (net.Listener, error) {
+	fd, err := syscall.Socket(syscall.AF_INET, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Bind(fd, &syscall.SockaddrInet4{Addr: [4]byte{127, 0, 0, 1}}); err != nil {
+		return nil, err
+	}
+	if err := syscall.Listen(fd, 0); err != nil {
+		return nil, err
+	}
+
+	f := os.NewFile(uintptr(fd), "unresponsive")
+	defer func() { _ = f.Close() }()
+
+	lis, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+
+	// never accepted, fills the queue
+	queued, err := net.DialTimeout("tcp", lis.Addr().String(), time.Second)
+	if err != nil {
+		_ = lis.Close()
+		return nil, err
+	}
+	return &queuedListener{Listener: lis, queued: queued}, nil
+}
+
+// queuedListener keeps the conn filling its accept queue open
+type queuedListener struct {
+	net.Listener
+
+	queued net.Conn
+}
+
+// Close implements net.Listener.
+func (q *queuedListener) Close() error {
+	_ = q.queued.Close()
+	return q.Listener.Close()
+}
+
+type MuxSuite struct {
+	suite.Suite
+
+	mux  *sessionMultiplexer
+	conn *recordConn
+
+	slow string
+	echo string
+
+	wg        sync.WaitGroup
+	listeners []net.Listener
+}
+
+func (suite *MuxSuite) SetupTest() {
+	suite.mux = newMux(teapot.New(teapot.WithWriter(io.Discard)), 10*time.Second).(*sessionMultiplexer)
+	suite.Require().NoError(suite.mux.start(context.Background()))
+	suite.conn = &recordConn{frames: make(chan *tunnelnet.DataFrame, 16)}
+
+	slow, err := unresponsiveBackend()
+	if err != nil {
+		suite.T().Skipf("unresponsive backend: %v", err)
+	}
+
+	echo, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+
+	suite.wg.Add(1)
+	go func() {
+		defer suite.wg.Done()
+		for {
+			c, err := echo.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer func() { _ = c.Close() }()
+				_, _ = io.Copy(c, c)
+			}()
+		}
+	}()
+
+	suite.listeners = []net.Listener{slow, echo}
+	suite.slow = slow.Addr().String()
+	suite.echo = echo.Addr().String()
+}
+
+func (suite *MuxSuite) TearDownTest() {
+	suite.NoError(suite.mux.stop(context.Background()))
+	for _, lis := range suite.listeners {
+		_ = lis.Close()
+	}
+	suite.wg.Wait()
+}
+
+func (suite *MuxSuite) TestSlowBackendDoesNotBlock() {
+	start := time.Now()
+	suite.Require().NoError(suite.mux.InitSession(suite.conn, "slow", "tcp", suite.slow, false, tunnelnet.Timeouts{}))
+	suite.Require().NoError(suite.mux.InitSession(suite.conn, "fast", "tcp", suite.echo, false, tunnelnet.Timeouts{}))
+	suite.Less(time.Since(start), time.Second)
+
+	suite.Eventually(func() bool {
+		suite.mux.mtx.RLock()
+		defer suite.mux.mtx.RUnlock()
+		_, ok := suite.mux.actors["fast"]
+		return ok
+	}, 5*time.Second, 10*time.Millisecond)
+
+	suite.Require().NoError(suite.mux.RouteMsg(&tunnelnet.DataFrame{SessionID: "fast", Payload: []byte("ping")}))
+
+	select {
+	case df := <-suite.conn.frames:
+		suite.Equal("fast", df.SessionID)
+		suite.Equal([]byte("ping"), df.Payload)
+	case <-time.After(5 * time.Second):
+		suite.Fail("fast session stalled behind the slow dial")
+	}
+
+	suite.mux.mtx.RLock()
+	_, dialing := suite.mux.dialing["slow"]
+	_, active := suite.mux.actors["slow"]
+	suite.mux.mtx.RUnlock()
+	suite.True(dialing)
+	suite.False(active)
+}
+
+func (suite *MuxSuite) TestDialTimeout() {
+	mux := newMux(teapot.New(teapot.WithWriter(io.Discard)), 100*time.Millisecond).(*sessionMultiplexer)
+	suite.Require().NoError(mux.start(context.Background()))
+	defer func() { suite.NoError(mux.stop(context.Background())) }()
+
+	start := time.Now()
+	suite.Require().NoError(mux.InitSession(suite.conn, "slow", "tcp", suite.slow, false, tunnelnet.Timeouts{}))
+
+	select {
+	case df := <-suite.conn.frames:
+		suite.Less(time.Since(start), 5*time.Second)
+		suite.Equal("slow", df.SessionID)
+		suite.Require().NotNil(df.CloseConn)
+		suite.Equal(tunnelnet.CloseDialTimeout, df.CloseConn.Reason)
+	case <-time.After(5 * time.Second):
+		suite.Fail("dial did not time out")
+	}
+
+	mux.mtx.RLock()
+	defer mux.mtx.RUnlock()
+	suite.Empty(mux.dialing)
+	suite.Empty(mux.actors)
+}
+
+func (suite *MuxSuite) TestCloseSessionAbortsDial() {
+	suite.Require().NoError(suite.mux.InitSession(suite.conn, "slow", "tcp", suite.slow, false, tunnelnet.Timeouts{}))
+	suite.Require().NoError(suite.mux.CloseSession("slow", tunnelnet.CloseNormal))
+
+	suite.mux.mtx.RLock()
+	suite.Empty(suite.mux.dialing)
+	suite.mux.mtx.RUnlock()
+
+	select {
+	case df := <-suite.conn.frames:
+		suite.Failf("aborted session answered", "%+v", df)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func (suite *MuxSuite) TestHalfClose() {
+	// the backend answers only once the server finished writing
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	suite.Require().NoError(err)
+	suite.listeners = append(suite.listeners, lis)
+
+	go func() {
+		c, err := lis.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = c.Close() }()
+
+		request, err := io.ReadAll(c)
+		if err != nil {
+			return
+		}
+		_, _ = io.WriteString(c, "received "+string(request))
+	}()
+
+	suite.Require().NoError(suite.mux.InitSession(suite.conn, "session", "tcp", lis.Addr().String(), false, tunnelnet.Timeouts{}))
+	suite.Require().NoError(suite.mux.RouteMsg(&tunnelnet.DataFrame{SessionID: "session", Seq: 0, Payload: []byte("request")}))
+	suite.Require().NoError(suite.mux.RouteMsg(&tunnelnet.DataFrame{SessionID: "session", Seq: 1, Fin: true}))
+
+	next := func() *tunnelnet.DataFrame {
+		select {
+		case df := <-suite.conn.frames:
+			return df
+		case <-time.After(5 * time.Second):
+			suite.FailNow("agent stopped sending")
+			return nil
+		}
+	}
+
+	df := next()
+	suite.Equal("received request", string(df.Payload))
+
+	df = next()
+	suite.True(df.Fin)
+	suite.Equal(uint64(1), df.Seq)
+
+	// both sides finished writing
+	df = next()
+	suite.Require().NotNil(df.CloseConn)
+	suite.Equal(tunnelnet.CloseNormal, df.CloseConn.Reason)
+}
+
+func TestMuxSuite(t *testing.T) {
+	suite.Run(t, new(MuxSuite))
+}
@@ -3,6 +3,7 @@ package transport
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"net"
 
@@ -32,6 +33,20 @@ func newAuthInfo(conn *QuicConn) credentials.AuthInfo {
 	return &authInfo{conn: conn}
 }
 
+// PeerCertificate verified certificate the peer of a quic connection presented
+func PeerCertificate(info credentials.AuthInfo) (*x509.Certificate, bool) {
+	qc, ok := Connection(info)
+	if !ok {
+		return nil, false
+	}
+
+	certs := qc.ConnectionState().TLS.PeerCertificates
+	if len(certs) < 1 {
+		return nil, false
+	}
+	return certs[0], true
+}
+
 /*
 Credentials for gRPC over QUIC
 https://pkg.go.dev/google.golang.org/grpc@v1.42.0/credentials#TransportCredentials
@@ -0,0 +1,104 @@
+package transport
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/quic-go/quic-go"
+	"google.golang.org/grpc/credentials"
+)
+
+// SessionStreamsKey metadata key agents advertise their data plane mode with
+const SessionStreamsKey = "session-streams"
+
+// SessionStreamsMode metadata value of agents accepting a quic stream per session
+const SessionStreamsMode = "quic"
+
+// sessionStreamErrorCode application error code of streams aborted by Close
+const sessionStreamErrorCode quic.StreamErrorCode = 0
+
+// ErrSessionHeader stream did not start with a session header
+var ErrSessionHeader = errors.New("invalid session stream header")
+
+// Connection quic connection carrying the gRPC stream of info
+func Connection(info credentials.AuthInfo) (*quic.Conn, bool) {
+	ai, ok := info.(*authInfo)
+	if !ok || ai.conn == nil {
+		return nil, false
+	}
+	return ai.conn.connection, true
+}
+
+// sessionStream quic stream of a single tunnel session
+type sessionStream struct {
+	*quic.Stream
+}
+
+// Close implements io.Closer.
+func (s *sessionStream) Close() error {
+	// release the receive side as well, quic.Stream.Close only ends the send side
+	s.CancelRead(sessionStreamErrorCode)
+	return s.Stream.Close()
+}
+
+// CloseWrite implements net.CloseWriter.
+func (s *sessionStream) CloseWrite() error {
+	// ends the send side only, the peer reads io.EOF
+	return s.Stream.Close()
+}
+
+// OpenSessionStream open a dedicated stream for sessionID, the peer pairs it with
+// the session using the header written before any payload
+func OpenSessionStream(ctx context.Context, conn *quic.Conn, sessionID string) (io.ReadWriteCloser, error) {
+	if len(sessionID) == 0 || len(sessionID) > 255 {
+		return nil, ErrSessionHeader
+	}
+
+	stream, err := conn.OpenStreamSync(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("conn.OpenStreamSync: %w", err)
+	}
+
+	header := append([]byte{byte(len(sessionID))}, sessionID...)
+	if _, err := stream.Write(header); err != nil {
+		stream.CancelWrite(sessionStreamErrorCode)
+		return nil, fmt.Errorf("stream.Write: %w", err)
+	}
+
+	return &sessionStream{Stream: stream}, nil
+}
+
+// AcceptSessionStream wait for the next session stream opened by the peer
+func AcceptSessionStream(ctx context.Context, conn *quic.Conn) (string, io.ReadWriteCloser, error) {
+	stream, err := conn.AcceptStream(ctx)
+	if err != nil {
+		return "", nil, fmt.Errorf("conn.AcceptStream: %w", err)
+	}
+
+	sessionID, err := readSessionHeader(stream)
+	if err != nil {
+		stream.CancelRead(sessionStreamErrorCode)
+		stream.CancelWrite(sessionStreamErrorCode)
+		return "", nil, fmt.Errorf("%w: %w", ErrSessionHeader, err)
+	}
+
+	return sessionID, &sessionStream{Stream: stream}, nil
+}
+
+func readSessionHeader(r io.Reader) (string, error) {
+	var size [1]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return "", fmt.Errorf("io.ReadFull: %w", err)
+	}
+	if size[0] == 0 {
+		return "", errors.New("empty session id")
+	}
+
+	sessionID := make([]byte, size[0])
+	if _, err := io.ReadFull(r, sessionID); err != nil {
+		return "", fmt.Errorf("io.ReadFull: %w", err)
+	}
+	return string(sessionID), nil
+}
@@ -0,0 +1,124 @@
+package transport
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"io"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/quic-go/quic-go"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+)
+
+type StreamSuite struct {
+	suite.Suite
+
+	server *grpc.Server
+	client *grpc.ClientConn
+
+	// serverConns quic connection of every stream accepted by the server
+	serverConns chan *quic.Conn
+}
+
+func (suite *StreamSuite) SetupTest() {
+	tlsConfig := suite.tlsConfig()
+
+	qlis, err := quic.ListenAddr("127.0.0.1:0", tlsConfig, nil)
+	suite.Require().NoError(err)
+
+	suite.serverConns = make(chan *quic.Conn, 1)
+	suite.server = grpc.NewServer(grpc.Creds(NewCredentials(tlsConfig)), grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if p, ok := peer.FromContext(ss.Context()); ok {
+			if qc, ok := Connection(p.AuthInfo); ok {
+				suite.serverConns <- qc
+			}
+		}
+		return handler(srv, ss)
+	}))
+	healthpb.RegisterHealthServer(suite.server, health.NewServer())
+	go func() { _ = suite.server.Serve(New(qlis)) }()
+
+	clientTLS := &tls.Config{InsecureSkipVerify: true, NextProtos: []string{"h3"}}
+	suite.client, err = grpc.NewClient(qlis.Addr().String(),
+		grpc.WithTransportCredentials(NewCredentials(clientTLS)),
+		grpc.WithContextDialer(NewQuicDialer(clientTLS)))
+	suite.Require().NoError(err)
+}
+
+func (suite *StreamSuite) TearDownTest() {
+	_ = suite.client.Close()
+	suite.server.Stop()
+}
+
+func (suite *StreamSuite) tlsConfig() *tls.Config {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().NoError(err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	suite.Require().NoError(err)
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		NextProtos:   []string{"h3"},
+	}
+}
+
+func (suite *StreamSuite) TestSessionStream() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watch, err := healthpb.NewHealthClient(suite.client).Watch(ctx, &healthpb.HealthCheckRequest{})
+	suite.Require().NoError(err)
+	_, err = watch.Recv()
+	suite.Require().NoError(err)
+
+	p, ok := peer.FromContext(watch.Context())
+	suite.Require().True(ok)
+	clientConn, ok := Connection(p.AuthInfo)
+	suite.Require().True(ok)
+
+	serverConn := <-suite.serverConns
+
+	opened, err := OpenSessionStream(ctx, serverConn, "session-1")
+	suite.Require().NoError(err)
+	defer func() { _ = opened.Close() }()
+
+	_, err = opened.Write([]byte("ping"))
+	suite.Require().NoError(err)
+
+	sessionID, accepted, err := AcceptSessionStream(ctx, clientConn)
+	suite.Require().NoError(err)
+	defer func() { _ = accepted.Close() }()
+	suite.Equal("session-1", sessionID)
+
+	b := make([]byte, 4)
+	_, err = io.ReadFull(accepted, b)
+	suite.Require().NoError(err)
+	suite.Equal("ping", string(b))
+
+	_, err = accepted.Write([]byte("pong"))
+	suite.Require().NoError(err)
+	_, err = io.ReadFull(opened, b)
+	suite.Require().NoError(err)
+	suite.Equal("pong", string(b))
+}
+
+func TestStreamSuite(t *testing.T) {
+	suite.Run(t, new(StreamSuite))
+}
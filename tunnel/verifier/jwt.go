@@ -4,41 +4,130 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"soft.structx.io/dino/auth"
 	"soft.structx.io/dino/internal/tunnel"
 )
 
+var (
+	// ErrTunnelMismatch token was issued for another tunnel than the one it authenticates
+	ErrTunnelMismatch = errors.New("token issued for another tunnel")
+	// ErrRotatedKey token is signed with rotated credentials and cannot be refreshed
+	ErrRotatedKey = errors.New("token signed with rotated credentials")
+)
+
+// Token verified tunnel token
+type Token struct {
+	Claims *auth.Claims
+	// KeyID fingerprint of the signing key the token verified with
+	KeyID string
+	// RevokeAt end of the grace period of rotated credentials the token is
+	// signed with, zero while they are current
+	RevokeAt time.Time
+}
+
 // Verifier
 type Verifier interface {
-	VerifyToken(context.Context, string, string) (*auth.Claims, error)
+	// VerifyToken claims of a valid token of the tunnel
+	VerifyToken(context.Context, string, string) (Token, error)
+	// RefreshToken new token of the tunnel for a valid token signed with its
+	// current credentials
+	RefreshToken(context.Context, string, string) (string, error)
 }
 
 type jwtVerifier struct {
 	t tunnel.Service
+	a auth.Authenticator
+
+	parser *jwt.Parser
 }
 
-func newVerifier(tunelSvc tunnel.Service) Verifier {
+func newVerifier(tunelSvc tunnel.Service, authenticator auth.Authenticator, issuer string, audience []string, lifetime time.Duration) Verifier {
+	opts := []jwt.ParserOption{
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience...),
+		jwt.WithIssuedAt(),
+	}
+	if lifetime > 0 {
+		// tokens issued before lifetimes were configured carry no expiry and
+		// are refused, not refreshed
+		opts = append(opts, jwt.WithExpirationRequired())
+	}
+
 	return &jwtVerifier{
-		t: tunelSvc,
+		t:      tunelSvc,
+		a:      authenticator,
+		parser: jwt.NewParser(opts...),
 	}
 }
 
-// VerifyToken implements Authenticator.
-func (j *jwtVerifier) VerifyToken(ctx context.Context, tunnelID, token string) (*auth.Claims, error) {
-	combinedHash, err := j.t.VerifyToken(ctx, tunnelID)
+// VerifyToken implements Verifier.
+func (j *jwtVerifier) VerifyToken(ctx context.Context, tunnelID, token string) (Token, error) {
+	keys, err := j.t.VerifyToken(ctx, tunnelID)
+	if err != nil {
+		return Token{}, fmt.Errorf("tunnelSvc.VerifyToken: %w", err)
+	}
+
+	claims, err := j.parseClaims(tunnelID, token, keys.Current)
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) && keys.Previous != nil {
+		// token issued before the last rotation, valid until the grace period ends
+		claims, err = j.parseClaims(tunnelID, token, keys.Previous)
+		if err != nil {
+			return Token{}, err
+		}
+		return Token{Claims: claims, KeyID: tunnel.KeyID(keys.Previous), RevokeAt: keys.PreviousExpiresAt}, nil
+	} else if err != nil {
+		return Token{}, err
+	}
+
+	return Token{Claims: claims, KeyID: tunnel.KeyID(keys.Current)}, nil
+}
+
+// RefreshToken implements Verifier.
+func (j *jwtVerifier) RefreshToken(ctx context.Context, tunnelID, token string) (string, error) {
+	keys, err := j.t.VerifyToken(ctx, tunnelID)
 	if err != nil {
-		return nil, fmt.Errorf("tunnelSvc.VerifyToken: %w", err)
+		return "", fmt.Errorf("tunnelSvc.VerifyToken: %w", err)
 	}
 
-	if tk, err := jwt.ParseWithClaims(token, &auth.Claims{}, func(t *jwt.Token) (any, error) {
-		return []byte(combinedHash), nil
-	}); err != nil {
+	claims, err := j.parseClaims(tunnelID, token, keys.Current)
+	if errors.Is(err, jwt.ErrTokenSignatureInvalid) && keys.Previous != nil {
+		// refreshing would outlive the grace period of the rotation
+		if _, err := j.parseClaims(tunnelID, token, keys.Previous); err == nil {
+			return "", ErrRotatedKey
+		}
+	}
+	if err != nil {
+		return "", err
+	}
+
+	refreshed, err := j.a.GenerateJWT(claims.Subject, claims.ID, string(keys.Current))
+	if err != nil {
+		return "", fmt.Errorf("auth.GenerateJWT: %w", err)
+	}
+
+	return refreshed, nil
+}
+
+// parseClaims validate token with signingKey, the token must be issued for tunnelID
+func (j *jwtVerifier) parseClaims(tunnelID, token string, signingKey []byte) (*auth.Claims, error) {
+	tk, err := j.parser.ParseWithClaims(token, &auth.Claims{}, func(t *jwt.Token) (any, error) {
+		return signingKey, nil
+	})
+	if err != nil {
 		return nil, fmt.Errorf("jwt.ParseWithClaims: %w", err)
-	} else if claims, ok := tk.Claims.(*auth.Claims); ok {
-		return claims, nil
-	} else {
+	}
+
+	claims, ok := tk.Claims.(*auth.Claims)
+	if !ok {
 		return nil, errors.New("unknown claims")
 	}
+	if claims.ID != tunnelID {
+		return nil, ErrTunnelMismatch
+	}
+
+	return claims, nil
 }
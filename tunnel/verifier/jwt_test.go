@@ -1 +1,142 @@
 package verifier
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/suite"
+	"soft.structx.io/dino/auth"
+	"soft.structx.io/dino/internal/tunnel"
+)
+
+// keyService tunnel service returning fixed signing keys
+type keyService struct {
+	tunnel.Service
+
+	keys tunnel.TokenKeys
+}
+
+// VerifyToken implements tunnel.Service.
+func (k *keyService) VerifyToken(context.Context, string) (tunnel.TokenKeys, error) {
+	return k.keys, nil
+}
+
+type VerifierSuite struct {
+	suite.Suite
+
+	svc      *keyService
+	verifier Verifier
+}
+
+func (suite *VerifierSuite) SetupTest() {
+	suite.svc = &keyService{keys: tunnel.TokenKeys{Current: []byte("current")}}
+	suite.verifier = newVerifier(suite.svc, suite, "dino.local", []string{"dino"}, time.Hour)
+}
+
+// GenerateJWT implements auth.Authenticator.
+func (suite *VerifierSuite) GenerateJWT(subject, id, signingKey string) (string, error) {
+	return suite.sign(suite.claims(id), signingKey), nil
+}
+
+func (suite *VerifierSuite) claims(id string) *auth.Claims {
+	now := time.Now()
+	return &auth.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        id,
+			Issuer:    "dino.local",
+			Subject:   "name",
+			Audience:  []string{"dino"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Hour)),
+		},
+	}
+}
+
+func (suite *VerifierSuite) sign(claims *auth.Claims, signingKey string) string {
+	ss, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(signingKey))
+	suite.Require().NoError(err)
+	return ss
+}
+
+func (suite *VerifierSuite) token(signingKey string) string {
+	return suite.sign(suite.claims("tunnel"), signingKey)
+}
+
+func (suite *VerifierSuite) TestCurrentKey() {
+	verified, err := suite.verifier.VerifyToken(context.Background(), "tunnel", suite.token("current"))
+	suite.Require().NoError(err)
+	suite.Equal("tunnel", verified.Claims.ID)
+	suite.Equal(tunnel.KeyID([]byte("current")), verified.KeyID)
+	suite.True(verified.RevokeAt.IsZero())
+}
+
+func (suite *VerifierSuite) TestPreviousKeyRevokedWithGracePeriod() {
+	graceEnd := time.Now().Add(time.Hour)
+	suite.svc.keys.Previous = []byte("previous")
+	suite.svc.keys.PreviousExpiresAt = graceEnd
+
+	verified, err := suite.verifier.VerifyToken(context.Background(), "tunnel", suite.token("previous"))
+	suite.Require().NoError(err)
+	suite.Equal(tunnel.KeyID([]byte("previous")), verified.KeyID)
+	suite.Equal(graceEnd, verified.RevokeAt)
+}
+
+func (suite *VerifierSuite) TestRevokedKey() {
+	_, err := suite.verifier.VerifyToken(context.Background(), "tunnel", suite.token("previous"))
+	suite.ErrorIs(err, jwt.ErrTokenSignatureInvalid)
+}
+
+func (suite *VerifierSuite) TestStrictClaims() {
+	_, err := suite.verifier.VerifyToken(context.Background(), "other", suite.token("current"))
+	suite.ErrorIs(err, ErrTunnelMismatch)
+
+	claims := suite.claims("tunnel")
+	claims.Audience = []string{"other"}
+	_, err = suite.verifier.VerifyToken(context.Background(), "tunnel", suite.sign(claims, "current"))
+	suite.ErrorIs(err, jwt.ErrTokenInvalidAudience)
+
+	claims = suite.claims("tunnel")
+	claims.Issuer = "other"
+	_, err = suite.verifier.VerifyToken(context.Background(), "tunnel", suite.sign(claims, "current"))
+	suite.ErrorIs(err, jwt.ErrTokenInvalidIssuer)
+
+	claims = suite.claims("tunnel")
+	claims.ExpiresAt = nil
+	_, err = suite.verifier.VerifyToken(context.Background(), "tunnel", suite.sign(claims, "current"))
+	suite.ErrorIs(err, jwt.ErrTokenRequiredClaimMissing)
+
+	claims = suite.claims("tunnel")
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute))
+	_, err = suite.verifier.VerifyToken(context.Background(), "tunnel", suite.sign(claims, "current"))
+	suite.ErrorIs(err, jwt.ErrTokenExpired)
+}
+
+func (suite *VerifierSuite) TestRefreshToken() {
+	refreshed, err := suite.verifier.RefreshToken(context.Background(), "tunnel", suite.token("current"))
+	suite.Require().NoError(err)
+
+	_, err = suite.verifier.VerifyToken(context.Background(), "tunnel", refreshed)
+	suite.NoError(err)
+}
+
+func (suite *VerifierSuite) TestRefreshWithoutExpiry() {
+	// tokens issued before lifetimes were configured need new credentials
+	claims := suite.claims("tunnel")
+	claims.ExpiresAt = nil
+	_, err := suite.verifier.RefreshToken(context.Background(), "tunnel", suite.sign(claims, "current"))
+	suite.ErrorIs(err, jwt.ErrTokenRequiredClaimMissing)
+}
+
+func (suite *VerifierSuite) TestRefreshRotatedToken() {
+	suite.svc.keys.Previous = []byte("previous")
+	suite.svc.keys.PreviousExpiresAt = time.Now().Add(time.Hour)
+
+	_, err := suite.verifier.RefreshToken(context.Background(), "tunnel", suite.token("previous"))
+	suite.ErrorIs(err, ErrRotatedKey)
+}
+
+func TestVerifierSuite(t *testing.T) {
+	suite.Run(t, new(VerifierSuite))
+}
@@ -2,14 +2,19 @@ package verifier
 
 import (
 	"go.uber.org/fx"
+	"soft.structx.io/dino/auth"
 	"soft.structx.io/dino/internal/tunnel"
+	"soft.structx.io/dino/setup"
 )
 
 // Params
 type Params struct {
 	fx.In
 
+	Cfg *setup.Authenticator
+
 	TunnelService tunnel.Service `name:"tunnel_service"`
+	Auth          auth.Authenticator
 }
 
 type Result struct {
@@ -23,6 +28,6 @@ var Module = fx.Module("verifier", fx.Provide(newModule))
 
 func newModule(p Params) Result {
 	return Result{
-		Verifier: newVerifier(p.TunnelService),
+		Verifier: newVerifier(p.TunnelService, p.Auth, p.Cfg.JWT.Issuer, p.Cfg.JWT.Audience, p.Cfg.JWT.Lifetime()),
 	}
 }